@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/agent"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// QueryAgents query the remote agents connected to the server.
+func QueryAgents(ctx *fiber.Ctx) error {
+	sessions := []agent.Session{}
+	if global.Agents != nil {
+		sessions = global.Agents.Sessions()
+	}
+
+	return ctx.JSON(response.Success("Success", sessions, nil))
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/notify"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryChannels query notification channels list.
+func QueryChannels(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	channels := make([]*dao.Channel, paginator.GetLimit())
+
+	if value := ctx.Query("type"); value != "" {
+		filter = append(filter, bson.E{Key: "type", Value: value})
+	}
+
+	collection := mongodb.Database.Collection(mongodb.ChannelCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all channels.
+	if err = cursor.All(global.Ctx, &channels); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	for i, channel := range channels {
+		channels[i] = channel.Redacted()
+	}
+
+	return ctx.JSON(response.Success("Success", channels, paginator))
+}
+
+// GetChannel get notification channel by id.
+func GetChannel(ctx *fiber.Ctx) error {
+	channel, err := findChannel(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Channel not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", channel.Redacted(), nil))
+}
+
+// CreateChannel create notification channel.
+func CreateChannel(ctx *fiber.Ctx) error {
+	channel := &dao.Channel{}
+	if err := ctx.BodyParser(channel); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err := channel.Channel(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid channel.", err))
+	}
+
+	if channel.ID == "" {
+		channel.ID = primitive.NewObjectID().Hex()
+	}
+
+	channel.CreatedAt = time.Now()
+	channel.UpdatedAt = channel.CreatedAt
+
+	_, err := mongodb.Database.Collection(mongodb.ChannelCollection).InsertOne(global.Ctx, channel)
+	if mongo.IsDuplicateKeyError(err) {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Channel already exists.", err))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadChannels()
+
+	return ctx.JSON(response.Success("Success", channel.Redacted(), nil))
+}
+
+// UpdateChannel replace notification channel, the credentials omitted are kept.
+func UpdateChannel(ctx *fiber.Ctx) error {
+	channel := &dao.Channel{}
+	if err := ctx.BodyParser(channel); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	existing, err := findChannel(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Channel not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	channel.KeepCredentials(existing)
+
+	if _, err = channel.Channel(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid channel.", err))
+	}
+
+	channel.ID = existing.ID
+	channel.CreatedAt = existing.CreatedAt
+	channel.UpdatedAt = time.Now()
+
+	if _, err = mongodb.Database.Collection(mongodb.ChannelCollection).ReplaceOne(global.Ctx, bson.D{{Key: "_id", Value: channel.ID}}, channel); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadChannels()
+
+	return ctx.JSON(response.Success("Success", channel.Redacted(), nil))
+}
+
+// DeleteChannel delete notification channel.
+func DeleteChannel(ctx *fiber.Ctx) error {
+	result, err := mongodb.Database.Collection(mongodb.ChannelCollection).DeleteOne(global.Ctx, bson.D{{Key: "_id", Value: ctx.Params("id")}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if result.DeletedCount == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Channel not found."))
+	}
+
+	reloadChannels()
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+// TestChannel send a test alert to the channel, including the channels of the configuration.
+func TestChannel(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	channel, ok := global.Notifications.Channel(id)
+	if !ok {
+		doc, err := findChannel(id)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Channel not found."))
+		}
+
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+		}
+
+		if channel, err = doc.Channel(); err != nil {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid channel.", err))
+		}
+	}
+
+	alert := &notify.Alert{
+		CheckID:   "orbit-test",
+		CheckName: "Orbit test alert",
+		Status:    checker.HealthCritical,
+		Previous:  checker.HealthPassing,
+		Output:    "This is a test alert sent from Orbit.",
+		Time:      time.Now(),
+		Test:      true,
+	}
+
+	notification := notify.NewNotification(channel.ID, "orbit-test", nil, []*notify.Alert{alert})
+	notification.Test = true
+
+	if err := global.Notifications.Deliver(global.Ctx, channel, notification); err != nil {
+		return ctx.Status(fiber.StatusBadGateway).JSON(response.Send(fiber.StatusBadGateway, "Failed to send the test alert.", err.Error()))
+	}
+
+	return ctx.JSON(response.Success("Success", notification, nil))
+}
+
+// QueryDeliveries query the notification deliveries, the latest first.
+func QueryDeliveries(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	deliveries := make([]*dao.Delivery, paginator.GetLimit())
+
+	for key, field := range map[string]string{"channel_id": "channel_id", "receiver": "receiver", "check_id": "check_ids", "status": "status"} {
+		if value := ctx.Query(key); value != "" {
+			filter = append(filter, bson.E{Key: field, Value: value})
+		}
+	}
+
+	if value := ctx.Query("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid success.", err))
+		}
+		filter = append(filter, bson.E{Key: "success", Value: success})
+	}
+
+	collection := mongodb.Database.Collection(mongodb.DeliveryCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all deliveries.
+	if err = cursor.All(global.Ctx, &deliveries); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", deliveries, paginator))
+}
+
+func findChannel(id string) (*dao.Channel, error) {
+	channel := &dao.Channel{}
+	err := mongodb.Database.Collection(mongodb.ChannelCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: id}}).Decode(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+func reloadChannels() {
+	if global.Notifications == nil {
+		return
+	}
+
+	if err := global.Notifications.Reload(global.Ctx); err != nil {
+		journal.Logger.Errorw("Failed to reload notification channels", "error", err)
+	}
+}
@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ttlUpdate struct {
+	Note string `json:"note" query:"note"`
+}
+
+type uptimeWindow struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// QueryChecks query checks list.
+func QueryChecks(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	checks := make([]*dao.Check, paginator.GetLimit())
+
+	for _, key := range []string{"node", "service_id", "service_name"} {
+		if value := ctx.Query(key); value != "" {
+			filter = append(filter, bson.E{Key: key, Value: value})
+		}
+	}
+
+	collection := mongodb.Database.Collection(mongodb.CheckCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all checks.
+	if err = cursor.All(global.Ctx, &checks); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	setFlapping(checks...)
+
+	return ctx.JSON(response.Success("Success", checks, paginator))
+}
+
+// GetCheck get check by id.
+func GetCheck(ctx *fiber.Ctx) error {
+	check := &dao.Check{}
+	err := mongodb.Database.Collection(mongodb.CheckCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: ctx.Params("id")}}).Decode(check)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Check not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	setFlapping(check)
+
+	return ctx.JSON(response.Success("Success", check, nil))
+}
+
+// CreateCheck create check and start running it.
+func CreateCheck(ctx *fiber.Ctx) error {
+	check := &dao.Check{}
+	if err := ctx.BodyParser(check); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	chkType, err := validateCheck(check)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid check definition.", err))
+	}
+
+	if check.ID == "" {
+		check.ID = primitive.NewObjectID().Hex()
+	}
+
+	check.CreatedAt = time.Now()
+	check.UpdatedAt = check.CreatedAt
+
+	_, err = mongodb.Database.Collection(mongodb.CheckCollection).InsertOne(global.Ctx, check)
+	if mongo.IsDuplicateKeyError(err) {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Check already exists.", err))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if err = startCheck(check, chkType); err != nil {
+		// The check is not created, so that the request can be retried.
+		if _, removeErr := mongodb.RemoveCheck(global.Ctx, check.ID); removeErr != nil {
+			journal.Logger.Errorw("Failed to delete the check which failed to start", "check", check.ID, "error", removeErr)
+		}
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Failed to start check.", err))
+	}
+
+	return ctx.JSON(response.Success("Success", check, nil))
+}
+
+// UpdateCheck replace the check definition and restart the check.
+func UpdateCheck(ctx *fiber.Ctx) error {
+	check := &dao.Check{}
+	if err := ctx.BodyParser(check); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	chkType, err := validateCheck(check)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid check definition.", err))
+	}
+
+	collection := mongodb.Database.Collection(mongodb.CheckCollection)
+	filter := bson.D{{Key: "_id", Value: ctx.Params("id")}}
+
+	existing := &dao.Check{}
+	err = collection.FindOne(global.Ctx, filter).Decode(existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Check not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	check.ID = existing.ID
+	check.CreatedAt = existing.CreatedAt
+	check.UpdatedAt = time.Now()
+
+	if _, err = collection.ReplaceOne(global.Ctx, filter, check); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if err = startCheck(check, chkType); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Failed to start check.", err))
+	}
+
+	return ctx.JSON(response.Success("Success", check, nil))
+}
+
+// DeleteCheck stop the check and delete its definition.
+func DeleteCheck(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	result, err := mongodb.Database.Collection(mongodb.CheckCollection).DeleteOne(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if result.DeletedCount == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Check not found."))
+	}
+
+	global.Checks.Remove(id)
+
+	if _, err = mongodb.Database.Collection(mongodb.TTLStateCollection).DeleteOne(global.Ctx, filter); err != nil {
+		journal.Logger.Errorw("Failed to delete TTL check state", "check", id, "error", err)
+	}
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+// GetCheckUptime compute the availability of the check over a time window,
+// the last 30 days by default.
+func GetCheckUptime(ctx *fiber.Ctx) error {
+	window := &uptimeWindow{}
+	if err := ctx.QueryParser(window); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	to, err := parseTime(window.To, time.Now())
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid to time.", err))
+	}
+
+	from, err := parseTime(window.From, to.Add(-30*24*time.Hour))
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid from time.", err))
+	}
+
+	if !from.Before(to) {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid time window.", errors.New("from must be before to")))
+	}
+
+	id := ctx.Params("id")
+	count, err := mongodb.Database.Collection(mongodb.CheckCollection).CountDocuments(global.Ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if count == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Check not found."))
+	}
+
+	uptime, err := mongodb.Uptime(global.Ctx, id, from, to)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", uptime, nil))
+}
+
+// GetCheckLocations get the status of the remote check in each of its locations.
+func GetCheckLocations(ctx *fiber.Ctx) error {
+	check, ok := global.State.Check(ctx.Params("id"))
+	if !ok {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Check not found."))
+	}
+
+	locations := check.Locations
+	if locations == nil {
+		locations = []*checker.LocationStatus{}
+	}
+
+	return ctx.JSON(response.Success("Success", locations, nil))
+}
+
+// PassCheck mark the TTL check as passing.
+func PassCheck(ctx *fiber.Ctx) error {
+	return updateTTL(ctx, checker.HealthPassing)
+}
+
+// WarnCheck mark the TTL check as warning.
+func WarnCheck(ctx *fiber.Ctx) error {
+	return updateTTL(ctx, checker.HealthWarning)
+}
+
+// FailCheck mark the TTL check as critical.
+func FailCheck(ctx *fiber.Ctx) error {
+	return updateTTL(ctx, checker.HealthCritical)
+}
+
+func updateTTL(ctx *fiber.Ctx, status string) error {
+	update := &ttlUpdate{}
+	if err := ctx.QueryParser(update); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	// The note can also be sent in the request body.
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(update); err != nil {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+		}
+	}
+
+	id := ctx.Params("id")
+	check, ok := checker.TTLChecks.Get(id)
+	if ok {
+		check.SetStatus(status, update.Note)
+		return ctx.JSON(response.Success("Success", nil, nil))
+	}
+
+	// The check is run by another server of the cluster, which picks up
+	// the persisted status within checker.TTLPollInterval.
+	def, ok := global.Checks.Definition(id)
+	if !ok || !def.Type.IsTTL() {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("TTL check not found."))
+	}
+
+	state := &checker.TTLState{Status: status, Output: update.Note, UpdatedAt: time.Now()}
+	if err := mongodb.NewTTLStore().SaveTTL(id, state); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+// validateCheck converts the check definition and makes sure it can be run.
+func validateCheck(check *dao.Check) (*checker.CheckType, error) {
+	chkType, err := check.CheckType()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = chkType.Validate(); err != nil {
+		return nil, err
+	}
+
+	if chkType.Interval > 0 && chkType.Interval < checker.MinInterval {
+		return nil, fmt.Errorf("interval must be at least %s", checker.MinInterval)
+	}
+
+	return chkType, nil
+}
+
+// startCheck starts the check, or restarts it if it already exists, e.g.
+// when the change of the store was already applied.
+func startCheck(check *dao.Check, chkType *checker.CheckType) error {
+	if _, exists := global.Checks.Definition(check.ID); exists {
+		return global.Checks.Update(check.HealthCheck(), chkType)
+	}
+
+	return global.Checks.Add(check.HealthCheck(), chkType)
+}
+
+// setFlapping flags the checks which are flapping, according to the state.
+func setFlapping(checks ...*dao.Check) {
+	for _, check := range checks {
+		if health, ok := global.State.Check(check.ID); ok {
+			check.Flapping = health.Flapping
+		}
+	}
+}
+
+// parseTime parses a RFC 3339 time, returning the fallback if the value is empty.
+func parseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
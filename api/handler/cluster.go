@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/cluster"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type clusterStatus struct {
+	Enabled bool              `json:"enabled"`
+	Self    string            `json:"self"`
+	Leader  bool              `json:"leader"`
+	Members []*cluster.Member `json:"members"`
+}
+
+// GetCluster get the members of the cluster, as seen by this server.
+func GetCluster(ctx *fiber.Ctx) error {
+	status := &clusterStatus{Members: []*cluster.Member{}}
+	if global.Cluster != nil {
+		status.Enabled = true
+		status.Self = global.Cluster.ID()
+		status.Leader = global.Cluster.IsLeader()
+		if members := global.Cluster.Members(); members != nil {
+			status.Members = members
+		}
+	}
+
+	return ctx.JSON(response.Success("Success", status, nil))
+}
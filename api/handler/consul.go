@@ -0,0 +1,505 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The handlers of this file implement a subset of the Consul HTTP API,
+// so that existing Consul tooling can register services and checks in Orbit.
+// Like Consul, errors are returned as plain text.
+
+// consulDuration accepts a duration either as a Go duration string or in nanoseconds.
+type consulDuration time.Duration
+
+func (d *consulDuration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		*d = consulDuration(v)
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = consulDuration(duration)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+
+	return nil
+}
+
+func (d consulDuration) String() string {
+	if d == 0 {
+		return ""
+	}
+
+	return time.Duration(d).String()
+}
+
+// consulCheck is the check definition of the Consul agent API.
+type consulCheck struct {
+	ID                             string
+	CheckID                        string
+	Name                           string
+	Notes                          string
+	ServiceID                      string
+	Status                         string
+	ScriptArgs                     []string
+	Shell                          string
+	HTTP                           string
+	Header                         map[string][]string
+	Method                         string
+	Body                           string
+	DisableRedirects               bool
+	H2PING                         string
+	H2PingUseTLS                   bool
+	TCP                            string
+	TCPUseTLS                      bool
+	UDP                            string
+	GRPC                           string
+	GRPCUseTLS                     bool
+	AliasNode                      string
+	AliasService                   string
+	TLSServerName                  string
+	TLSSkipVerify                  bool
+	Interval                       consulDuration
+	Timeout                        consulDuration
+	TTL                            consulDuration
+	DeregisterCriticalServiceAfter consulDuration
+	SuccessBeforePassing           int
+	FailuresBeforeWarning          int
+	FailuresBeforeCritical         int
+	OutputMaxSize                  int
+}
+
+// consulService is the service definition of the Consul agent API.
+type consulService struct {
+	ID      string
+	Name    string
+	Tags    []string
+	Address string
+	Port    int
+	Meta    map[string]string
+	Check   *consulCheck
+	Checks  []*consulCheck
+}
+
+type consulNode struct {
+	ID              string
+	Node            string
+	Address         string
+	Datacenter      string
+	TaggedAddresses map[string]string
+	Meta            map[string]string
+	CreateIndex     uint64
+	ModifyIndex     uint64
+}
+
+type consulAgentService struct {
+	ID                string
+	Service           string
+	Tags              []string
+	Address           string
+	Meta              map[string]string
+	Port              int
+	EnableTagOverride bool
+	CreateIndex       uint64
+	ModifyIndex       uint64
+}
+
+// consulHealthCheck is a check as reported by the Consul health endpoints,
+// without the fields Orbit adds to the checks.
+type consulHealthCheck struct {
+	Node        string
+	CheckID     string
+	Name        string
+	Notes       string
+	Status      string
+	Output      string
+	ServiceID   string
+	ServiceName string
+	ServiceTags []string
+	Type        string
+	Namespace   string `json:",omitempty"`
+	Partition   string `json:",omitempty"`
+	ExposedPort int
+	PeerName    string `json:",omitempty"`
+
+	Definition checker.HealthCheckDefinition
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+type consulServiceEntry struct {
+	Node    *consulNode
+	Service *consulAgentService
+	Checks  []*consulHealthCheck
+}
+
+// ConsulRegisterCheck register a check on the local agent.
+func ConsulRegisterCheck(ctx *fiber.Ctx) error {
+	definition := &consulCheck{}
+	if err := json.Unmarshal(ctx.Body(), definition); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Request decode failed: %v", err))
+	}
+
+	check := definition.toCheck()
+	if check.Name == "" {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Missing check name")
+	}
+
+	if check.ID == "" {
+		check.ID = check.Name
+	}
+
+	check.Node = consulNodeName()
+	if check.ServiceID != "" {
+		service, err := findService(check.ServiceID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ctx.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("ServiceID %q does not exist", check.ServiceID))
+		}
+
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+
+		check.Node = service.Node
+		check.ServiceName = service.Name
+		check.ServiceTags = service.Tags
+	}
+
+	chkType, err := validateCheck(check)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Invalid check: %v", err))
+	}
+
+	err = mongodb.SaveCheck(global.Ctx, check)
+	if errors.Is(err, mongodb.ErrCheckTaken) {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	if err = startCheck(check, chkType); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Failed to start check: %v", err))
+	}
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// ConsulDeregisterCheck deregister a check from the local agent.
+func ConsulDeregisterCheck(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	found, err := mongodb.RemoveCheck(global.Ctx, id)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	if !found {
+		return ctx.Status(fiber.StatusNotFound).SendString(fmt.Sprintf("Unknown check ID %q. Ensure that the check ID is passed, not the check name.", id))
+	}
+
+	global.Checks.Remove(id)
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// ConsulRegisterService register a service and its checks on the local agent.
+func ConsulRegisterService(ctx *fiber.Ctx) error {
+	definition := &consulService{}
+	if err := json.Unmarshal(ctx.Body(), definition); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Request decode failed: %v", err))
+	}
+
+	if definition.Name == "" {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Missing service name")
+	}
+
+	if definition.ID == "" {
+		definition.ID = definition.Name
+	}
+
+	registration := &dao.ServiceRegistration{
+		Service: dao.Service{
+			ID:      definition.ID,
+			Name:    definition.Name,
+			Node:    consulNodeName(),
+			Address: definition.Address,
+			Port:    definition.Port,
+			Tags:    definition.Tags,
+			Meta:    definition.Meta,
+		},
+	}
+
+	definitions := definition.Checks
+	if definition.Check != nil {
+		definitions = append([]*consulCheck{definition.Check}, definitions...)
+	}
+
+	for _, def := range definitions {
+		registration.Checks = append(registration.Checks, def.toCheck())
+	}
+
+	chkTypes, err := prepareService(registration)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Invalid service: %v", err))
+	}
+
+	err = saveService(registration, chkTypes)
+	if errors.Is(err, mongodb.ErrCheckTaken) {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// ConsulDeregisterService deregister a service and its checks from the local agent.
+func ConsulDeregisterService(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	if _, err := findService(id); errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).SendString(fmt.Sprintf("Unknown service ID %q. Ensure that the service ID is passed, not the service name.", id))
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	checkIDs, err := mongodb.RemoveService(global.Ctx, id)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	for _, checkID := range checkIDs {
+		global.Checks.Remove(checkID)
+	}
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// ConsulServiceHealth list the instances of a service with their node and checks.
+// Supports the "passing" and "tag" query parameters.
+func ConsulServiceHealth(ctx *fiber.Ctx) error {
+	filter := bson.D{{Key: "name", Value: ctx.Params("name")}}
+	if tag := ctx.Query("tag"); tag != "" {
+		filter = append(filter, bson.E{Key: "tags", Value: tag})
+	}
+
+	cursor, err := mongodb.Database.Collection(mongodb.ServiceCollection).Find(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	var services []*dao.Service
+	if err = cursor.All(global.Ctx, &services); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	// Like Consul, "?passing" without a value enables the filter.
+	passing := ctx.Context().QueryArgs().Has("passing")
+	if value := ctx.Query("passing"); value != "" {
+		if passing, err = strconv.ParseBool(value); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).SendString("Invalid value for ?passing")
+		}
+	}
+
+	checks := global.State.Checks()
+	nodes := make(map[string]*consulNode)
+	entries := make([]*consulServiceEntry, 0, len(services))
+	for _, service := range services {
+		node, ok := nodes[service.Node]
+		if !ok {
+			if node, err = findConsulNode(service.Node); err != nil {
+				return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+			}
+			nodes[service.Node] = node
+		}
+
+		entry := &consulServiceEntry{
+			Node: node,
+			Service: &consulAgentService{
+				ID:      service.ID,
+				Service: service.Name,
+				Tags:    nonNilTags(service.Tags),
+				Address: service.Address,
+				Meta:    service.Meta,
+				Port:    service.Port,
+			},
+			Checks: []*consulHealthCheck{},
+		}
+
+		// Like Consul, the node checks are part of the health of its services.
+		var serviceChecks []*checker.HealthCheck
+		for _, check := range checks {
+			if check.Node == service.Node && (check.ServiceID == "" || check.ServiceID == service.ID) {
+				serviceChecks = append(serviceChecks, check)
+				entry.Checks = append(entry.Checks, toConsulHealthCheck(check))
+			}
+		}
+
+		if passing && checker.AggregateStatus(serviceChecks) != checker.HealthPassing {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return ctx.JSON(entries)
+}
+
+// ConsulServiceChecks list the checks of all instances of a service.
+func ConsulServiceChecks(ctx *fiber.Ctx) error {
+	name := ctx.Params("service")
+
+	checks := []*consulHealthCheck{}
+	for _, check := range global.State.Checks() {
+		if check.ServiceName == name {
+			checks = append(checks, toConsulHealthCheck(check))
+		}
+	}
+
+	return ctx.JSON(checks)
+}
+
+func toConsulHealthCheck(check *checker.HealthCheck) *consulHealthCheck {
+	return &consulHealthCheck{
+		Node:        check.Node,
+		CheckID:     check.CheckID,
+		Name:        check.Name,
+		Notes:       check.Notes,
+		Status:      check.Status,
+		Output:      check.Output,
+		ServiceID:   check.ServiceID,
+		ServiceName: check.ServiceName,
+		ServiceTags: check.ServiceTags,
+		Type:        check.Type,
+		Namespace:   check.Namespace,
+		Partition:   check.Partition,
+		ExposedPort: check.ExposedPort,
+		PeerName:    check.PeerName,
+		Definition:  check.Definition,
+		CreateIndex: check.CreateIndex,
+		ModifyIndex: check.ModifyIndex,
+	}
+}
+
+// toCheck converts the Consul check definition to a check document.
+func (c *consulCheck) toCheck() *dao.Check {
+	id := c.ID
+	if id == "" {
+		id = c.CheckID
+	}
+
+	return &dao.Check{
+		ID:        id,
+		Name:      c.Name,
+		Notes:     c.Notes,
+		ServiceID: c.ServiceID,
+		Status:    c.Status,
+		Definition: dao.CheckDefinition{
+			ScriptArgs:                     c.ScriptArgs,
+			Shell:                          c.Shell,
+			HTTP:                           c.HTTP,
+			Header:                         c.Header,
+			Method:                         c.Method,
+			Body:                           c.Body,
+			DisableRedirects:               c.DisableRedirects,
+			H2PING:                         c.H2PING,
+			H2PingUseTLS:                   c.H2PingUseTLS,
+			TCP:                            c.TCP,
+			TCPUseTLS:                      c.TCPUseTLS,
+			UDP:                            c.UDP,
+			GRPC:                           c.GRPC,
+			GRPCUseTLS:                     c.GRPCUseTLS,
+			AliasNode:                      c.AliasNode,
+			AliasService:                   c.AliasService,
+			TLSServerName:                  c.TLSServerName,
+			TLSSkipVerify:                  c.TLSSkipVerify,
+			Interval:                       c.Interval.String(),
+			Timeout:                        c.Timeout.String(),
+			TTL:                            c.TTL.String(),
+			SuccessBeforePassing:           c.SuccessBeforePassing,
+			FailuresBeforeWarning:          c.FailuresBeforeWarning,
+			FailuresBeforeCritical:         c.FailuresBeforeCritical,
+			DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter.String(),
+			OutputMaxSize:                  c.OutputMaxSize,
+		},
+	}
+}
+
+// findConsulNode returns the node in the Consul format, the node is
+// reported without address if it is not in the catalog.
+func findConsulNode(id string) (*consulNode, error) {
+	node := &dao.Node{}
+	err := mongodb.Database.Collection(mongodb.NodeCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: id}}).Decode(node)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	return &consulNode{
+		ID:              id,
+		Node:            id,
+		Address:         node.Address,
+		Datacenter:      consulDatacenter(),
+		TaggedAddresses: map[string]string{},
+		Meta:            node.Meta,
+	}, nil
+}
+
+// consulNodeName returns the node the agent endpoints register services on,
+// the host name by default.
+func consulNodeName() string {
+	if name := viper.GetString("consul.node_name"); name != "" {
+		return name
+	}
+
+	name, err := os.Hostname()
+	if err != nil {
+		return "orbit"
+	}
+
+	return name
+}
+
+func consulDatacenter() string {
+	if dc := viper.GetString("consul.datacenter"); dc != "" {
+		return dc
+	}
+
+	return "dc1"
+}
+
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
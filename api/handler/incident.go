@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/incident"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// incidentAction is the body of the actions of the users on an incident.
+type incidentAction struct {
+	User     string `json:"user"`
+	Assignee string `json:"assignee"`
+	Message  string `json:"message"`
+}
+
+// QueryIncidents query incidents list without their timeline, the latest first.
+func QueryIncidents(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	incidents := make([]*incident.Incident, paginator.GetLimit())
+
+	for key, field := range map[string]string{"status": "status", "check_id": "check_ids", "service_id": "service_id", "node": "node", "assigned_to": "assigned_to"} {
+		if value := ctx.Query(key); value != "" {
+			filter = append(filter, bson.E{Key: field, Value: value})
+		}
+	}
+
+	if ctx.QueryBool("open") && ctx.Query("status") == "" {
+		filter = append(filter, bson.E{Key: "status", Value: bson.D{{Key: "$ne", Value: incident.StatusResolved}}})
+	}
+
+	collection := mongodb.Database.Collection(mongodb.IncidentCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().
+		SetProjection(bson.D{{Key: "timeline", Value: 0}}).
+		SetSort(bson.D{{Key: "opened_at", Value: -1}}).
+		SetLimit(paginator.GetLimit()).
+		SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all incidents.
+	if err = cursor.All(global.Ctx, &incidents); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", incidents, paginator))
+}
+
+// GetIncident get incident by id, with its timeline.
+func GetIncident(ctx *fiber.Ctx) error {
+	doc, err := mongodb.NewIncidentStore().Get(global.Ctx, ctx.Params("id"))
+
+	return incidentResponse(ctx, doc, err)
+}
+
+// AcknowledgeIncident acknowledge incident.
+func AcknowledgeIncident(ctx *fiber.Ctx) error {
+	action, err := parseIncidentAction(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid request body.", err))
+	}
+
+	doc, err := global.Incidents.Acknowledge(global.Ctx, ctx.Params("id"), action.User)
+
+	return incidentResponse(ctx, doc, err)
+}
+
+// AssignIncident assign incident to the assignee.
+func AssignIncident(ctx *fiber.Ctx) error {
+	action, err := parseIncidentAction(ctx)
+	if err == nil && action.Assignee == "" {
+		err = errors.New("assignee is required")
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid request body.", err))
+	}
+
+	doc, err := global.Incidents.Assign(global.Ctx, ctx.Params("id"), action.User, action.Assignee)
+
+	return incidentResponse(ctx, doc, err)
+}
+
+// CommentIncident add comment to the timeline of the incident.
+func CommentIncident(ctx *fiber.Ctx) error {
+	action, err := parseIncidentAction(ctx)
+	if err == nil && action.Message == "" {
+		err = errors.New("message is required")
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid request body.", err))
+	}
+
+	doc, err := global.Incidents.Comment(global.Ctx, ctx.Params("id"), action.User, action.Message)
+
+	return incidentResponse(ctx, doc, err)
+}
+
+// ResolveIncident resolve incident.
+func ResolveIncident(ctx *fiber.Ctx) error {
+	action, err := parseIncidentAction(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid request body.", err))
+	}
+
+	doc, err := global.Incidents.Resolve(global.Ctx, ctx.Params("id"), action.User, action.Message)
+
+	return incidentResponse(ctx, doc, err)
+}
+
+func parseIncidentAction(ctx *fiber.Ctx) (*incidentAction, error) {
+	action := &incidentAction{}
+	if err := ctx.BodyParser(action); err != nil {
+		return nil, err
+	}
+
+	if action.User == "" {
+		return nil, errors.New("user is required")
+	}
+
+	return action, nil
+}
+
+// incidentResponse returns the incident, or the response matching the error.
+func incidentResponse(ctx *fiber.Ctx, doc *incident.Incident, err error) error {
+	switch {
+	case err == nil:
+		return ctx.JSON(response.Success("Success", doc, nil))
+	case errors.Is(err, incident.ErrNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Incident not found."))
+	case errors.Is(err, incident.ErrResolved), errors.Is(err, incident.ErrAcknowledged), errors.Is(err, incident.ErrConflict):
+		return ctx.Status(fiber.StatusConflict).JSON(response.Send(fiber.StatusConflict, err.Error(), nil))
+	default:
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+}
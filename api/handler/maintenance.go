@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryMaintenances query maintenance windows list.
+func QueryMaintenances(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	maintenances := make([]*dao.Maintenance, paginator.GetLimit())
+
+	for key, field := range map[string]string{"check_id": "check_ids", "service_id": "service_ids", "tag": "tags"} {
+		if value := ctx.Query(key); value != "" {
+			filter = append(filter, bson.E{Key: field, Value: value})
+		}
+	}
+
+	collection := mongodb.Database.Collection(mongodb.MaintenanceCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all maintenance windows.
+	if err = cursor.All(global.Ctx, &maintenances); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", maintenances, paginator))
+}
+
+// GetMaintenance get maintenance window by id.
+func GetMaintenance(ctx *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	maintenance := &dao.Maintenance{}
+	err = mongodb.Database.Collection(mongodb.MaintenanceCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: id}}).Decode(maintenance)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", maintenance, nil))
+}
+
+// CreateMaintenance create maintenance window.
+func CreateMaintenance(ctx *fiber.Ctx) error {
+	maintenance := &dao.Maintenance{}
+	if err := ctx.BodyParser(maintenance); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err := maintenance.Window(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid maintenance window.", err))
+	}
+
+	maintenance.ID = primitive.NewObjectID()
+	maintenance.CreatedAt = time.Now()
+	maintenance.UpdatedAt = maintenance.CreatedAt
+
+	if _, err := mongodb.Database.Collection(mongodb.MaintenanceCollection).InsertOne(global.Ctx, maintenance); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadMaintenance()
+
+	return ctx.JSON(response.Success("Success", maintenance, nil))
+}
+
+// UpdateMaintenance replace maintenance window.
+func UpdateMaintenance(ctx *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	maintenance := &dao.Maintenance{}
+	if err = ctx.BodyParser(maintenance); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err = maintenance.Window(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid maintenance window.", err))
+	}
+
+	collection := mongodb.Database.Collection(mongodb.MaintenanceCollection)
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	existing := &dao.Maintenance{}
+	err = collection.FindOne(global.Ctx, filter).Decode(existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	maintenance.ID = existing.ID
+	maintenance.CreatedAt = existing.CreatedAt
+	maintenance.UpdatedAt = time.Now()
+
+	if _, err = collection.ReplaceOne(global.Ctx, filter, maintenance); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadMaintenance()
+
+	return ctx.JSON(response.Success("Success", maintenance, nil))
+}
+
+// DeleteMaintenance delete maintenance window, the checks it covers leave maintenance.
+func DeleteMaintenance(ctx *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	result, err := mongodb.Database.Collection(mongodb.MaintenanceCollection).DeleteOne(global.Ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if result.DeletedCount == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Maintenance not found."))
+	}
+
+	reloadMaintenance()
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+func reloadMaintenance() {
+	if global.Maintenance != nil {
+		global.Maintenance.Reload()
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryNodes query nodes list.
+func QueryNodes(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	nodes := make([]*dao.Node, paginator.GetLimit())
+
+	collection := mongodb.Database.Collection(mongodb.NodeCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all nodes.
+	if err = cursor.All(global.Ctx, &nodes); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", nodes, paginator))
+}
+
+// GetNode get node by id.
+func GetNode(ctx *fiber.Ctx) error {
+	node := &dao.Node{}
+	err := mongodb.Database.Collection(mongodb.NodeCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: ctx.Params("id")}}).Decode(node)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Node not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", node, nil))
+}
+
+// RegisterNode create or replace the node.
+func RegisterNode(ctx *fiber.Ctx) error {
+	node := &dao.Node{}
+	if err := ctx.BodyParser(node); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	node.ID = ctx.Params("id")
+
+	if err := mongodb.SaveNode(global.Ctx, node); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", node, nil))
+}
+
+// DeregisterNode delete the node, its services and stop all their checks.
+func DeregisterNode(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	count, err := mongodb.Database.Collection(mongodb.NodeCollection).CountDocuments(global.Ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if count == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Node not found."))
+	}
+
+	checkIDs, err := mongodb.RemoveNode(global.Ctx, id)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	for _, checkID := range checkIDs {
+		global.Checks.Remove(checkID)
+	}
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetOnCallNow get the users on call now in every schedule, ?team_id= for the schedules of a team.
+func GetOnCallNow(ctx *fiber.Ctx) error {
+	shifts, err := global.Escalations.OnCall(global.Ctx, ctx.Query("team_id"), time.Now())
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", shifts, nil))
+}
+
+// QuerySchedules query on-call schedules list.
+func QuerySchedules(ctx *fiber.Ctx) error {
+	filter, paginator, err := teamFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	schedules := make([]*dao.Schedule, paginator.GetLimit())
+	collection := mongodb.Database.Collection(mongodb.ScheduleCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all schedules.
+	if err = cursor.All(global.Ctx, &schedules); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", schedules, paginator))
+}
+
+// GetSchedule get on-call schedule by id.
+func GetSchedule(ctx *fiber.Ctx) error {
+	schedule := &dao.Schedule{}
+	err := findByObjectID(mongodb.ScheduleCollection, ctx.Params("id"), schedule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Schedule not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", schedule, nil))
+}
+
+// CreateSchedule create on-call schedule.
+func CreateSchedule(ctx *fiber.Ctx) error {
+	schedule := &dao.Schedule{}
+	if err := ctx.BodyParser(schedule); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err := schedule.Schedule(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid schedule.", err))
+	}
+
+	schedule.ID = primitive.NewObjectID()
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = schedule.CreatedAt
+
+	if _, err := mongodb.Database.Collection(mongodb.ScheduleCollection).InsertOne(global.Ctx, schedule); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadEscalations()
+
+	return ctx.JSON(response.Success("Success", schedule, nil))
+}
+
+// UpdateSchedule replace on-call schedule, including its overrides.
+func UpdateSchedule(ctx *fiber.Ctx) error {
+	existing := &dao.Schedule{}
+	err := findByObjectID(mongodb.ScheduleCollection, ctx.Params("id"), existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Schedule not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	schedule := &dao.Schedule{}
+	if err = ctx.BodyParser(schedule); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err = schedule.Schedule(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid schedule.", err))
+	}
+
+	schedule.ID = existing.ID
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = time.Now()
+
+	if _, err = mongodb.Database.Collection(mongodb.ScheduleCollection).ReplaceOne(global.Ctx, bson.D{{Key: "_id", Value: schedule.ID}}, schedule); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadEscalations()
+
+	return ctx.JSON(response.Success("Success", schedule, nil))
+}
+
+// DeleteSchedule delete on-call schedule.
+func DeleteSchedule(ctx *fiber.Ctx) error {
+	return deleteOnCall(ctx, mongodb.ScheduleCollection, "Schedule not found.")
+}
+
+// QueryEscalationPolicies query escalation policies list.
+func QueryEscalationPolicies(ctx *fiber.Ctx) error {
+	filter, paginator, err := teamFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	policies := make([]*dao.EscalationPolicy, paginator.GetLimit())
+	collection := mongodb.Database.Collection(mongodb.EscalationPolicyCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all escalation policies.
+	if err = cursor.All(global.Ctx, &policies); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", policies, paginator))
+}
+
+// GetEscalationPolicy get escalation policy by id.
+func GetEscalationPolicy(ctx *fiber.Ctx) error {
+	policy := &dao.EscalationPolicy{}
+	err := findByObjectID(mongodb.EscalationPolicyCollection, ctx.Params("id"), policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Escalation policy not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", policy, nil))
+}
+
+// CreateEscalationPolicy create escalation policy, a team has one policy.
+func CreateEscalationPolicy(ctx *fiber.Ctx) error {
+	policy := &dao.EscalationPolicy{}
+	if err := ctx.BodyParser(policy); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if _, err := policy.Policy(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid escalation policy.", err))
+	}
+
+	collection := mongodb.Database.Collection(mongodb.EscalationPolicyCollection)
+
+	count, err := collection.CountDocuments(global.Ctx, bson.D{{Key: "team_id", Value: policy.TeamID}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if count > 0 {
+		return ctx.Status(fiber.StatusConflict).JSON(response.Send(fiber.StatusConflict, "The team already has an escalation policy.", nil))
+	}
+
+	policy.ID = primitive.NewObjectID()
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+
+	if _, err = collection.InsertOne(global.Ctx, policy); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadEscalations()
+
+	return ctx.JSON(response.Success("Success", policy, nil))
+}
+
+// UpdateEscalationPolicy replace escalation policy, the team can't be changed.
+func UpdateEscalationPolicy(ctx *fiber.Ctx) error {
+	existing := &dao.EscalationPolicy{}
+	err := findByObjectID(mongodb.EscalationPolicyCollection, ctx.Params("id"), existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Escalation policy not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	policy := &dao.EscalationPolicy{}
+	if err = ctx.BodyParser(policy); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	policy.ID = existing.ID
+	policy.TeamID = existing.TeamID
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+
+	if _, err = policy.Policy(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid escalation policy.", err))
+	}
+
+	if _, err = mongodb.Database.Collection(mongodb.EscalationPolicyCollection).ReplaceOne(global.Ctx, bson.D{{Key: "_id", Value: policy.ID}}, policy); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadEscalations()
+
+	return ctx.JSON(response.Success("Success", policy, nil))
+}
+
+// DeleteEscalationPolicy delete escalation policy.
+func DeleteEscalationPolicy(ctx *fiber.Ctx) error {
+	return deleteOnCall(ctx, mongodb.EscalationPolicyCollection, "Escalation policy not found.")
+}
+
+// teamFilter returns the filter on ?team_id= and the paginator of the query.
+func teamFilter(ctx *fiber.Ctx) (bson.D, *pagination.Paginator, error) {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	if err := ctx.QueryParser(paginator); err != nil {
+		return nil, nil, err
+	}
+
+	if value := ctx.Query("team_id"); value != "" {
+		teamID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter = append(filter, bson.E{Key: "team_id", Value: teamID})
+	}
+
+	return filter, paginator, nil
+}
+
+func deleteOnCall(ctx *fiber.Ctx, collection, notFound string) error {
+	id, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound(notFound))
+	}
+
+	result, err := mongodb.Database.Collection(collection).DeleteOne(global.Ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if result.DeletedCount == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound(notFound))
+	}
+
+	reloadEscalations()
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+func findByObjectID(collection, id string, doc any) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return mongo.ErrNoDocuments
+	}
+
+	return mongodb.Database.Collection(collection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: oid}}).Decode(doc)
+}
+
+func reloadEscalations() {
+	if global.Escalations != nil {
+		global.Escalations.Reload()
+	}
+}
@@ -0,0 +1,12 @@
+package handler
+
+import (
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetScheduler get the load and the lag of the scheduler of the checks.
+func GetScheduler(ctx *fiber.Ctx) error {
+	return ctx.JSON(response.Success("Success", global.Checks.Scheduler().Stats(), nil))
+}
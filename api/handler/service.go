@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryServices query services list.
+func QueryServices(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	services := make([]*dao.Service, paginator.GetLimit())
+
+	for _, key := range []string{"node", "name"} {
+		if value := ctx.Query(key); value != "" {
+			filter = append(filter, bson.E{Key: key, Value: value})
+		}
+	}
+
+	if tag := ctx.Query("tag"); tag != "" {
+		filter = append(filter, bson.E{Key: "tags", Value: tag})
+	}
+
+	collection := mongodb.Database.Collection(mongodb.ServiceCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all services.
+	if err = cursor.All(global.Ctx, &services); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", services, paginator))
+}
+
+// GetService get service by id.
+func GetService(ctx *fiber.Ctx) error {
+	service, err := findService(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Service not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", service, nil))
+}
+
+// RegisterService create or replace the service and its checks, the checks
+// which are no longer part of the service are stopped and deleted.
+func RegisterService(ctx *fiber.Ctx) error {
+	registration := &dao.ServiceRegistration{}
+	if err := ctx.BodyParser(registration); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	registration.ID = ctx.Params("id")
+
+	chkTypes, err := prepareService(registration)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid service.", err))
+	}
+
+	err = saveService(registration, chkTypes)
+	if errors.Is(err, mongodb.ErrCheckTaken) {
+		return ctx.Status(fiber.StatusConflict).JSON(response.Send(fiber.StatusConflict, err.Error(), nil))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	if registration.Checks == nil {
+		registration.Checks = []*dao.Check{}
+	}
+
+	return ctx.JSON(response.Success("Success", registration, nil))
+}
+
+// DeregisterService delete the service and stop all its checks.
+func DeregisterService(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	if _, err := findService(id); errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Service not found."))
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	checkIDs, err := mongodb.RemoveService(global.Ctx, id)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	for _, checkID := range checkIDs {
+		global.Checks.Remove(checkID)
+	}
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+// GetServiceHealth get the aggregated health of the service from the latest status of its checks.
+func GetServiceHealth(ctx *fiber.Ctx) error {
+	service, err := findService(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Service not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	var checks []*checker.HealthCheck
+	for _, check := range global.State.Checks() {
+		if check.ServiceID == service.ID {
+			checks = append(checks, check)
+		}
+	}
+
+	health := &dao.ServiceHealth{
+		Service: service,
+		Status:  checker.AggregateStatus(checks),
+		Checks:  make([]*dao.CheckStatus, 0, len(checks)),
+	}
+
+	for _, check := range checks {
+		health.Checks = append(health.Checks, &dao.CheckStatus{
+			ID:       check.CheckID,
+			Name:     check.Name,
+			Type:     check.Type,
+			Status:   check.Status,
+			Output:   check.Output,
+			Flapping: check.Flapping,
+		})
+	}
+
+	return ctx.JSON(response.Success("Success", health, nil))
+}
+
+// prepareService attaches the checks to the service and validates them.
+func prepareService(registration *dao.ServiceRegistration) ([]*checker.CheckType, error) {
+	service := &registration.Service
+	if service.Name == "" || service.Node == "" {
+		return nil, errors.New("name and node are required")
+	}
+
+	chkTypes := make([]*checker.CheckType, len(registration.Checks))
+	for i, check := range registration.Checks {
+		attachCheck(service, check, i, len(registration.Checks))
+
+		chkType, err := validateCheck(check)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check %q: %w", check.ID, err)
+		}
+
+		chkTypes[i] = chkType
+	}
+
+	return chkTypes, nil
+}
+
+// saveService persists the service and its checks, then starts the checks
+// and stops the ones no longer part of the service.
+func saveService(registration *dao.ServiceRegistration, chkTypes []*checker.CheckType) error {
+	service := &registration.Service
+
+	checkIDs := make([]string, 0, len(registration.Checks))
+	for _, check := range registration.Checks {
+		checkIDs = append(checkIDs, check.ID)
+	}
+
+	if err := mongodb.EnsureServiceChecks(global.Ctx, service.ID, checkIDs); err != nil {
+		return err
+	}
+
+	if err := mongodb.EnsureNode(global.Ctx, service.Node); err != nil {
+		return err
+	}
+
+	if err := mongodb.SaveService(global.Ctx, service); err != nil {
+		return err
+	}
+
+	removed, err := mongodb.SaveServiceChecks(global.Ctx, service.ID, registration.Checks)
+	if err != nil {
+		return err
+	}
+
+	for _, checkID := range removed {
+		global.Checks.Remove(checkID)
+	}
+
+	var errs []error
+	for i, check := range registration.Checks {
+		if err = startCheck(check, chkTypes[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start check %q: %w", check.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func findService(id string) (*dao.Service, error) {
+	service := &dao.Service{}
+	err := mongodb.Database.Collection(mongodb.ServiceCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: id}}).Decode(service)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// attachCheck binds the check to the service, checks without an ID are named
+// after the service like Consul does.
+func attachCheck(service *dao.Service, check *dao.Check, index, total int) {
+	if check.ID == "" {
+		check.ID = "service:" + service.ID
+		if total > 1 {
+			check.ID = fmt.Sprintf("service:%s:%d", service.ID, index+1)
+		}
+	}
+
+	if check.Name == "" {
+		check.Name = fmt.Sprintf("Service '%s' check", service.Name)
+	}
+
+	check.Node = service.Node
+	check.ServiceID = service.ID
+	check.ServiceName = service.Name
+	check.ServiceTags = service.Tags
+}
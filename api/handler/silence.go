@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/pagination"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QuerySilences query silences list, ?active=true for the silences in effect.
+func QuerySilences(ctx *fiber.Ctx) error {
+	filter := bson.D{}
+	paginator := pagination.Init()
+	err := ctx.QueryParser(paginator)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	silences := make([]*dao.Silence, paginator.GetLimit())
+
+	if value := ctx.Query("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid active.", err))
+		}
+
+		now := time.Now()
+		if active {
+			filter = append(filter,
+				bson.E{Key: "starts_at", Value: bson.D{{Key: "$lte", Value: now}}},
+				bson.E{Key: "ends_at", Value: bson.D{{Key: "$gt", Value: now}}},
+			)
+		} else {
+			filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: "starts_at", Value: bson.D{{Key: "$gt", Value: now}}}},
+				bson.D{{Key: "ends_at", Value: bson.D{{Key: "$lte", Value: now}}}},
+			}})
+		}
+	}
+
+	collection := mongodb.Database.Collection(mongodb.SilenceCollection)
+
+	// Query total count.
+	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(paginator.GetLimit()).SetSkip(paginator.GetOffset())
+	cursor, err := collection.Find(global.Ctx, filter, opts)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	// Decode all silences.
+	if err = cursor.All(global.Ctx, &silences); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", silences, paginator))
+}
+
+// GetSilence get silence by id.
+func GetSilence(ctx *fiber.Ctx) error {
+	silence, err := findSilence(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Silence not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	return ctx.JSON(response.Success("Success", silence, nil))
+}
+
+// CreateSilence create silence, starting now unless starts_at is set.
+func CreateSilence(ctx *fiber.Ctx) error {
+	silence := &dao.Silence{}
+	if err := ctx.BodyParser(silence); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	silence.CreatedAt = time.Now()
+	silence.UpdatedAt = silence.CreatedAt
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = silence.CreatedAt
+	}
+
+	if _, err := silence.Silence(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid silence.", err))
+	}
+
+	silence.ID = primitive.NewObjectID()
+
+	if _, err := mongodb.Database.Collection(mongodb.SilenceCollection).InsertOne(global.Ctx, silence); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadSilences()
+
+	return ctx.JSON(response.Success("Success", silence, nil))
+}
+
+// UpdateSilence replace silence.
+func UpdateSilence(ctx *fiber.Ctx) error {
+	existing, err := findSilence(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Silence not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	silence := &dao.Silence{}
+	if err = ctx.BodyParser(silence); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse request body.", err))
+	}
+
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = existing.StartsAt
+	}
+
+	if _, err = silence.Silence(); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Invalid silence.", err))
+	}
+
+	silence.ID = existing.ID
+	silence.CreatedAt = existing.CreatedAt
+	silence.UpdatedAt = time.Now()
+
+	if _, err = mongodb.Database.Collection(mongodb.SilenceCollection).ReplaceOne(global.Ctx, bson.D{{Key: "_id", Value: silence.ID}}, silence); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadSilences()
+
+	return ctx.JSON(response.Success("Success", silence, nil))
+}
+
+// ExpireSilence end the silence now, it is kept for the record. A silence
+// which hasn't started yet is deleted.
+func ExpireSilence(ctx *fiber.Ctx) error {
+	silence, err := findSilence(ctx.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ctx.Status(fiber.StatusNotFound).JSON(response.NotFound("Silence not found."))
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	collection := mongodb.Database.Collection(mongodb.SilenceCollection)
+	filter := bson.D{{Key: "_id", Value: silence.ID}}
+	now := time.Now()
+
+	switch {
+	case silence.StartsAt.After(now):
+		_, err = collection.DeleteOne(global.Ctx, filter)
+	case silence.EndsAt.After(now):
+		_, err = collection.UpdateOne(global.Ctx, filter, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "ends_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}}})
+	}
+
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response.InternalServerError("Internal Error!", err))
+	}
+
+	reloadSilences()
+
+	return ctx.JSON(response.Success("Success", nil, nil))
+}
+
+func findSilence(id string) (*dao.Silence, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	silence := &dao.Silence{}
+	err = mongodb.Database.Collection(mongodb.SilenceCollection).FindOne(global.Ctx, bson.D{{Key: "_id", Value: oid}}).Decode(silence)
+	if err != nil {
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+func reloadSilences() {
+	if global.Notifications == nil {
+		return
+	}
+
+	if err := global.Notifications.ReloadSilences(global.Ctx); err != nil {
+		journal.Logger.Errorw("Failed to reload silences", "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/response"
+	"github.com/betterde/orbit/internal/stream"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// heartbeatInterval is how often idle streams are pinged, so that
+// disconnected clients are detected.
+const heartbeatInterval = 15 * time.Second
+
+// StreamEvents push the check events as Server-Sent Events.
+func StreamEvents(ctx *fiber.Ctx) error {
+	filter := stream.Filter{}
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	sub := global.Stream.Subscribe(filter)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer global.Stream.Unsubscribe(sub)
+
+		// Send the headers right away, the first event may take a while.
+		if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event := <-sub.Events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					journal.Logger.Errorw("Failed to encode stream event", "error", err)
+					continue
+				}
+
+				if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case <-global.Ctx.Done():
+				return
+			}
+
+			// Flush fails once the client is gone.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// UpgradeStream make sure the client asked for a WebSocket connection,
+// and keeps the filter of the events for StreamEventsWebSocket.
+func UpgradeStream(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	filter := stream.Filter{}
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(response.ValidationError("Failed to parse query params.", err))
+	}
+
+	ctx.Locals("filter", filter)
+
+	return ctx.Next()
+}
+
+// StreamEventsWebSocket push the check events as WebSocket JSON messages.
+var StreamEventsWebSocket = websocket.New(func(conn *websocket.Conn) {
+	filter, _ := conn.Locals("filter").(stream.Filter)
+	sub := global.Stream.Subscribe(filter)
+	defer global.Stream.Unsubscribe(sub)
+
+	// Read until the client goes away, the messages it sends are ignored.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events:
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(heartbeatInterval)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-global.Ctx.Done():
+			return
+		}
+	}
+})
@@ -27,7 +27,7 @@ func QueryUsers(ctx *fiber.Ctx) error {
 		filter = append(filter, bson.E{Key: "status", Value: status})
 	}
 
-	collection := mongodb.Database.Collection("users")
+	collection := mongodb.Database.Collection(mongodb.UserCollection)
 
 	// Query total count.
 	paginator.Total, err = collection.CountDocuments(global.Ctx, filter)
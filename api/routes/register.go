@@ -20,6 +20,84 @@ func RegisterRoutes(app *fiber.App) {
 	api.Post("/users", handler.CreateUser).Name("Create user")
 	api.Get("/users", handler.QueryUsers).Name("Query users list")
 
+	api.Post("/checks", handler.CreateCheck).Name("Create check")
+	api.Get("/checks", handler.QueryChecks).Name("Query checks list")
+	api.Get("/checks/:id", handler.GetCheck).Name("Get check")
+	api.Put("/checks/:id", handler.UpdateCheck).Name("Update check")
+	api.Delete("/checks/:id", handler.DeleteCheck).Name("Delete check")
+	api.Get("/checks/:id/uptime", handler.GetCheckUptime).Name("Get check uptime")
+	api.Get("/checks/:id/locations", handler.GetCheckLocations).Name("Get check status per location")
+	api.Put("/checks/:id/pass", handler.PassCheck).Name("Mark TTL check as passing")
+	api.Put("/checks/:id/warn", handler.WarnCheck).Name("Mark TTL check as warning")
+	api.Put("/checks/:id/fail", handler.FailCheck).Name("Mark TTL check as critical")
+
+	api.Get("/nodes", handler.QueryNodes).Name("Query nodes list")
+	api.Get("/nodes/:id", handler.GetNode).Name("Get node")
+	api.Put("/nodes/:id", handler.RegisterNode).Name("Register node")
+	api.Delete("/nodes/:id", handler.DeregisterNode).Name("Deregister node")
+
+	api.Get("/services", handler.QueryServices).Name("Query services list")
+	api.Get("/services/:id", handler.GetService).Name("Get service")
+	api.Put("/services/:id", handler.RegisterService).Name("Register service")
+	api.Delete("/services/:id", handler.DeregisterService).Name("Deregister service")
+	api.Get("/services/:id/health", handler.GetServiceHealth).Name("Get service health")
+
+	api.Post("/maintenances", handler.CreateMaintenance).Name("Create maintenance")
+	api.Get("/maintenances", handler.QueryMaintenances).Name("Query maintenances list")
+	api.Get("/maintenances/:id", handler.GetMaintenance).Name("Get maintenance")
+	api.Put("/maintenances/:id", handler.UpdateMaintenance).Name("Update maintenance")
+	api.Delete("/maintenances/:id", handler.DeleteMaintenance).Name("Delete maintenance")
+
+	api.Post("/channels", handler.CreateChannel).Name("Create notification channel")
+	api.Get("/channels", handler.QueryChannels).Name("Query notification channels list")
+	api.Get("/channels/:id", handler.GetChannel).Name("Get notification channel")
+	api.Put("/channels/:id", handler.UpdateChannel).Name("Update notification channel")
+	api.Delete("/channels/:id", handler.DeleteChannel).Name("Delete notification channel")
+	api.Post("/channels/:id/test", handler.TestChannel).Name("Send test alert to notification channel")
+	api.Get("/deliveries", handler.QueryDeliveries).Name("Query notification deliveries list")
+
+	api.Post("/silences", handler.CreateSilence).Name("Create silence")
+	api.Get("/silences", handler.QuerySilences).Name("Query silences list")
+	api.Get("/silences/:id", handler.GetSilence).Name("Get silence")
+	api.Put("/silences/:id", handler.UpdateSilence).Name("Update silence")
+	api.Delete("/silences/:id", handler.ExpireSilence).Name("Expire silence")
+
+	api.Get("/incidents", handler.QueryIncidents).Name("Query incidents list")
+	api.Get("/incidents/:id", handler.GetIncident).Name("Get incident")
+	api.Post("/incidents/:id/acknowledge", handler.AcknowledgeIncident).Name("Acknowledge incident")
+	api.Post("/incidents/:id/assign", handler.AssignIncident).Name("Assign incident")
+	api.Post("/incidents/:id/comments", handler.CommentIncident).Name("Comment incident")
+	api.Post("/incidents/:id/resolve", handler.ResolveIncident).Name("Resolve incident")
+
+	api.Get("/oncall/now", handler.GetOnCallNow).Name("Get the users on call now")
+	api.Post("/schedules", handler.CreateSchedule).Name("Create on-call schedule")
+	api.Get("/schedules", handler.QuerySchedules).Name("Query on-call schedules list")
+	api.Get("/schedules/:id", handler.GetSchedule).Name("Get on-call schedule")
+	api.Put("/schedules/:id", handler.UpdateSchedule).Name("Update on-call schedule")
+	api.Delete("/schedules/:id", handler.DeleteSchedule).Name("Delete on-call schedule")
+	api.Post("/escalation-policies", handler.CreateEscalationPolicy).Name("Create escalation policy")
+	api.Get("/escalation-policies", handler.QueryEscalationPolicies).Name("Query escalation policies list")
+	api.Get("/escalation-policies/:id", handler.GetEscalationPolicy).Name("Get escalation policy")
+	api.Put("/escalation-policies/:id", handler.UpdateEscalationPolicy).Name("Update escalation policy")
+	api.Delete("/escalation-policies/:id", handler.DeleteEscalationPolicy).Name("Delete escalation policy")
+
+	api.Get("/agents", handler.QueryAgents).Name("Query connected agents list")
+	api.Get("/cluster", handler.GetCluster).Name("Get cluster members")
+	api.Get("/scheduler", handler.GetScheduler).Name("Get check scheduler stats")
+
+	api.Get("/stream/events", handler.StreamEvents).Name("Stream check events (SSE)")
+	api.Get("/stream/ws", handler.UpgradeStream, handler.StreamEventsWebSocket).Name("Stream check events (WebSocket)")
+
+	// Subset of the Consul HTTP API.
+	v1 := app.Group("/v1")
+
+	v1.Put("/agent/check/register", handler.ConsulRegisterCheck).Name("Consul register check")
+	v1.Put("/agent/check/deregister/:id", handler.ConsulDeregisterCheck).Name("Consul deregister check")
+	v1.Put("/agent/service/register", handler.ConsulRegisterService).Name("Consul register service")
+	v1.Put("/agent/service/deregister/:id", handler.ConsulDeregisterService).Name("Consul deregister service")
+	v1.Get("/health/service/:name", handler.ConsulServiceHealth).Name("Consul service health")
+	v1.Get("/health/checks/:service", handler.ConsulServiceChecks).Name("Consul service checks")
+
 	app.Get("/swagger/*", filesystem.New(filesystem.Config{
 		Root:               docs.Serve(),
 		Index:              "user.swagger.json",
@@ -0,0 +1,146 @@
+/*
+Copyright © 2023 George
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/betterde/orbit/internal/agent"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Start an orbit agent, which runs the checks of its location for an orbit server",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := agent.Config{
+			ID:         viper.GetString("agent.id"),
+			Location:   viper.GetString("agent.location"),
+			Version:    version,
+			Token:      viper.GetString("agent.token"),
+			BufferSize: viper.GetInt("agent.buffer_size"),
+		}
+
+		if config.ID == "" {
+			config.ID, _ = os.Hostname()
+		}
+
+		addr := viper.GetString("agent.server")
+		if addr == "" || config.Location == "" || config.ID == "" {
+			journal.Logger.Error("The agent server, location and ID are required.")
+			os.Exit(1)
+		}
+
+		creds, err := agentCredentials()
+		if err != nil {
+			journal.Logger.Errorf("Failed to load the TLS configuration: %s", err)
+			os.Exit(1)
+		}
+
+		conn, err := grpc.NewClient(addr,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: 30 * time.Second, Timeout: 10 * time.Second, PermitWithoutStream: true}),
+		)
+		if err != nil {
+			journal.Logger.Errorf("Invalid orbit server address %q: %s", addr, err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		checker.EnableScriptChecks = viper.GetBool("agent.enable_script_checks")
+
+		journal.Logger.Infow("Starting orbit agent", "server", addr, "agent", config.ID, "location", config.Location)
+		agent.NewAgent(config, conn, journal.Logger).Run(ctx)
+		journal.Logger.Info("Orbit agent stopped.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+
+	flags := agentCmd.Flags()
+	flags.String("server", "", "address of the orbit server gRPC endpoint, e.g. orbit.example.com:9090")
+	flags.String("token", "", "token the agent authenticates with")
+	flags.String("location", "", "location of the agent, it runs the checks of this location")
+	flags.String("id", "", "unique ID of the agent (default is the hostname)")
+	flags.Int("buffer-size", agent.DefaultBufferSize, "number of results kept while disconnected from the server")
+	flags.Bool("tls", false, "connect to the server with TLS")
+	flags.String("tls-ca-file", "", "CA certificate the server certificate is verified with (default is the system pool)")
+	flags.Bool("tls-skip-verify", false, "don't verify the server certificate")
+	flags.Bool("enable-script-checks", false, "run the script checks assigned by the server")
+
+	for key, flag := range map[string]string{
+		"agent.server":               "server",
+		"agent.token":                "token",
+		"agent.location":             "location",
+		"agent.id":                   "id",
+		"agent.buffer_size":          "buffer-size",
+		"agent.tls.enabled":          "tls",
+		"agent.tls.ca_file":          "tls-ca-file",
+		"agent.tls.skip_verify":      "tls-skip-verify",
+		"agent.enable_script_checks": "enable-script-checks",
+	} {
+		_ = viper.BindPFlag(key, flags.Lookup(flag))
+	}
+}
+
+// agentCredentials returns the transport credentials of the connection to the server.
+func agentCredentials() (credentials.TransportCredentials, error) {
+	if !viper.GetBool("agent.tls.enabled") {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: viper.GetBool("agent.tls.skip_verify")}
+
+	if caFile := viper.GetString("agent.tls.ca_file"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+	}
+
+	return credentials.NewTLS(config), nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright © 2023 George
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	server         string
+	newMaintenance = &dao.Maintenance{}
+	startsAt       string
+	endsAt         string
+)
+
+// maintenanceCmd represents the maintenance command
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Manage the maintenance windows of an orbit server",
+}
+
+var maintenanceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the maintenance windows",
+	Run: func(cmd *cobra.Command, args []string) {
+		var maintenances []*dao.Maintenance
+		if err := requestServer(http.MethodGet, "/api/maintenances?limit=1000", nil, &maintenances); err != nil {
+			journal.Logger.Error(err)
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "ID\tNAME\tSCHEDULE\tTARGETS")
+		for _, m := range maintenances {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", m.ID.Hex(), m.Name, describeSchedule(m), describeTargets(m))
+		}
+
+		if err := writer.Flush(); err != nil {
+			journal.Logger.Error(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var maintenanceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a maintenance window",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, value := range []struct {
+			text   string
+			target **time.Time
+		}{{startsAt, &newMaintenance.StartsAt}, {endsAt, &newMaintenance.EndsAt}} {
+			if value.text == "" {
+				continue
+			}
+
+			t, err := time.Parse(time.RFC3339, value.text)
+			if err != nil {
+				journal.Logger.Errorf("Invalid time %q, expected RFC 3339: %s", value.text, err)
+				os.Exit(1)
+			}
+			*value.target = &t
+		}
+
+		created := &dao.Maintenance{}
+		if err := requestServer(http.MethodPost, "/api/maintenances", newMaintenance, created); err != nil {
+			journal.Logger.Error(err)
+			os.Exit(1)
+		}
+
+		fmt.Println(created.ID.Hex())
+	},
+}
+
+var maintenanceDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete maintenance windows",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, id := range args {
+			if err := requestServer(http.MethodDelete, "/api/maintenances/"+id, nil, nil); err != nil {
+				journal.Logger.Error(err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(maintenanceCmd)
+	maintenanceCmd.AddCommand(maintenanceListCmd, maintenanceCreateCmd, maintenanceDeleteCmd)
+
+	maintenanceCmd.PersistentFlags().StringVar(&server, "server", "", "orbit server address (default is http:// + listen)")
+
+	flags := maintenanceCreateCmd.Flags()
+	flags.StringVar(&newMaintenance.Name, "name", "", "name of the maintenance window")
+	flags.StringVar(&newMaintenance.Description, "description", "", "description of the maintenance window")
+	flags.StringSliceVar(&newMaintenance.CheckIDs, "check", nil, "ID of a check under maintenance, can be repeated")
+	flags.StringSliceVar(&newMaintenance.ServiceIDs, "service", nil, "ID of a service under maintenance, can be repeated")
+	flags.StringSliceVar(&newMaintenance.Tags, "tag", nil, "tag of the services under maintenance, can be repeated")
+	flags.StringVar(&startsAt, "start", "", "start of the window, or of the recurrence (RFC 3339)")
+	flags.StringVar(&endsAt, "end", "", "end of the window, or of the recurrence (RFC 3339)")
+	flags.StringVar(&newMaintenance.Cron, "cron", "", "cron expression of a recurring window, e.g. \"0 2 * * SUN\"")
+	flags.StringVar(&newMaintenance.RRule, "rrule", "", "RRULE of a recurring window, e.g. \"FREQ=WEEKLY;BYDAY=SU;BYHOUR=2\"")
+	flags.StringVar(&newMaintenance.Duration, "duration", "", "duration of each occurrence of a recurring window, e.g. 2h")
+	flags.StringVar(&newMaintenance.Timezone, "timezone", "", "timezone of the recurrence, e.g. Europe/Paris (default is UTC)")
+	_ = maintenanceCreateCmd.MarkFlagRequired("name")
+}
+
+// requestServer sends the request to the orbit server API,
+// and decodes the item or items of the response into out.
+func requestServer(method, path string, in, out interface{}) error {
+	address := server
+	if address == "" {
+		address = "http://" + viper.GetString("listen")
+	}
+
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(address, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Message string `json:"message"`
+		Data    struct {
+			Item  json.RawMessage `json:"item"`
+			Items json.RawMessage `json:"items"`
+		} `json:"data"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("unexpected response from the server: %s", resp.Status)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s %s", resp.Status, result.Message, result.Data.Item)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	// Lists are returned in items, anything else in item.
+	raw := result.Data.Item
+	if _, ok := out.(*[]*dao.Maintenance); ok {
+		raw = result.Data.Items
+	}
+
+	return json.Unmarshal(raw, out)
+}
+
+func describeSchedule(m *dao.Maintenance) string {
+	format := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch {
+	case m.Cron != "":
+		return fmt.Sprintf("cron %q for %s %s", m.Cron, m.Duration, m.Timezone)
+	case m.RRule != "":
+		return fmt.Sprintf("rrule %q for %s %s", m.RRule, m.Duration, m.Timezone)
+	default:
+		return format(m.StartsAt) + " - " + format(m.EndsAt)
+	}
+}
+
+func describeTargets(m *dao.Maintenance) string {
+	var targets []string
+	for _, id := range m.CheckIDs {
+		targets = append(targets, "check:"+id)
+	}
+
+	for _, id := range m.ServiceIDs {
+		targets = append(targets, "service:"+id)
+	}
+
+	for _, tag := range m.Tags {
+		targets = append(targets, "tag:"+tag)
+	}
+
+	return strings.Join(targets, ",")
+}
@@ -24,10 +24,19 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/betterde/orbit/api/routes"
+	"github.com/betterde/orbit/dao"
 	"github.com/betterde/orbit/global"
+	"github.com/betterde/orbit/internal/agent"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/cluster"
 	"github.com/betterde/orbit/internal/database/mongodb"
+	"github.com/betterde/orbit/internal/incident"
 	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/maintenance"
+	"github.com/betterde/orbit/internal/notify"
+	"github.com/betterde/orbit/internal/oncall"
 	"github.com/betterde/orbit/internal/pagination"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -37,9 +46,14 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // serveCmd represents the serve command
@@ -59,11 +73,94 @@ var serveCmd = &cobra.Command{
 		routes.RegisterRoutes(app)
 
 		mongodb.Init(global.Ctx)
-		mongodb.SetDatabase(viper.GetString("database.mongodb.db"))
 
 		// Set user define pagination limit.
 		pagination.SetUserDefineLimit(viper.GetInt64("paginator.limit"))
 
+		// Start all checks defined in MongoDB.
+		checker.EnableScriptChecks = viper.GetBool("checker.enable_script_checks")
+		global.Checks = checker.NewManager(global.State, mongodb.NewTTLStore(), journal.Logger)
+
+		// Run the interval checks on a bounded pool of workers.
+		scheduler := global.Checks.Scheduler()
+		scheduler.Workers = viper.GetInt("checker.workers")
+		scheduler.HostConcurrency = viper.GetInt("checker.host_concurrency")
+		go scheduler.Run(global.Ctx)
+
+		// Keep the history of all check results.
+		results := mongodb.NewResultWriter()
+		results.Start()
+		global.Checks.AddNotifier(results.Notifier)
+
+		// Push the check events to the real-time subscribers.
+		global.Checks.AddNotifier(global.Stream.Notifier)
+		go global.Stream.Forward(global.Ctx, global.Checks.Events(), global.State)
+
+		// Open incidents for the critical checks, the notifications are added to their timeline.
+		global.Incidents = incident.NewManager(global.State, mongodb.NewIncidentStore(), journal.Logger)
+		go global.Incidents.Run(global.Ctx, global.Checks.Events())
+
+		// Route the alerts to the notification channels of the configuration and MongoDB.
+		var channels dao.ChannelList
+		if err := viper.UnmarshalKey("notifications.channels", &channels); err != nil {
+			journal.Logger.Errorw("Failed to read the notification channels:", err)
+		}
+
+		global.Notifications = notify.NewDispatcher(global.State, notify.DeliveryLogs{mongodb.NewDeliveryLog(), global.Incidents}, journal.Logger, channels, mongodb.NewChannelStore())
+		global.Notifications.Silences = mongodb.NewSilenceStore()
+		if attempts := viper.GetInt("notifications.attempts"); attempts > 0 {
+			global.Notifications.Attempts = attempts
+		}
+
+		var routing notify.Config
+		if err := viper.UnmarshalKey("notifications", &routing); err != nil {
+			journal.Logger.Errorw("Failed to read the notification routing:", err)
+		} else if err = global.Notifications.Configure(&routing); err != nil {
+			journal.Logger.Errorw("Invalid notification routing:", err)
+		}
+
+		if err := global.Notifications.Reload(global.Ctx); err != nil {
+			journal.Logger.Errorw("Failed to load the notification channels:", err)
+		}
+
+		// Escalate the unacknowledged incidents to the users on call.
+		global.Escalations = oncall.NewEscalator(mongodb.NewOnCallStore(), global.Incidents, global.Notifications, journal.Logger)
+
+		// Share the checks with the other servers of the cluster, the leader
+		// delivers the notifications of all checks and escalates the incidents.
+		if viper.GetBool("cluster.enabled") {
+			joinCluster()
+			global.Notifications.Shared = global.Checks.SharedEvents()
+			global.Notifications.Leader = global.Cluster.IsLeader
+			global.Escalations.Leader = global.Cluster.IsLeader
+		}
+		go global.Notifications.Run(global.Ctx, global.Checks.Events())
+		go global.Escalations.Run(global.Ctx)
+
+		store := mongodb.NewCheckStore()
+		if err := global.Checks.Load(global.Ctx, store); err != nil {
+			journal.Logger.Errorw("Failed to load checks:", err)
+		}
+
+		// Apply the checks added, updated or deleted through the other servers.
+		go global.Checks.Sync(global.Ctx, store, viper.GetDuration("checker.sync_interval"))
+
+		// Put the checks under maintenance during the maintenance windows.
+		global.Maintenance = maintenance.NewScheduler(mongodb.NewMaintenanceStore(), global.State, global.Checks, journal.Logger)
+		go global.Maintenance.Run(global.Ctx)
+
+		// Deregister the services which stay critical for too long.
+		go global.Checks.RunReaper(global.Ctx, viper.GetDuration("checker.reap_interval"), store)
+
+		// Serve the remote agents, which run the checks of their locations.
+		var agents *grpc.Server
+		if addr := viper.GetString("grpc.listen"); addr != "" {
+			var err error
+			if agents, err = serveAgents(addr); err != nil {
+				journal.Logger.Errorw("Failed to start the agents server:", err)
+			}
+		}
+
 		go func() {
 			addr := viper.GetString("listen")
 			if err := app.Listen(addr); err != nil {
@@ -71,7 +168,7 @@ var serveCmd = &cobra.Command{
 			}
 		}()
 
-		if err := shutdownServer(app, global.CancelFunc); err != nil {
+		if err := shutdownServer(app, global.CancelFunc, results, agents); err != nil {
 			journal.Logger.Errorw("Failed to shutdown orbit server:", err)
 		}
 	},
@@ -91,13 +188,100 @@ func init() {
 	// serveCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func shutdownServer(app *fiber.App, cancel context.CancelFunc) error {
+// joinCluster joins the cluster before the checks are loaded, so that only the
+// checks owned by the server are started, and rebalances them whenever the
+// members change. The status of the checks is shared with the other members,
+// and the results of the remote checks are forwarded to the member running them.
+func joinCluster() {
+	id := viper.GetString("cluster.member_id")
+	if id == "" {
+		hostname, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	address := viper.GetString("cluster.address")
+	if address == "" {
+		address = viper.GetString("listen")
+	}
+
+	global.Cluster = cluster.New(mongodb.NewClusterStore(), id, address, viper.GetDuration("cluster.lease_ttl"), journal.Logger)
+	if err := global.Cluster.Join(global.Ctx); err != nil {
+		journal.Logger.Errorw("Failed to join the cluster:", err)
+	}
+
+	global.Checks.SetOwner(global.Cluster.Owns)
+	go global.Cluster.Run(global.Ctx)
+	go global.Cluster.OnChange(global.Ctx, global.Checks.Rebalance)
+	go global.Checks.ShareStatus(global.Ctx, mongodb.NewStatusStore(), viper.GetDuration("cluster.share_interval"))
+	go global.Checks.ForwardResults(global.Ctx, mongodb.NewResultQueue(), checker.DefaultForwardInterval)
+}
+
+// serveAgents starts the gRPC server of the remote agents in the background.
+func serveAgents(addr string) (*grpc.Server, error) {
+	token := viper.GetString("grpc.token")
+	if token == "" {
+		journal.Logger.Warn("No grpc.token configured, any agent can connect to the server.")
+	}
+
+	options := []grpc.ServerOption{
+		// The agents ping the server to detect broken connections.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 10 * time.Second, PermitWithoutStream: true}),
+	}
+
+	if certFile := viper.GetString("grpc.tls.cert_file"); certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, viper.GetString("grpc.tls.key_file"))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	global.Agents = agent.NewServer(global.Checks, token, journal.Logger)
+
+	server := grpc.NewServer(options...)
+	global.Agents.Register(server)
+
+	go func() {
+		journal.Logger.Infof("Agents server listening on %s", addr)
+		if err := server.Serve(listener); err != nil {
+			journal.Logger.Errorw("Agents server stopped:", err)
+		}
+	}()
+
+	return server, nil
+}
+
+func shutdownServer(app *fiber.App, cancel context.CancelFunc, results *mongodb.ResultWriter, agents *grpc.Server) error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	select {
 	case <-shutdown:
 		cancel()
+		if agents != nil {
+			agents.Stop()
+		}
+		if global.Checks != nil {
+			global.Checks.Stop()
+		}
+
+		// Flush the pending results before disconnecting from MongoDB.
+		results.Stop()
+
+		// Let the other servers take over the checks right away.
+		if global.Cluster != nil {
+			ctx, cancelLeave := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := global.Cluster.Leave(ctx); err != nil {
+				journal.Logger.Errorw("Failed to leave the cluster:", err)
+			}
+			cancelLeave()
+		}
+
 		if mongodb.Client != nil {
 			err := mongodb.Client.Disconnect(context.TODO())
 			if err != nil {
@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	AuditActionServiceReaped = "service.reaped"
+)
+
+// AuditLog records a change made to the catalog, stored in the "audit_logs" collection.
+type AuditLog struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Action    string             `bson:"action" json:"action"`
+	Actor     string             `bson:"actor" json:"actor"`
+	ServiceID string             `bson:"service_id,omitempty" json:"service_id,omitempty"`
+	CheckIDs  []string           `bson:"check_ids,omitempty" json:"check_ids,omitempty"`
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
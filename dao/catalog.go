@@ -0,0 +1,48 @@
+package dao
+
+import "time"
+
+// Node is a machine running services, stored in the "nodes" collection.
+type Node struct {
+	ID        string            `bson:"_id" json:"id"`
+	Address   string            `bson:"address" json:"address"`
+	Meta      map[string]string `bson:"meta,omitempty" json:"meta,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// Service is an instance of a service running on a node, stored in the "services" collection.
+type Service struct {
+	ID        string            `bson:"_id" json:"id"`
+	Name      string            `bson:"name" json:"name"`
+	Node      string            `bson:"node" json:"node"`
+	Address   string            `bson:"address" json:"address"`
+	Port      int               `bson:"port" json:"port"`
+	Tags      []string          `bson:"tags" json:"tags"`
+	Meta      map[string]string `bson:"meta,omitempty" json:"meta,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// ServiceRegistration is a service together with the checks attached to it.
+type ServiceRegistration struct {
+	Service `bson:",inline"`
+	Checks  []*Check `bson:"-" json:"checks"`
+}
+
+// ServiceHealth is the aggregated health of a service, the worst status of its checks.
+type ServiceHealth struct {
+	Service *Service       `json:"service"`
+	Status  string         `json:"status"`
+	Checks  []*CheckStatus `json:"checks"`
+}
+
+// CheckStatus is the latest status reported by a check.
+type CheckStatus struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Output   string `json:"output"`
+	Flapping bool   `json:"flapping"`
+}
@@ -0,0 +1,170 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/internal/notify"
+)
+
+// Channel is a destination of the alerts, stored in the "channels" collection
+// or defined in the "notifications.channels" section of the configuration.
+// Only the settings of its type are used.
+type Channel struct {
+	ID       string   `bson:"_id" json:"id" mapstructure:"id"`
+	Name     string   `bson:"name" json:"name" mapstructure:"name"`
+	Type     string   `bson:"type" json:"type" mapstructure:"type"`
+	Disabled bool     `bson:"disabled" json:"disabled" mapstructure:"disabled"`
+	Statuses []string `bson:"statuses,omitempty" json:"statuses,omitempty" mapstructure:"statuses"`
+
+	// Webhook, Slack and PagerDuty.
+	URL     string            `bson:"url,omitempty" json:"url,omitempty" mapstructure:"url"`
+	Secret  string            `bson:"secret,omitempty" json:"secret,omitempty" mapstructure:"secret"`
+	Headers map[string]string `bson:"headers,omitempty" json:"headers,omitempty" mapstructure:"headers"`
+
+	// Slack.
+	SlackChannel  string `bson:"slack_channel,omitempty" json:"slack_channel,omitempty" mapstructure:"slack_channel"`
+	SlackUsername string `bson:"slack_username,omitempty" json:"slack_username,omitempty" mapstructure:"slack_username"`
+
+	// PagerDuty.
+	RoutingKey string `bson:"routing_key,omitempty" json:"routing_key,omitempty" mapstructure:"routing_key"`
+
+	// Email.
+	SMTPHost          string   `bson:"smtp_host,omitempty" json:"smtp_host,omitempty" mapstructure:"smtp_host"`
+	SMTPPort          int      `bson:"smtp_port,omitempty" json:"smtp_port,omitempty" mapstructure:"smtp_port"`
+	SMTPUsername      string   `bson:"smtp_username,omitempty" json:"smtp_username,omitempty" mapstructure:"smtp_username"`
+	SMTPPassword      string   `bson:"smtp_password,omitempty" json:"smtp_password,omitempty" mapstructure:"smtp_password"`
+	SMTPTLS           bool     `bson:"smtp_tls,omitempty" json:"smtp_tls,omitempty" mapstructure:"smtp_tls"`
+	SMTPTLSSkipVerify bool     `bson:"smtp_tls_skip_verify,omitempty" json:"smtp_tls_skip_verify,omitempty" mapstructure:"smtp_tls_skip_verify"`
+	From              string   `bson:"from,omitempty" json:"from,omitempty" mapstructure:"from"`
+	To                []string `bson:"to,omitempty" json:"to,omitempty" mapstructure:"to"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at" mapstructure:"-"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at" mapstructure:"-"`
+}
+
+// Redacted returns a copy of the channel without its credentials, which are write-only:
+// the secret, the routing key, the SMTP password and the URL of Slack, which embeds its token.
+func (c *Channel) Redacted() *Channel {
+	redacted := *c
+	redacted.Secret = ""
+	redacted.RoutingKey = ""
+	redacted.SMTPPassword = ""
+
+	if c.Type == notify.ChannelSlack {
+		redacted.URL = ""
+	}
+
+	return &redacted
+}
+
+// KeepCredentials copies the credentials of the existing channel omitted from the
+// channel, so that a channel can be updated without sending its credentials again.
+func (c *Channel) KeepCredentials(existing *Channel) {
+	if c.Type != existing.Type {
+		return
+	}
+
+	if c.Secret == "" {
+		c.Secret = existing.Secret
+	}
+
+	if c.RoutingKey == "" {
+		c.RoutingKey = existing.RoutingKey
+	}
+
+	if c.SMTPPassword == "" {
+		c.SMTPPassword = existing.SMTPPassword
+	}
+
+	if c.Type == notify.ChannelSlack && c.URL == "" {
+		c.URL = existing.URL
+	}
+}
+
+// Channel converts the document to the channel used by the dispatcher.
+func (c *Channel) Channel() (*notify.Channel, error) {
+	channel := &notify.Channel{
+		ID:       c.ID,
+		Name:     c.Name,
+		Type:     c.Type,
+		Statuses: c.Statuses,
+	}
+
+	switch c.Type {
+	case notify.ChannelWebhook:
+		if c.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		channel.Sender = &notify.Webhook{URL: c.URL, Secret: c.Secret, Headers: c.Headers}
+	case notify.ChannelSlack:
+		if c.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		channel.Sender = &notify.Slack{URL: c.URL, Channel: c.SlackChannel, Username: c.SlackUsername}
+	case notify.ChannelPagerDuty:
+		if c.RoutingKey == "" {
+			return nil, errors.New("routing_key is required")
+		}
+		channel.Sender = &notify.PagerDuty{URL: c.URL, RoutingKey: c.RoutingKey}
+	case notify.ChannelEmail:
+		if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+			return nil, errors.New("smtp_host, from and to are required")
+		}
+		channel.Sender = &notify.Email{
+			Host:          c.SMTPHost,
+			Port:          c.SMTPPort,
+			Username:      c.SMTPUsername,
+			Password:      c.SMTPPassword,
+			From:          c.From,
+			To:            c.To,
+			TLS:           c.SMTPTLS,
+			TLSSkipVerify: c.SMTPTLSSkipVerify,
+		}
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", c.Type)
+	}
+
+	return channel, nil
+}
+
+// Delivery is the outcome of sending a notification to a channel, stored in the "deliveries" collection.
+type Delivery struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	ChannelID   string             `bson:"channel_id" json:"channel_id"`
+	ChannelType string             `bson:"channel_type" json:"channel_type"`
+	Receiver    string             `bson:"receiver" json:"receiver"`
+	GroupKey    string             `bson:"group_key" json:"group_key"`
+	CheckIDs    []string           `bson:"check_ids" json:"check_ids"`
+	Status      string             `bson:"status" json:"status"`
+	Alerts      []*notify.Alert    `bson:"alerts" json:"alerts"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	Success     bool               `bson:"success" json:"success"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ChannelList are the channels defined in the configuration.
+type ChannelList []*Channel
+
+func (l ChannelList) Channels(ctx context.Context) ([]*notify.Channel, error) {
+	channels := make([]*notify.Channel, 0, len(l))
+	for _, c := range l {
+		if c.Disabled {
+			continue
+		}
+
+		channel, err := c.Channel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel %q: %w", c.ID, err)
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
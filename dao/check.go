@@ -0,0 +1,190 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// Check is the document of a health check definition, stored in the "checks" collection.
+type Check struct {
+	ID          string            `bson:"_id" json:"id"`
+	Name        string            `bson:"name" json:"name"`
+	Notes       string            `bson:"notes" json:"notes"`
+	Node        string            `bson:"node" json:"node"`
+	ServiceID   string            `bson:"service_id" json:"service_id"`
+	ServiceName string            `bson:"service_name" json:"service_name"`
+	ServiceTags []string          `bson:"service_tags" json:"service_tags"`
+	Labels      map[string]string `bson:"labels,omitempty" json:"labels,omitempty"` // Used to route the alerts, the team label holds the ID of the team on call.
+	Status      string            `bson:"status" json:"status"`
+	Flapping    bool              `bson:"-" json:"flapping"` // Taken from the state of the running check.
+	Definition  CheckDefinition   `bson:"definition" json:"definition"`
+	CreatedAt   time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// CheckDefinition holds the details about a check's execution.
+// Durations are stored as Go duration strings, e.g. "10s".
+type CheckDefinition struct {
+	ScriptArgs                     []string            `bson:"script_args,omitempty" json:"script_args,omitempty"`
+	Shell                          string              `bson:"shell,omitempty" json:"shell,omitempty"`
+	HTTP                           string              `bson:"http,omitempty" json:"http,omitempty"`
+	Header                         map[string][]string `bson:"header,omitempty" json:"header,omitempty"`
+	Method                         string              `bson:"method,omitempty" json:"method,omitempty"`
+	Body                           string              `bson:"body,omitempty" json:"body,omitempty"`
+	DisableRedirects               bool                `bson:"disable_redirects,omitempty" json:"disable_redirects,omitempty"`
+	H2PING                         string              `bson:"h2ping,omitempty" json:"h2ping,omitempty"`
+	H2PingUseTLS                   bool                `bson:"h2ping_use_tls,omitempty" json:"h2ping_use_tls,omitempty"`
+	TCP                            string              `bson:"tcp,omitempty" json:"tcp,omitempty"`
+	TCPUseTLS                      bool                `bson:"tcp_use_tls,omitempty" json:"tcp_use_tls,omitempty"`
+	UDP                            string              `bson:"udp,omitempty" json:"udp,omitempty"`
+	GRPC                           string              `bson:"grpc,omitempty" json:"grpc,omitempty"`
+	GRPCUseTLS                     bool                `bson:"grpc_use_tls,omitempty" json:"grpc_use_tls,omitempty"`
+	AliasNode                      string              `bson:"alias_node,omitempty" json:"alias_node,omitempty"`
+	AliasService                   string              `bson:"alias_service,omitempty" json:"alias_service,omitempty"`
+	TLSServerName                  string              `bson:"tls_server_name,omitempty" json:"tls_server_name,omitempty"`
+	TLSSkipVerify                  bool                `bson:"tls_skip_verify,omitempty" json:"tls_skip_verify,omitempty"`
+	Interval                       string              `bson:"interval,omitempty" json:"interval,omitempty"`
+	Timeout                        string              `bson:"timeout,omitempty" json:"timeout,omitempty"`
+	TTL                            string              `bson:"ttl,omitempty" json:"ttl,omitempty"`
+	SuccessBeforePassing           int                 `bson:"success_before_passing,omitempty" json:"success_before_passing,omitempty"`
+	FailuresBeforeWarning          int                 `bson:"failures_before_warning,omitempty" json:"failures_before_warning,omitempty"`
+	FailuresBeforeCritical         int                 `bson:"failures_before_critical,omitempty" json:"failures_before_critical,omitempty"`
+	FlapWindow                     int                 `bson:"flap_window,omitempty" json:"flap_window,omitempty"`
+	FlapLowThreshold               float64             `bson:"flap_low_threshold,omitempty" json:"flap_low_threshold,omitempty"`
+	FlapHighThreshold              float64             `bson:"flap_high_threshold,omitempty" json:"flap_high_threshold,omitempty"`
+	DeregisterCriticalServiceAfter string              `bson:"deregister_critical_service_after,omitempty" json:"deregister_critical_service_after,omitempty"`
+	OutputMaxSize                  int                 `bson:"output_max_size,omitempty" json:"output_max_size,omitempty"`
+	Locations                      []string            `bson:"locations,omitempty" json:"locations,omitempty"` // Run by the agents of these locations instead of the server.
+	Quorum                         int                 `bson:"quorum,omitempty" json:"quorum,omitempty"`       // Locations which must fail for the check to fail, a majority by default.
+}
+
+// HealthCheck converts the document to the check tracked by the checker.
+func (c *Check) HealthCheck() *checker.HealthCheck {
+	def := c.Definition
+	interval, _ := parseDuration(def.Interval)
+	timeout, _ := parseDuration(def.Timeout)
+	deregister, _ := parseDuration(def.DeregisterCriticalServiceAfter)
+
+	return &checker.HealthCheck{
+		Node:        c.Node,
+		CheckID:     c.ID,
+		Name:        c.Name,
+		Notes:       c.Notes,
+		Status:      c.Status,
+		ServiceID:   c.ServiceID,
+		ServiceName: c.ServiceName,
+		ServiceTags: c.ServiceTags,
+		Labels:      c.Labels,
+		Type:        c.Type(),
+		Definition: checker.HealthCheckDefinition{
+			HTTP:                                   def.HTTP,
+			Header:                                 def.Header,
+			Method:                                 def.Method,
+			Body:                                   def.Body,
+			TLSServerName:                          def.TLSServerName,
+			TLSSkipVerify:                          def.TLSSkipVerify,
+			TCP:                                    def.TCP,
+			TCPUseTLS:                              def.TCPUseTLS,
+			UDP:                                    def.UDP,
+			GRPC:                                   def.GRPC,
+			GRPCUseTLS:                             def.GRPCUseTLS,
+			TimeoutDuration:                        timeout,
+			IntervalDuration:                       interval,
+			DeregisterCriticalServiceAfterDuration: deregister,
+		},
+	}
+}
+
+// CheckType converts the definition to the check type run by the checker.
+func (c *Check) CheckType() (*checker.CheckType, error) {
+	def := c.Definition
+	chkType := &checker.CheckType{
+		Name:                   c.Name,
+		Notes:                  c.Notes,
+		Status:                 c.Status,
+		ScriptArgs:             def.ScriptArgs,
+		HTTP:                   def.HTTP,
+		H2PING:                 def.H2PING,
+		H2PingUseTLS:           def.H2PingUseTLS,
+		Header:                 def.Header,
+		Method:                 def.Method,
+		Body:                   def.Body,
+		DisableRedirects:       def.DisableRedirects,
+		TCP:                    def.TCP,
+		TCPUseTLS:              def.TCPUseTLS,
+		UDP:                    def.UDP,
+		AliasNode:              def.AliasNode,
+		AliasService:           def.AliasService,
+		Shell:                  def.Shell,
+		GRPC:                   def.GRPC,
+		GRPCUseTLS:             def.GRPCUseTLS,
+		TLSServerName:          def.TLSServerName,
+		TLSSkipVerify:          def.TLSSkipVerify,
+		SuccessBeforePassing:   def.SuccessBeforePassing,
+		FailuresBeforeWarning:  def.FailuresBeforeWarning,
+		FailuresBeforeCritical: def.FailuresBeforeCritical,
+		FlapWindow:             def.FlapWindow,
+		FlapLowThreshold:       def.FlapLowThreshold,
+		FlapHighThreshold:      def.FlapHighThreshold,
+		OutputMaxSize:          def.OutputMaxSize,
+		Locations:              def.Locations,
+		Quorum:                 def.Quorum,
+	}
+
+	durations := []struct {
+		field  string
+		value  string
+		target *time.Duration
+	}{
+		{"interval", def.Interval, &chkType.Interval},
+		{"timeout", def.Timeout, &chkType.Timeout},
+		{"ttl", def.TTL, &chkType.TTL},
+		{"deregister_critical_service_after", def.DeregisterCriticalServiceAfter, &chkType.DeregisterCriticalServiceAfter},
+	}
+
+	for _, d := range durations {
+		duration, err := parseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", d.field, d.value, err)
+		}
+
+		*d.target = duration
+	}
+
+	return chkType, nil
+}
+
+// Type returns the name of the check type, as reported in HealthCheck.Type.
+func (c *Check) Type() string {
+	def := c.Definition
+	switch {
+	case def.TTL != "":
+		return "ttl"
+	case def.HTTP != "":
+		return "http"
+	case def.H2PING != "":
+		return "h2ping"
+	case def.TCP != "":
+		return "tcp"
+	case def.UDP != "":
+		return "udp"
+	case def.GRPC != "":
+		return "grpc"
+	case len(def.ScriptArgs) > 0:
+		return "script"
+	case def.AliasNode != "" || def.AliasService != "":
+		return "alias"
+	default:
+		return ""
+	}
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,69 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/internal/maintenance"
+)
+
+// Maintenance is a maintenance window, stored in the "maintenances" collection.
+// One-off windows only have starts_at and ends_at, recurring windows start at
+// every occurrence of cron or rrule for duration, e.g. "2h".
+type Maintenance struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	CheckIDs    []string           `bson:"check_ids" json:"check_ids"`
+	ServiceIDs  []string           `bson:"service_ids" json:"service_ids"`
+	Tags        []string           `bson:"tags" json:"tags"`
+	StartsAt    *time.Time         `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt      *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Cron        string             `bson:"cron,omitempty" json:"cron,omitempty"`
+	RRule       string             `bson:"rrule,omitempty" json:"rrule,omitempty"`
+	Duration    string             `bson:"duration,omitempty" json:"duration,omitempty"`
+	Timezone    string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Window converts the document to the window evaluated by the scheduler.
+func (m *Maintenance) Window() (*maintenance.Window, error) {
+	duration, err := parseDuration(m.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %w", m.Duration, err)
+	}
+
+	location, err := time.LoadLocation(m.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", m.Timezone, err)
+	}
+
+	window := &maintenance.Window{
+		ID:          m.ID.Hex(),
+		Name:        m.Name,
+		CheckIDs:    m.CheckIDs,
+		ServiceIDs:  m.ServiceIDs,
+		ServiceTags: m.Tags,
+		Cron:        m.Cron,
+		RRule:       m.RRule,
+		Duration:    duration,
+		Location:    location,
+	}
+
+	if m.StartsAt != nil {
+		window.StartsAt = *m.StartsAt
+	}
+
+	if m.EndsAt != nil {
+		window.EndsAt = *m.EndsAt
+	}
+
+	if err = window.Compile(); err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
@@ -0,0 +1,146 @@
+package dao
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/internal/oncall"
+)
+
+// Schedule is the on-call rotation of a team, stored in the "schedules" collection.
+// The users take turns every day, or every week on handoff_day, e.g. "monday",
+// at handoff_time, e.g. "09:00", in timezone. The first user is on call for
+// the shift of start.
+type Schedule struct {
+	ID          primitive.ObjectID   `bson:"_id" json:"id"`
+	TeamID      primitive.ObjectID   `bson:"team_id" json:"team_id"`
+	Name        string               `bson:"name" json:"name"`
+	Rotation    string               `bson:"rotation" json:"rotation"`
+	UserIDs     []primitive.ObjectID `bson:"user_ids" json:"user_ids"`
+	Start       time.Time            `bson:"start" json:"start"`
+	HandoffTime string               `bson:"handoff_time" json:"handoff_time"`
+	HandoffDay  string               `bson:"handoff_day,omitempty" json:"handoff_day,omitempty"`
+	Timezone    string               `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	Overrides   []*ScheduleOverride  `bson:"overrides" json:"overrides"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// ScheduleOverride puts another user on call, e.g. to swap a shift.
+type ScheduleOverride struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	StartsAt time.Time          `bson:"starts_at" json:"starts_at"`
+	EndsAt   time.Time          `bson:"ends_at" json:"ends_at"`
+}
+
+// EscalationPolicy is the escalation policy of a team, stored in the "escalation_policies" collection.
+type EscalationPolicy struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	TeamID    primitive.ObjectID `bson:"team_id" json:"team_id"`
+	Name      string             `bson:"name" json:"name"`
+	Levels    []*EscalationLevel `bson:"levels" json:"levels"`
+	Repeat    int                `bson:"repeat" json:"repeat"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// EscalationLevel notifies its users and the users on call in its schedules,
+// then escalates to the next level after escalate_after, e.g. "15m".
+type EscalationLevel struct {
+	EscalateAfter string               `bson:"escalate_after" json:"escalate_after"`
+	UserIDs       []primitive.ObjectID `bson:"user_ids" json:"user_ids"`
+	ScheduleIDs   []primitive.ObjectID `bson:"schedule_ids" json:"schedule_ids"`
+	Channels      []string             `bson:"channels" json:"channels"`
+}
+
+// Schedule converts the document to the schedule evaluated by the escalator.
+func (s *Schedule) Schedule() (*oncall.Schedule, error) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+
+	handoff, err := time.Parse("15:04", s.HandoffTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid handoff_time %q, expected HH:MM", s.HandoffTime)
+	}
+
+	schedule := &oncall.Schedule{
+		ID:          s.ID.Hex(),
+		TeamID:      s.TeamID.Hex(),
+		Name:        s.Name,
+		Rotation:    s.Rotation,
+		Users:       hexes(s.UserIDs),
+		Start:       s.Start,
+		HandoffTime: time.Duration(handoff.Hour())*time.Hour + time.Duration(handoff.Minute())*time.Minute,
+		Location:    location,
+	}
+
+	if s.Rotation == oncall.RotationWeekly {
+		if schedule.HandoffDay, err = parseWeekday(s.HandoffDay); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range s.Overrides {
+		schedule.Overrides = append(schedule.Overrides, &oncall.Override{UserID: o.UserID.Hex(), StartsAt: o.StartsAt, EndsAt: o.EndsAt})
+	}
+
+	if err = schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// Policy converts the document to the policy evaluated by the escalator.
+func (p *EscalationPolicy) Policy() (*oncall.Policy, error) {
+	policy := &oncall.Policy{
+		ID:     p.ID.Hex(),
+		TeamID: p.TeamID.Hex(),
+		Name:   p.Name,
+		Repeat: p.Repeat,
+	}
+
+	for i, l := range p.Levels {
+		escalateAfter, err := parseDuration(l.EscalateAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid escalate_after %q of level %d: %w", l.EscalateAfter, i+1, err)
+		}
+
+		policy.Levels = append(policy.Levels, &oncall.Level{
+			EscalateAfter: escalateAfter,
+			UserIDs:       hexes(l.UserIDs),
+			ScheduleIDs:   hexes(l.ScheduleIDs),
+			Channels:      l.Channels,
+		})
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func hexes(ids []primitive.ObjectID) []string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id.Hex())
+	}
+
+	return values
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), value) {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid handoff_day %q, expected a day of the week", value)
+}
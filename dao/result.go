@@ -0,0 +1,33 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// CheckResult is a single result of a check, stored in the "check_results" time series collection.
+type CheckResult struct {
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+	CheckID   string    `bson:"check_id" json:"check_id"`
+	Status    string    `bson:"status" json:"status"`
+	Output    string    `bson:"output" json:"output"`
+	Latency   float64   `bson:"latency" json:"latency"` // In milliseconds.
+
+	PerfData []checker.PerfData `bson:"perf_data,omitempty" json:"perf_data,omitempty"`
+}
+
+// Uptime is the availability of a check over a time window.
+// Durations are in seconds, periods of maintenance are excluded.
+type Uptime struct {
+	CheckID      string    `json:"check_id"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Availability *float64  `json:"availability"` // In percent, null if the check wasn't monitored.
+	Monitored    float64   `json:"monitored"`
+	Downtime     float64   `json:"downtime"`
+	Maintenance  float64   `json:"maintenance"`
+	Outages      int64     `json:"outages"`
+	MTTR         float64   `json:"mttr"`
+	MTBF         float64   `json:"mtbf"`
+}
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/internal/notify"
+)
+
+// Silence mutes the alerts matching all its matchers, e.g. `service="api"` or
+// `team=~"db|infra"`, between starts_at and ends_at. Stored in the "silences" collection.
+type Silence struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Matchers  []string           `bson:"matchers" json:"matchers"`
+	StartsAt  time.Time          `bson:"starts_at" json:"starts_at"`
+	EndsAt    time.Time          `bson:"ends_at" json:"ends_at"`
+	CreatedBy string             `bson:"created_by" json:"created_by"`
+	Comment   string             `bson:"comment" json:"comment"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Silence converts the document to the silence evaluated by the dispatcher.
+func (s *Silence) Silence() (*notify.Silence, error) {
+	if len(s.Matchers) == 0 {
+		return nil, errors.New("a silence needs at least one matcher")
+	}
+
+	if !s.EndsAt.After(s.StartsAt) {
+		return nil, errors.New("ends_at must be after starts_at")
+	}
+
+	matchers, err := notify.ParseMatchers(s.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notify.Silence{
+		ID:       s.ID.Hex(),
+		Matchers: matchers,
+		StartsAt: s.StartsAt,
+		EndsAt:   s.EndsAt,
+	}, nil
+}
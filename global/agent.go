@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/agent"
+
+// Agents serves the remote agents, nil if the gRPC server isn't enabled.
+var Agents *agent.Server
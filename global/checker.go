@@ -0,0 +1,11 @@
+package global
+
+import "github.com/betterde/orbit/internal/checker"
+
+var (
+	// State holds the latest status of every check.
+	State = checker.NewState()
+
+	// Checks is the manager of the checks run by this server.
+	Checks *checker.Manager
+)
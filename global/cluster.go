@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/cluster"
+
+// Cluster is the membership of the server in the cluster, nil if clustering isn't enabled.
+var Cluster *cluster.Cluster
@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/incident"
+
+// Incidents opens and resolves the incidents of the failing checks.
+var Incidents *incident.Manager
@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/maintenance"
+
+// Maintenance puts the checks under maintenance during the maintenance windows.
+var Maintenance *maintenance.Scheduler
@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/notify"
+
+// Notifications sends the alerts to the notification channels.
+var Notifications *notify.Dispatcher
@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/oncall"
+
+// Escalations escalates the unacknowledged incidents to the responders on call.
+var Escalations *oncall.Escalator
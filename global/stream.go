@@ -0,0 +1,6 @@
+package global
+
+import "github.com/betterde/orbit/internal/stream"
+
+// Stream fans out the check events to the real-time subscribers.
+var Stream = stream.NewHub()
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"sync"
+
+	agentv1 "github.com/betterde/orbit/proto/gen/orbit/agent/v1"
+)
+
+// DefaultBufferSize is how many results are kept while the server doesn't acknowledge them.
+const DefaultBufferSize = 10000
+
+// Buffer keeps the results until the server acknowledges them, so that they are
+// sent again after a reconnection. The oldest results are dropped once it's full.
+type Buffer struct {
+	lock     sync.Mutex
+	size     int
+	results  []*agentv1.Result
+	sequence uint64
+	dropped  int
+	notify   chan struct{}
+}
+
+func NewBuffer(size int) *Buffer {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	return &Buffer{
+		size:   size,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Add numbers the result and appends it to the buffer.
+func (b *Buffer) Add(result *agentv1.Result) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.sequence++
+	result.Sequence = b.sequence
+	b.results = append(b.results, result)
+
+	if len(b.results) > b.size {
+		b.results = b.results[len(b.results)-b.size:]
+		b.dropped++
+	}
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// After returns at most limit results following the sequence.
+func (b *Buffer) After(sequence uint64, limit int) []*agentv1.Result {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var results []*agentv1.Result
+	for _, result := range b.results {
+		if result.Sequence <= sequence {
+			continue
+		}
+
+		results = append(results, result)
+		if len(results) == limit {
+			break
+		}
+	}
+
+	return results
+}
+
+// Ack drops the results up to the sequence.
+func (b *Buffer) Ack(sequence uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	i := 0
+	for i < len(b.results) && b.results[i].Sequence <= sequence {
+		i++
+	}
+
+	b.results = b.results[i:]
+}
+
+// Len returns the number of results not acknowledged yet.
+func (b *Buffer) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.results)
+}
+
+// Dropped returns and resets the number of results dropped because the buffer was full.
+func (b *Buffer) Dropped() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	dropped := b.dropped
+	b.dropped = 0
+
+	return dropped
+}
+
+// Notify returns a channel signalled whenever a result is added.
+func (b *Buffer) Notify() <-chan struct{} {
+	return b.notify
+}
@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/betterde/orbit/internal/checker"
+	agentv1 "github.com/betterde/orbit/proto/gen/orbit/agent/v1"
+)
+
+const (
+	// MinBackoff is the delay before the first reconnection, doubled after each failure.
+	MinBackoff = time.Second
+
+	// MaxBackoff is the longest delay between two reconnections.
+	MaxBackoff = time.Minute
+
+	// BatchSize is the maximum number of results sent in a message.
+	BatchSize = 100
+)
+
+// Config is the configuration of an agent.
+type Config struct {
+	ID         string
+	Location   string
+	Version    string
+	Token      string
+	BufferSize int
+}
+
+// Agent runs the checks assigned by the server to its location, and streams
+// their results back. The checks keep running while the agent is disconnected,
+// their results are buffered and sent once it is connected again.
+type Agent struct {
+	config Config
+	conn   grpc.ClientConnInterface
+	logger *zap.SugaredLogger
+	checks *checker.Manager
+	buffer *Buffer
+
+	// assigned is the definition of each check run by the agent.
+	assigned map[string]*agentv1.Check
+	lock     sync.Mutex
+}
+
+func NewAgent(config Config, conn grpc.ClientConnInterface, logger *zap.SugaredLogger) *Agent {
+	a := &Agent{
+		config:   config,
+		conn:     conn,
+		logger:   logger,
+		checks:   checker.NewManager(checker.NewState(), nil, logger),
+		buffer:   NewBuffer(config.BufferSize),
+		assigned: make(map[string]*agentv1.Check),
+	}
+
+	a.checks.AddNotifier(a.notifier)
+
+	return a
+}
+
+// Run connects to the server, and reconnects with an exponential backoff
+// until the context is done. The checks are stopped when it returns.
+func (a *Agent) Run(ctx context.Context) {
+	defer a.checks.Stop()
+	go a.checks.Scheduler().Run(ctx)
+
+	backoff := MinBackoff
+	for {
+		connected, err := a.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			backoff = MinBackoff
+		}
+
+		a.logger.Warnw("Disconnected from the server", "error", err, "retry_in", backoff, "buffered", a.buffer.Len())
+
+		select {
+		case <-time.After(backoff):
+			backoff = min(backoff*2, MaxBackoff)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// connect runs a session until the stream fails, it reports whether the server
+// accepted the agent, i.e. if the backoff must be reset.
+func (a *Agent) connect(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if a.config.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.config.Token)
+	}
+
+	stream, err := agentv1.NewAgentServiceClient(a.conn).Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	hello := &agentv1.Hello{AgentId: a.config.ID, Location: a.config.Location, Version: a.config.Version}
+	if err = stream.Send(&agentv1.ConnectRequest{Message: &agentv1.ConnectRequest_Hello{Hello: hello}}); err != nil {
+		return false, err
+	}
+
+	// The server assigns the checks first, unless it rejects the agent.
+	res, err := stream.Recv()
+	if err != nil {
+		return false, err
+	}
+
+	a.logger.Infow("Connected to the server", "agent", a.config.ID, "location", a.config.Location)
+	a.handle(res)
+
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			a.handle(res)
+		}
+	}()
+
+	// The results not acknowledged by the previous sessions are sent again.
+	var sent uint64
+	for {
+		if dropped := a.buffer.Dropped(); dropped > 0 {
+			a.logger.Warnw("Results dropped, the buffer is full", "count", dropped)
+		}
+
+		for results := a.buffer.After(sent, BatchSize); len(results) > 0; results = a.buffer.After(sent, BatchSize) {
+			err = stream.Send(&agentv1.ConnectRequest{Message: &agentv1.ConnectRequest_Results{Results: &agentv1.Results{Results: results}}})
+			if err != nil {
+				return true, err
+			}
+
+			sent = results[len(results)-1].Sequence
+		}
+
+		select {
+		case <-a.buffer.Notify():
+		case err = <-errs:
+			if errors.Is(err, io.EOF) {
+				err = fmt.Errorf("connection closed by the server")
+			}
+			return true, err
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// handle applies the message of the server.
+func (a *Agent) handle(res *agentv1.ConnectResponse) {
+	switch msg := res.Message.(type) {
+	case *agentv1.ConnectResponse_Assignment:
+		a.assign(msg.Assignment.Checks)
+	case *agentv1.ConnectResponse_Ack:
+		a.buffer.Ack(msg.Ack.Sequence)
+	}
+}
+
+// assign starts the checks assigned to the agent, restarts the
+// checks which changed, and stops the checks not assigned anymore.
+func (a *Agent) assign(checks []*agentv1.Check) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	assigned := make(map[string]*agentv1.Check, len(checks))
+	for _, check := range checks {
+		assigned[check.CheckId] = check
+
+		previous, ok := a.assigned[check.CheckId]
+		if ok && reflect.DeepEqual(previous.Definition, check.Definition) {
+			continue
+		}
+
+		chkType := &checker.CheckType{}
+		if err := json.Unmarshal(check.Definition, chkType); err != nil {
+			a.logger.Errorw("Invalid check definition", "check", check.CheckId, "error", err)
+			delete(assigned, check.CheckId)
+			continue
+		}
+
+		health := &checker.HealthCheck{CheckID: check.CheckId, Name: check.Name, ServiceID: check.ServiceId}
+
+		var err error
+		if ok {
+			err = a.checks.Update(health, local(chkType))
+		} else {
+			err = a.checks.Add(health, local(chkType))
+		}
+
+		if err != nil {
+			a.logger.Errorw("Failed to start check", "check", check.CheckId, "error", err)
+			delete(assigned, check.CheckId)
+		}
+	}
+
+	for checkID := range a.assigned {
+		if _, ok := assigned[checkID]; !ok {
+			a.checks.Remove(checkID)
+		}
+	}
+
+	a.assigned = assigned
+	a.logger.Infow("Checks assigned", "count", len(assigned))
+}
+
+// notifier buffers the results of the check.
+func (a *Agent) notifier(check *checker.HealthCheck) checker.CheckNotifier {
+	return &resultNotifier{checkID: check.CheckID, buffer: a.buffer}
+}
+
+// local returns the check type run by the agent. The thresholds and the flap
+// detection are applied by the server, so every result is reported.
+func local(chkType *checker.CheckType) *checker.CheckType {
+	run := *chkType
+	run.Locations = nil
+	run.Quorum = 0
+	run.SuccessBeforePassing = 0
+	run.FailuresBeforeWarning = 0
+	run.FailuresBeforeCritical = 0
+	run.FlapHighThreshold = 0
+	run.DeregisterCriticalServiceAfter = 0
+
+	return &run
+}
+
+type resultNotifier struct {
+	checkID string
+	buffer  *Buffer
+}
+
+func (n *resultNotifier) UpdateCheck(status, output string) {
+	n.UpdateResult(&checker.Result{Status: status, Output: output, Time: time.Now()})
+}
+
+func (n *resultNotifier) UpdateResult(result *checker.Result) {
+	n.buffer.Add(&agentv1.Result{
+		CheckId: n.checkID,
+		Status:  result.Status,
+		Output:  result.Output,
+		Time:    timestamppb.New(result.Time),
+		Latency: durationpb.New(result.Latency),
+	})
+}
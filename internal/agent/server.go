@@ -0,0 +1,280 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/betterde/orbit/internal/checker"
+	agentv1 "github.com/betterde/orbit/proto/gen/orbit/agent/v1"
+)
+
+// Session is an agent connected to the server.
+type Session struct {
+	AgentID     string    `json:"agent_id"`
+	Location    string    `json:"location"`
+	Version     string    `json:"version"`
+	Address     string    `json:"address"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Checks      int       `json:"checks"`
+	LastResult  time.Time `json:"last_result,omitempty"`
+	Sequence    uint64    `json:"sequence"`
+
+	cancel context.CancelFunc
+}
+
+// Server assigns the remote checks to the agents of their locations, and
+// feeds the results reported by the agents to the checks. In a cluster, the
+// agents are assigned the remote checks of their location whichever server
+// runs them, the results are forwarded to that server.
+type Server struct {
+	checks *checker.Manager
+	token  string
+	logger *zap.SugaredLogger
+
+	lock     sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewServer(checks *checker.Manager, token string, logger *zap.SugaredLogger) *Server {
+	return &Server{
+		checks:   checks,
+		token:    token,
+		logger:   logger,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Register registers the agent service on the gRPC server.
+func (s *Server) Register(server *grpc.Server) {
+	agentv1.RegisterAgentServiceServer(server, s)
+}
+
+// Sessions returns the agents connected, sorted by location.
+func (s *Server) Sessions() []Session {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Location != sessions[j].Location {
+			return sessions[i].Location < sessions[j].Location
+		}
+		return sessions[i].AgentID < sessions[j].AgentID
+	})
+
+	return sessions
+}
+
+// Connect runs the session of an agent until it disconnects. An agent connecting
+// again with the same ID replaces its previous session.
+func (s *Server) Connect(stream agentv1.AgentService_ConnectServer) error {
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	hello := req.GetHello()
+	if hello == nil || hello.AgentId == "" || hello.Location == "" {
+		return status.Error(codes.InvalidArgument, "the agent ID and location are required")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	session := s.open(ctx, hello, cancel)
+	defer s.close(session)
+
+	logger := s.logger.With("agent", hello.AgentId, "location", hello.Location)
+	logger.Infow("Agent connected", "version", hello.Version, "address", session.Address)
+	defer logger.Infow("Agent disconnected")
+
+	changes := make(chan struct{}, 1)
+	s.checks.NotifyRemote(changes)
+	defer s.checks.StopNotifyRemote(changes)
+
+	requests := make(chan *agentv1.ConnectRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if err = s.assign(stream, session, hello.Location); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case req := <-requests:
+			results := req.GetResults()
+			if results == nil {
+				continue
+			}
+
+			if sequence := s.report(logger, session, results.Results); sequence > 0 {
+				if err = stream.Send(&agentv1.ConnectResponse{Message: &agentv1.ConnectResponse_Ack{Ack: &agentv1.Ack{Sequence: sequence}}}); err != nil {
+					return err
+				}
+			}
+		case <-changes:
+			if err = s.assign(stream, session, hello.Location); err != nil {
+				return err
+			}
+		case err = <-errs:
+			if status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// authorize checks the bearer token of the agent, if the server requires one.
+func (s *Server) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid agent token")
+}
+
+// assign sends the remote checks of the location to the agent.
+func (s *Server) assign(stream agentv1.AgentService_ConnectServer, session *Session, location string) error {
+	definitions := s.checks.RemoteDefinitions(location)
+
+	assignment := &agentv1.Assignment{}
+	for _, def := range definitions {
+		definition, err := json.Marshal(def.Type)
+		if err != nil {
+			return status.Errorf(codes.Internal, "check %q: %s", def.Check.CheckID, err)
+		}
+
+		assignment.Checks = append(assignment.Checks, &agentv1.Check{
+			CheckId:    def.Check.CheckID,
+			Name:       def.Check.Name,
+			ServiceId:  def.Check.ServiceID,
+			Definition: definition,
+		})
+	}
+
+	s.lock.Lock()
+	session.Checks = len(assignment.Checks)
+	s.lock.Unlock()
+
+	return stream.Send(&agentv1.ConnectResponse{Message: &agentv1.ConnectResponse_Assignment{Assignment: assignment}})
+}
+
+// report feeds the results to their checks, and returns the sequence to acknowledge.
+// Results of checks which aren't run from the location anymore are dropped.
+func (s *Server) report(logger *zap.SugaredLogger, session *Session, results []*agentv1.Result) uint64 {
+	var sequence uint64
+	for _, result := range results {
+		sequence = max(sequence, result.Sequence)
+
+		if !validStatus(result.Status) {
+			logger.Warnw("Invalid status reported", "check", result.CheckId, "status", result.Status)
+			continue
+		}
+
+		// The results buffered while the agent was disconnected keep the time they were measured at.
+		var at time.Time
+		if result.Time != nil {
+			at = result.Time.AsTime()
+		}
+
+		err := s.checks.Report(result.CheckId, session.Location, result.Status, result.Output, result.Latency.AsDuration(), at)
+		if err != nil {
+			logger.Debugw("Result dropped", "check", result.CheckId, "error", err)
+		}
+	}
+
+	s.lock.Lock()
+	session.LastResult = time.Now()
+	session.Sequence = max(session.Sequence, sequence)
+	s.lock.Unlock()
+
+	return sequence
+}
+
+func (s *Server) open(ctx context.Context, hello *agentv1.Hello, cancel context.CancelFunc) *Session {
+	session := &Session{
+		AgentID:     hello.AgentId,
+		Location:    hello.Location,
+		Version:     hello.Version,
+		ConnectedAt: time.Now(),
+		cancel:      cancel,
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		session.Address = p.Addr.String()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if previous, ok := s.sessions[hello.AgentId]; ok {
+		previous.cancel()
+	}
+	s.sessions[hello.AgentId] = session
+
+	return session
+}
+
+func (s *Server) close(session *Session) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.sessions[session.AgentID] == session {
+		delete(s.sessions, session.AgentID)
+	}
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case checker.HealthPassing, checker.HealthWarning, checker.HealthCritical:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// memoryQueue is a ResultQueue shared by the members of the test cluster.
+type memoryQueue struct {
+	lock    sync.Mutex
+	results []*checker.RemoteResult
+}
+
+func (q *memoryQueue) PushResult(result *checker.RemoteResult) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.results = append(q.results, result)
+
+	return nil
+}
+
+func (q *memoryQueue) PopResults(checkIDs []string) ([]*checker.RemoteResult, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var popped, kept []*checker.RemoteResult
+	for _, result := range q.results {
+		if slices.Contains(checkIDs, result.CheckID) {
+			popped = append(popped, result)
+		} else {
+			kept = append(kept, result)
+		}
+	}
+	q.results = kept
+
+	sort.SliceStable(popped, func(i, j int) bool {
+		return popped[i].Time.Before(popped[j].Time)
+	})
+
+	return popped, nil
+}
+
+// startServer serves the agent service of the manager on a random port.
+func startServer(t *testing.T, checks *checker.Manager) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(checks, "", zap.NewNop().Sugar())
+	grpcServer := grpc.NewServer()
+	server.Register(grpcServer)
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	agent := NewAgent(Config{ID: "agent-1", Location: "eu", BufferSize: 100}, conn, zap.NewNop().Sugar())
+	go agent.Run(ctx)
+
+	return server
+}
+
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+// TestForwardResults connects a single agent to one of the two members of a
+// cluster: it runs the remote checks of both, and the results of the checks
+// run by the other member are forwarded to it.
+func TestForwardResults(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	owners := map[string]string{"check-a": "a", "check-b": "b"}
+	queue := &memoryQueue{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	managers := make(map[string]*checker.Manager, 2)
+	states := make(map[string]*checker.State, 2)
+	for _, member := range []string{"a", "b"} {
+		states[member] = checker.NewState()
+		manager := checker.NewManager(states[member], nil, zap.NewNop().Sugar())
+		manager.SetOwner(func(checkID string) bool { return owners[checkID] == member })
+		defer manager.Stop()
+
+		for checkID := range owners {
+			check := &checker.HealthCheck{CheckID: checkID, Name: checkID, Status: checker.HealthCritical}
+			chkType := &checker.CheckType{TCP: target.Addr().String(), Interval: time.Second, Locations: []string{"eu"}}
+			if err := manager.Add(check, chkType); err != nil {
+				t.Fatalf("%s failed to add %s: %s", member, checkID, err)
+			}
+		}
+
+		go manager.ForwardResults(ctx, queue, 100*time.Millisecond)
+		managers[member] = manager
+	}
+
+	server := startServer(t, managers["a"])
+
+	assigned := waitFor(t, 5*time.Second, func() bool {
+		sessions := server.Sessions()
+		return len(sessions) == 1 && sessions[0].Checks == 2
+	})
+	if !assigned {
+		t.Fatalf("the agent isn't assigned the checks of both members, sessions: %+v", server.Sessions())
+	}
+
+	for checkID, owner := range owners {
+		passing := waitFor(t, 5*time.Second, func() bool {
+			check, ok := states[owner].Check(checkID)
+			return ok && check.Status == checker.HealthPassing
+		})
+		if !passing {
+			check, _ := states[owner].Check(checkID)
+			t.Errorf("%s isn't passing on %s, its owner: %+v", checkID, owner, check)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// CheckState is the source of the checks watched by alias checks.
+type CheckState interface {
+	Checks() []*HealthCheck
+	Notify(ch chan<- struct{})
+	StopNotify(ch chan<- struct{})
+}
+
+// CheckAlias is a check type that aliases the health of another service
+// instance or node. If the service aliased has any critical health checks, then
+// this check is critical. If the service has no critical but warnings,
+// then this check is warning, and if a service has only passing checks, then
+// this check is passing. The status is re-evaluated as soon as the state
+// of the aliased checks changes, the changes of the other checks are ignored.
+type CheckAlias struct {
+	CheckID       string
+	Node          string // Node name of the service. If empty, the service is looked up on every node.
+	ServiceID     string // ID (not name) of the service to alias. If empty, the node itself is aliased.
+	State         CheckState
+	Logger        *zap.SugaredLogger
+	StatusHandler *StatusHandler
+
+	// aliased is the status and output of each aliased check last evaluated, nil
+	// until the first evaluation, and reported the status and output last reported.
+	aliased  map[string]string
+	reported string
+
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+	stopWg   sync.WaitGroup
+}
+
+func (c *CheckAlias) CheckType() CheckType {
+	return CheckType{
+		AliasNode:    c.Node,
+		AliasService: c.ServiceID,
+	}
+}
+
+// Start is used to start the check, runs until Stop()
+func (c *CheckAlias) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	c.aliased, c.reported = nil, ""
+	c.stopWg.Add(1)
+	go c.run()
+}
+
+// Stop is used to stop the check.
+func (c *CheckAlias) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+
+	// Wait for the c.run() goroutine to complete before returning.
+	c.stopWg.Wait()
+}
+
+// run is invoked by a goroutine to watch the state until Stop() is called
+func (c *CheckAlias) run() {
+	defer c.stopWg.Done()
+
+	notifyCh := make(chan struct{}, 1)
+	c.State.Notify(notifyCh)
+	defer c.State.StopNotify(notifyCh)
+
+	c.processChecks(c.State.Checks())
+	for {
+		select {
+		case <-notifyCh:
+			c.processChecks(c.State.Checks())
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// processChecks is a common helper for taking a set of health checks and
+// using them to update our alias. The alias is only evaluated if the aliased
+// checks changed since the last evaluation, and updated if its status or
+// output changed, so that its history only records its changes.
+func (c *CheckAlias) processChecks(checks []*HealthCheck) {
+	var matched []*HealthCheck
+	aliased := make(map[string]string)
+	for _, chk := range checks {
+		if c.aliases(chk) {
+			matched = append(matched, chk)
+			aliased[chk.CheckID] = chk.Name + "\x00" + chk.Status + "\x00" + chk.Output
+		}
+	}
+
+	if c.aliased != nil && maps.Equal(aliased, c.aliased) {
+		return
+	}
+	c.aliased = aliased
+
+	health := HealthPassing
+	msg := "No checks found."
+	serviceFound := false
+	for _, chk := range matched {
+		// We have at least one healthcheck for this service
+		if c.ServiceID != "" && chk.ServiceID == c.ServiceID {
+			serviceFound = true
+		}
+
+		if statusRank(chk.Status) > statusRank(health) {
+			health = chk.Status
+			msg = fmt.Sprintf("Aliased check %q failing: %s", chk.Name, chk.Output)
+		} else if health == HealthPassing {
+			msg = "All checks passing."
+		}
+	}
+
+	if c.ServiceID != "" && !serviceFound {
+		health = HealthCritical
+		msg = fmt.Sprintf("Service %s could not be found on node %s", c.ServiceID, c.Node)
+		if c.Node == "" {
+			msg = fmt.Sprintf("Service %s could not be found", c.ServiceID)
+		}
+	}
+
+	if reported := health + "\x00" + msg; reported != c.reported {
+		c.reported = reported
+		c.StatusHandler.updateCheck(health, msg)
+	}
+}
+
+// aliases reports whether the status of the check is part of the alias.
+func (c *CheckAlias) aliases(chk *HealthCheck) bool {
+	// Never take our own status into account.
+	if chk.CheckID == c.CheckID {
+		return false
+	}
+
+	if c.Node != "" && chk.Node != c.Node {
+		return false
+	}
+
+	// Without a node, only the checks of the service are relevant.
+	serviceMatch := c.ServiceID != "" && chk.ServiceID == c.ServiceID
+
+	return (chk.ServiceID == "" && c.Node != "") || serviceMatch
+}
+
+// AggregateStatus returns the worst status among the checks,
+// passing if there are no checks.
+func AggregateStatus(checks []*HealthCheck) string {
+	health := HealthPassing
+	for _, chk := range checks {
+		if statusRank(chk.Status) > statusRank(health) {
+			health = chk.Status
+		}
+	}
+
+	return health
+}
+
+// statusRank orders the health statuses from the best to the worst.
+func statusRank(status string) int {
+	switch status {
+	case HealthPassing:
+		return 0
+	case HealthWarning:
+		return 1
+	case HealthMaint:
+		return 2
+	default:
+		return 3
+	}
+}
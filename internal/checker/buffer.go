@@ -1,6 +1,7 @@
 package checker
 
 import (
+	"bytes"
 	"errors"
 )
 
@@ -90,3 +91,42 @@ func (b *Buffer) Reset() {
 func (b *Buffer) String() string {
 	return string(b.Bytes())
 }
+
+// LineBuffer keeps the first line written to it, up to a given size, e.g. the
+// status line of a script whose output doesn't fit in a Buffer.
+type LineBuffer struct {
+	line []byte
+	size int
+	done bool
+}
+
+// NewLineBuffer creates a new line buffer of a given size.
+func NewLineBuffer(size int) *LineBuffer {
+	return &LineBuffer{size: size}
+}
+
+// Write records the bytes of the first line which fit in the buffer, the rest is discarded.
+func (b *LineBuffer) Write(buf []byte) (int, error) {
+	n := len(buf)
+	if b.done {
+		return n, nil
+	}
+
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+		b.done = true
+	}
+
+	if remain := b.size - len(b.line); len(buf) > remain {
+		buf = buf[:remain]
+		b.done = true
+	}
+
+	b.line = append(b.line, buf...)
+	return n, nil
+}
+
+// String returns the first line, without its line break.
+func (b *LineBuffer) String() string {
+	return string(b.line)
+}
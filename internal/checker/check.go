@@ -2,14 +2,19 @@ package checker
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-cleanhttp"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	hv1 "google.golang.org/grpc/health/grpc_health_v1"
 	"io"
 	"math/rand"
 	"net"
 	"net/http"
+	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +40,501 @@ type CheckNotifier interface {
 	UpdateCheck(status, output string)
 }
 
+// ResultNotifier is implemented by notifiers which also want to know
+// how long the check took to produce the result, and when.
+type ResultNotifier interface {
+	UpdateResult(result *Result)
+}
+
+// Result is a single result of a check, before the thresholds are applied.
+type Result struct {
+	Status   string
+	Output   string
+	Latency  time.Duration
+	Time     time.Time
+	PerfData []PerfData // Of the Nagios plugins.
+}
+
+// ResultRecorder is implemented by notifiers which want to know every result
+// of the check, including the ones which don't change its status.
+type ResultRecorder interface {
+	RecordResult(result *Result)
+}
+
+// Notifiers fans out the check updates to several notifiers.
+type Notifiers []CheckNotifier
+
+func (n Notifiers) UpdateCheck(status, output string) {
+	for _, notifier := range n {
+		notifier.UpdateCheck(status, output)
+	}
+}
+
+func (n Notifiers) UpdateFlapping(flapping bool) {
+	for _, notifier := range n {
+		if fn, ok := notifier.(FlapNotifier); ok {
+			fn.UpdateFlapping(flapping)
+		}
+	}
+}
+
+func (n Notifiers) UpdateLocations(locations []*LocationStatus) {
+	for _, notifier := range n {
+		if ln, ok := notifier.(LocationNotifier); ok {
+			ln.UpdateLocations(locations)
+		}
+	}
+}
+
+func (n Notifiers) RecordResult(result *Result) {
+	for _, notifier := range n {
+		if rr, ok := notifier.(ResultRecorder); ok {
+			rr.RecordResult(result)
+		}
+	}
+}
+
+func (n Notifiers) UpdateResult(result *Result) {
+	for _, notifier := range n {
+		if rn, ok := notifier.(ResultNotifier); ok {
+			rn.UpdateResult(result)
+		} else {
+			notifier.UpdateCheck(result.Status, result.Output)
+		}
+	}
+}
+
+// CheckMonitor is used to periodically invoke a script to
+// determine the health of a given check. It is compatible with
+// nagios plugins and expects the output in the same format.
+// Supports failures_before_critical and success_before_passing.
+type CheckMonitor struct {
+	ServiceID     string
+	ScriptArgs    []string
+	Shell         string
+	Interval      time.Duration
+	Timeout       time.Duration
+	Logger        *zap.SugaredLogger
+	OutputMaxSize int
+	StatusHandler *StatusHandler
+	Scheduler     *Scheduler
+
+	perfData []PerfData
+	perfLock sync.RWMutex
+	job      *Job
+	stopLock sync.Mutex
+}
+
+func (c *CheckMonitor) CheckType() CheckType {
+	return CheckType{
+		ScriptArgs:    c.ScriptArgs,
+		Shell:         c.Shell,
+		Interval:      c.Interval,
+		Timeout:       c.Timeout,
+		OutputMaxSize: c.OutputMaxSize,
+	}
+}
+
+// PerfData returns the performance data reported by the last run of the script.
+func (c *CheckMonitor) PerfData() []PerfData {
+	c.perfLock.RLock()
+	defer c.perfLock.RUnlock()
+
+	return c.perfData
+}
+
+// Start is used to start a check monitor.
+// Monitor runs until stop is called
+func (c *CheckMonitor) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.OutputMaxSize < 1 {
+		c.OutputMaxSize = DefaultBufSize
+	}
+
+	// Scripts run locally, they have no host to limit the concurrency of.
+	if c.job == nil {
+		c.job = c.Scheduler.Schedule(c.Interval, "", func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
+}
+
+// Stop is used to stop a check monitor.
+func (c *CheckMonitor) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	// Wait for the running script to complete before returning.
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
+	}
+}
+
+// check is invoked periodically to perform the script check
+func (c *CheckMonitor) check() {
+	// Create the command
+	cmd, err := newCommand(c.Shell, c.ScriptArgs)
+	if err != nil {
+		c.Logger.Error("Check failed to setup", "error", err)
+		c.StatusHandler.updateCheck(HealthCritical, err.Error())
+		return
+	}
+
+	// Collect the output, the end of it and its first line, which holds
+	// the status and the main performance data of the Nagios plugins.
+	output, _ := NewBuffer(int64(c.OutputMaxSize))
+	firstLine := NewLineBuffer(c.OutputMaxSize)
+	writer := io.MultiWriter(output, firstLine)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	setSysProcAttr(cmd)
+
+	truncateAndLogOutput := func() (string, []PerfData) {
+		text, perfData := ParsePerfData(output.String())
+		if output.TotalWritten() > output.Size() {
+			// The beginning of the output is lost, the first line of
+			// what is left is incomplete and parsed from its own buffer.
+			_, rest, _ := strings.Cut(output.String(), "\n")
+			first, firstPerfData := ParsePerfData(firstLine.String())
+			text, perfData = parseLongOutput(rest)
+
+			text = fmt.Sprintf("%s\nCaptured %d of %d bytes\n...\n%s", first, output.Size(), output.TotalWritten(), text)
+			perfData = append(firstPerfData, perfData...)
+		}
+
+		c.perfLock.Lock()
+		c.perfData = perfData
+		c.perfLock.Unlock()
+
+		c.Logger.Debug("Check output", "output", text)
+		return text, perfData
+	}
+
+	// Start the check
+	if err := cmd.Start(); err != nil {
+		c.Logger.Error("Check failed to invoke", "error", err)
+		c.StatusHandler.updateCheck(HealthCritical, err.Error())
+		return
+	}
+
+	// Wait for the check to complete
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	timeout := 30 * time.Second
+	if c.Timeout > 0 {
+		timeout = c.Timeout
+	}
+
+	select {
+	case <-time.After(timeout):
+		if err := killCommandSubtree(cmd); err != nil {
+			c.Logger.Warn("Check failed to kill after timeout", "error", err)
+		}
+
+		msg := fmt.Sprintf("Timed out (%s) running check", timeout.String())
+		c.Logger.Warn("Timed out running check", "timeout", timeout.String())
+
+		// Wait for the process to exit so we never start another instance
+		// concurrently, and for its output to be copied before reading it.
+		<-waitCh
+
+		outputStr, perfData := truncateAndLogOutput()
+		if len(outputStr) > 0 {
+			msg += "\n\n" + outputStr
+		}
+		c.StatusHandler.updatePerfData(HealthCritical, msg, perfData)
+		return
+	case err = <-waitCh:
+		// The process returned before the timeout, proceed normally
+	}
+
+	// Check if the check passed
+	outputStr, perfData := truncateAndLogOutput()
+	if err == nil {
+		c.StatusHandler.updatePerfData(HealthPassing, outputStr, perfData)
+		return
+	}
+
+	// If the exit code is 1, set check as warning
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		c.StatusHandler.updatePerfData(HealthWarning, outputStr, perfData)
+		return
+	}
+
+	// Set the health as critical
+	c.StatusHandler.updatePerfData(HealthCritical, outputStr, perfData)
+}
+
+// joinArgs joins the script arguments into a single command line
+// to be interpreted by a shell.
+func joinArgs(args []string) string {
+	return strings.Join(args, " ")
+}
+
+// TTLState is the last status pushed to a TTL check.
+type TTLState struct {
+	Status    string
+	Output    string
+	UpdatedAt time.Time
+}
+
+// TTLStore is used to persist the state of TTL checks, so that
+// the expiry timer survives server restarts.
+type TTLStore interface {
+	LoadTTL(checkID string) (*TTLState, error)
+	SaveTTL(checkID string, state *TTLState) error
+}
+
+// TTLPollInterval is how often the TTL checks look up the status pushed to
+// the other servers of a cluster, which persist it in the TTLStore.
+const TTLPollInterval = 5 * time.Second
+
+// CheckTTL is used to apply a TTL to check status,
+// and enables clients to set the status of a check
+// but upon the TTL expiring, the check status is
+// automatically set to critical.
+type CheckTTL struct {
+	CheckID       string
+	ServiceID     string
+	TTL           time.Duration
+	Logger        *zap.SugaredLogger
+	OutputMaxSize int
+	Store         TTLStore
+	StatusHandler *StatusHandler
+
+	// PollInterval is how often the persisted status is looked up, so that the
+	// status pushed to another server applies. It is disabled if zero.
+	PollInterval time.Duration
+
+	timer          *time.Timer
+	lastOutput     string
+	lastUpdate     time.Time // When the status was last set, see refresh.
+	lastOutputLock sync.RWMutex
+	stop           bool
+	stopCh         chan struct{}
+	stopLock       sync.Mutex
+	stopWg         sync.WaitGroup
+}
+
+func (c *CheckTTL) CheckType() CheckType {
+	return CheckType{
+		TTL:           c.TTL,
+		OutputMaxSize: c.OutputMaxSize,
+	}
+}
+
+// Start is used to start a check ttl, runs until Stop()
+func (c *CheckTTL) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	c.timer = time.NewTimer(c.restore())
+	c.stopWg.Add(1)
+	go c.run()
+}
+
+// Stop is used to stop a check ttl.
+func (c *CheckTTL) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.timer.Stop()
+		c.stop = true
+		close(c.stopCh)
+	}
+
+	// Wait for the c.run() goroutine to complete before returning.
+	c.stopWg.Wait()
+}
+
+// run is used to handle TTL expiration and to update the check status
+func (c *CheckTTL) run() {
+	defer c.stopWg.Done()
+
+	var poll <-chan time.Time
+	if c.PollInterval > 0 && c.Store != nil {
+		ticker := time.NewTicker(c.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.timer.C:
+			// The expiry may be stale, if the status was set while it was pending.
+			remaining := c.refresh()
+			if remaining <= 0 {
+				remaining = c.remaining()
+			}
+
+			if remaining > 0 {
+				c.reset(remaining)
+				continue
+			}
+
+			c.Logger.Warn("Check missed TTL, is now critical", "check", c.CheckID)
+			c.StatusHandler.updateCheck(HealthCritical, c.getExpiredOutput())
+		case <-poll:
+			// The timer is restarted even if the TTL expired already.
+			if remaining := c.refresh(); remaining > 0 {
+				c.reset(remaining)
+			}
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// reset restarts the timer to expire after the given duration. The expiry
+// pending in the channel is dropped, unless run received it already.
+func (c *CheckTTL) reset(d time.Duration) {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.timer == nil || c.stop {
+		return
+	}
+
+	if !c.timer.Stop() {
+		select {
+		case <-c.timer.C:
+		default:
+		}
+	}
+
+	c.timer.Reset(d)
+}
+
+// restore loads the persisted state of the check and returns the time
+// left until the TTL expires. A check without state expires after a full TTL.
+func (c *CheckTTL) restore() time.Duration {
+	if c.Store == nil {
+		return c.TTL
+	}
+
+	state, err := c.Store.LoadTTL(c.CheckID)
+	if err != nil {
+		c.Logger.Errorw("Failed to restore TTL check state", "check", c.CheckID, "error", err)
+		return c.TTL
+	}
+
+	if state == nil {
+		return c.TTL
+	}
+
+	c.lastOutputLock.Lock()
+	c.lastOutput = state.Output
+	c.lastUpdate = state.UpdatedAt
+	c.lastOutputLock.Unlock()
+
+	remaining := c.TTL - time.Since(state.UpdatedAt)
+	if remaining <= 0 {
+		return 0
+	}
+
+	c.StatusHandler.updateCheck(state.Status, state.Output)
+
+	return remaining
+}
+
+// refresh applies the status persisted since the check was last updated, e.g.
+// pushed to another server of the cluster, and returns the time left until the
+// TTL expires. It returns 0 if the TTL expired.
+func (c *CheckTTL) refresh() time.Duration {
+	if c.Store == nil {
+		return 0
+	}
+
+	state, err := c.Store.LoadTTL(c.CheckID)
+	if err != nil {
+		c.Logger.Errorw("Failed to refresh TTL check state", "check", c.CheckID, "error", err)
+		return 0
+	}
+
+	c.lastOutputLock.Lock()
+	defer c.lastOutputLock.Unlock()
+
+	if state == nil || !state.UpdatedAt.After(c.lastUpdate) {
+		return 0
+	}
+
+	remaining := c.TTL - time.Since(state.UpdatedAt)
+	if remaining <= 0 {
+		return 0
+	}
+
+	c.lastOutput = state.Output
+	c.lastUpdate = state.UpdatedAt
+	c.StatusHandler.updateCheck(state.Status, state.Output)
+
+	return remaining
+}
+
+// remaining returns the time left until the TTL expires since the status was
+// last set, 0 if it expired or was never set.
+func (c *CheckTTL) remaining() time.Duration {
+	c.lastOutputLock.RLock()
+	defer c.lastOutputLock.RUnlock()
+
+	if c.lastUpdate.IsZero() {
+		return 0
+	}
+
+	return max(c.TTL-time.Since(c.lastUpdate), 0)
+}
+
+// getExpiredOutput formats the output for the case when the TTL is expired.
+func (c *CheckTTL) getExpiredOutput() string {
+	c.lastOutputLock.RLock()
+	defer c.lastOutputLock.RUnlock()
+
+	const prefix = "TTL expired"
+	if c.lastOutput == "" {
+		return prefix
+	}
+
+	return fmt.Sprintf("%s (last output before timeout follows): %s", prefix, c.lastOutput)
+}
+
+// SetStatus is used to update the status of the check,
+// and to renew the TTL. If expired, TTL is restarted.
+// output is returned (might be truncated)
+func (c *CheckTTL) SetStatus(status, output string) string {
+	c.Logger.Debug("Check status updated", "check", c.CheckID, "status", status)
+	total := len(output)
+	if c.OutputMaxSize > 0 && total > c.OutputMaxSize {
+		output = fmt.Sprintf("%s ... (captured %d of %d bytes)", output[:c.OutputMaxSize], c.OutputMaxSize, total)
+	}
+	c.StatusHandler.updateCheck(status, output)
+
+	// Store the last output so we can retain it if the TTL expires.
+	now := time.Now()
+	c.lastOutputLock.Lock()
+	c.lastOutput = output
+	c.lastUpdate = now
+	c.lastOutputLock.Unlock()
+
+	if c.Store != nil {
+		state := &TTLState{Status: status, Output: output, UpdatedAt: now}
+		if err := c.Store.SaveTTL(c.CheckID, state); err != nil {
+			c.Logger.Errorw("Failed to persist TTL check state", "check", c.CheckID, "error", err)
+		}
+	}
+
+	c.reset(c.TTL)
+
+	return output
+}
+
 type CheckHTTP struct {
 	HTTP             string
 	Header           map[string][]string
@@ -47,12 +547,11 @@ type CheckHTTP struct {
 	OutputMaxSize    int
 	StatusHandler    *StatusHandler
 	DisableRedirects bool
+	Scheduler        *Scheduler
 
 	httpClient *http.Client
-	stop       bool
-	stopCh     chan struct{}
+	job        *Job
 	stopLock   sync.Mutex
-	stopWg     sync.WaitGroup
 
 	// Set if checks are exposed through Connect proxies
 	// If set, this is the target of check()
@@ -106,39 +605,27 @@ func (c *CheckHTTP) Start() {
 		}
 	}
 
-	c.stop = false
-	c.stopCh = make(chan struct{})
-	c.stopWg.Add(1)
-	go c.run()
+	if c.job == nil {
+		target := c.HTTP
+		if c.ProxyHTTP != "" {
+			target = c.ProxyHTTP
+		}
+
+		c.job = c.Scheduler.Schedule(c.Interval, targetHost(target), func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
 }
 
 // Stop is used to stop an HTTP check.
 func (c *CheckHTTP) Stop() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
-	if !c.stop {
-		c.stop = true
-		close(c.stopCh)
-	}
 
-	// Wait for the c.run() goroutine to complete before returning.
-	c.stopWg.Wait()
-}
-
-// run is invoked by a goroutine to run until Stop() is called
-func (c *CheckHTTP) run() {
-	defer c.stopWg.Done()
-	// Get the randomized initial pause time
-	initialPauseTime := RandomStagger(c.Interval)
-	next := time.After(initialPauseTime)
-	for {
-		select {
-		case <-next:
-			c.check()
-			next = time.After(c.Interval)
-		case <-c.stopCh:
-			return
-		}
+	// Wait for the running check to complete before returning.
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
 	}
 }
 
@@ -227,13 +714,22 @@ type CheckTCP struct {
 	Logger          *zap.SugaredLogger
 	TLSClientConfig *tls.Config
 	StatusHandler   *StatusHandler
+	Scheduler       *Scheduler
 
 	dialer   *net.Dialer
-	stop     bool
-	stopCh   chan struct{}
+	job      *Job
 	stopLock sync.Mutex
 }
 
+func (c *CheckTCP) CheckType() CheckType {
+	return CheckType{
+		TCP:       c.TCP,
+		TCPUseTLS: c.TLSClientConfig != nil,
+		Interval:  c.Interval,
+		Timeout:   c.Timeout,
+	}
+}
+
 // Start is used to start a TCP check.
 // The check runs until stop is called
 func (c *CheckTCP) Start() {
@@ -250,34 +746,21 @@ func (c *CheckTCP) Start() {
 		}
 	}
 
-	c.stop = false
-	c.stopCh = make(chan struct{})
-	go c.run()
+	if c.job == nil {
+		c.job = c.Scheduler.Schedule(c.Interval, targetHost(c.TCP), func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
 }
 
 // Stop is used to stop a TCP check.
 func (c *CheckTCP) Stop() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
-	if !c.stop {
-		c.stop = true
-		close(c.stopCh)
-	}
-}
 
-// run is invoked by a goroutine to run until Stop() is called
-func (c *CheckTCP) run() {
-	// Get the randomized initial pause time
-	initialPauseTime := RandomStagger(c.Interval)
-	next := time.After(initialPauseTime)
-	for {
-		select {
-		case <-next:
-			c.check()
-			next = time.After(c.Interval)
-		case <-c.stopCh:
-			return
-		}
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
 	}
 }
 
@@ -322,13 +805,21 @@ type CheckUDP struct {
 	Timeout       time.Duration
 	Logger        *zap.SugaredLogger
 	StatusHandler *StatusHandler
+	Scheduler     *Scheduler
 
 	dialer   *net.Dialer
-	stop     bool
-	stopCh   chan struct{}
+	job      *Job
 	stopLock sync.Mutex
 }
 
+func (c *CheckUDP) CheckType() CheckType {
+	return CheckType{
+		UDP:      c.UDP,
+		Interval: c.Interval,
+		Timeout:  c.Timeout,
+	}
+}
+
 func (c *CheckUDP) Start() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
@@ -343,34 +834,21 @@ func (c *CheckUDP) Start() {
 		}
 	}
 
-	c.stop = false
-	c.stopCh = make(chan struct{})
-	go c.run()
+	if c.job == nil {
+		c.job = c.Scheduler.Schedule(c.Interval, targetHost(c.UDP), func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
 }
 
 func (c *CheckUDP) Stop() {
 	c.stopLock.Lock()
 	defer c.stopLock.Unlock()
-	if !c.stop {
-		c.stop = true
-		close(c.stopCh)
-	}
-}
 
-func (c *CheckUDP) run() {
-	// Get the randomized initial pause time
-	initialPauseTime := RandomStagger(c.Interval)
-	next := time.After(initialPauseTime)
-	for {
-		select {
-		case <-next:
-			c.check()
-			next = time.After(c.Interval)
-		case <-c.stopCh:
-			return
-		}
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
 	}
-
 }
 
 func (c *CheckUDP) check() {
@@ -422,6 +900,243 @@ func (c *CheckUDP) check() {
 	}
 }
 
+// CheckGRPC is used to periodically send request to a gRPC server
+// application that implements gRPC health-checking protocol.
+// The check is passing if returned status is SERVING.
+// The check is critical if the status is NOT_SERVING or connection
+// to the health service fails.
+// The check is warning if the status is UNKNOWN or SERVICE_UNKNOWN.
+type CheckGRPC struct {
+	ServiceID       string
+	GRPC            string
+	Interval        time.Duration
+	Timeout         time.Duration
+	Logger          *zap.SugaredLogger
+	TLSClientConfig *tls.Config
+	OutputMaxSize   int
+	StatusHandler   *StatusHandler
+	Scheduler       *Scheduler
+
+	probe    *GrpcHealthProbe
+	job      *Job
+	stopLock sync.Mutex
+
+	// Set if checks are exposed through Connect proxies
+	// If set, this is the target of check()
+	ProxyGRPC string
+}
+
+func (c *CheckGRPC) CheckType() CheckType {
+	return CheckType{
+		GRPC:          c.GRPC,
+		GRPCUseTLS:    c.TLSClientConfig != nil,
+		ProxyGRPC:     c.ProxyGRPC,
+		Interval:      c.Interval,
+		Timeout:       c.Timeout,
+		OutputMaxSize: c.OutputMaxSize,
+	}
+}
+
+// Start is used to start a gRPC check.
+// The check runs until stop is called
+func (c *CheckGRPC) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.probe == nil {
+		timeout := 10 * time.Second
+		if c.Timeout > 0 {
+			timeout = c.Timeout
+		}
+		c.probe = NewGrpcHealthProbe(c.GRPC, timeout, c.TLSClientConfig)
+	}
+
+	if c.OutputMaxSize < 1 {
+		c.OutputMaxSize = DefaultBufSize
+	}
+
+	if c.job == nil {
+		target := c.GRPC
+		if c.ProxyGRPC != "" {
+			target = c.ProxyGRPC
+		}
+
+		c.job = c.Scheduler.Schedule(c.Interval, targetHost(target), func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
+}
+
+// Stop is used to stop a gRPC check.
+func (c *CheckGRPC) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
+	}
+}
+
+// check is invoked periodically to perform the gRPC check
+func (c *CheckGRPC) check() {
+	target := c.GRPC
+	if c.ProxyGRPC != "" {
+		target = c.ProxyGRPC
+	}
+
+	status, err := c.probe.Check(target)
+	if err != nil {
+		c.Logger.Warn("Check gRPC health service query failed", "error", err)
+		c.StatusHandler.updateCheck(HealthCritical, truncate(err.Error(), c.OutputMaxSize))
+		return
+	}
+
+	result := fmt.Sprintf("gRPC check %s: %s", target, status)
+	switch status {
+	case hv1.HealthCheckResponse_SERVING:
+		c.StatusHandler.updateCheck(HealthPassing, result)
+	case hv1.HealthCheckResponse_NOT_SERVING:
+		c.StatusHandler.updateCheck(HealthCritical, result)
+	default:
+		// UNKNOWN and SERVICE_UNKNOWN mean the server is reachable
+		// but can't vouch for the requested service.
+		c.StatusHandler.updateCheck(HealthWarning, result)
+	}
+}
+
+// CheckH2PING is used to periodically send an HTTP/2 PING frame over
+// a dedicated connection to determine the health of the HTTP/2 layer.
+// The check is passing if the PING is acknowledged within the timeout.
+// The check is critical if the connection or the PING fails.
+type CheckH2PING struct {
+	ServiceID       string
+	H2PING          string
+	Interval        time.Duration
+	Timeout         time.Duration
+	Logger          *zap.SugaredLogger
+	TLSClientConfig *tls.Config
+	StatusHandler   *StatusHandler
+	Scheduler       *Scheduler
+
+	job      *Job
+	stopLock sync.Mutex
+}
+
+func (c *CheckH2PING) CheckType() CheckType {
+	return CheckType{
+		H2PING:       c.H2PING,
+		H2PingUseTLS: c.TLSClientConfig != nil,
+		Interval:     c.Interval,
+		Timeout:      c.Timeout,
+	}
+}
+
+// Start is used to start an HTTP/2 PING check.
+// The check runs until stop is called
+func (c *CheckH2PING) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	if c.TLSClientConfig != nil && len(c.TLSClientConfig.NextProtos) == 0 {
+		// HTTP/2 over TLS must be negotiated through ALPN.
+		c.TLSClientConfig = c.TLSClientConfig.Clone()
+		c.TLSClientConfig.NextProtos = []string{http2.NextProtoTLS}
+	}
+
+	if c.job == nil {
+		c.job = c.Scheduler.Schedule(c.Interval, targetHost(c.H2PING), func() {
+			c.StatusHandler.measure(c.check)
+		})
+	}
+}
+
+// Stop is used to stop an HTTP/2 PING check.
+func (c *CheckH2PING) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.job != nil {
+		c.Scheduler.Stop(c.job)
+		c.job = nil
+	}
+}
+
+// check is invoked periodically to perform the HTTP/2 PING check
+func (c *CheckH2PING) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	var checkType string
+
+	// Prior knowledge (h2c) is used when TLS is disabled.
+	transport := &http2.Transport{AllowHTTP: c.TLSClientConfig == nil}
+	if c.TLSClientConfig == nil {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, `tcp`, c.H2PING)
+		checkType = "h2c"
+	} else {
+		dialer := &tls.Dialer{Config: c.TLSClientConfig}
+		conn, err = dialer.DialContext(ctx, `tcp`, c.H2PING)
+		checkType = "h2"
+	}
+
+	if err != nil {
+		c.Logger.Warn(fmt.Sprintf("Check %s connection failed", checkType), "error", err)
+		c.StatusHandler.updateCheck(HealthCritical, fmt.Sprintf("Failed to dial to %s: %s", c.H2PING, err))
+		return
+	}
+	defer func() {
+		// The connection is already closed if the client connection was created.
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			c.Logger.Errorw("Error closing HTTP/2 connection.", "error", err)
+		}
+	}()
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if proto := tlsConn.ConnectionState().NegotiatedProtocol; proto != http2.NextProtoTLS {
+			c.StatusHandler.updateCheck(HealthCritical, fmt.Sprintf("Server %s did not negotiate HTTP/2, got %q", c.H2PING, proto))
+			return
+		}
+	}
+
+	clientConn, err := transport.NewClientConn(conn)
+	if err != nil {
+		c.StatusHandler.updateCheck(HealthCritical, fmt.Sprintf("Failed to create HTTP/2 client connection: %s", err))
+		return
+	}
+	defer func() {
+		if err := clientConn.Close(); err != nil {
+			c.Logger.Errorw("Error closing HTTP/2 client connection.", "error", err)
+		}
+	}()
+
+	start := time.Now()
+	err = clientConn.Ping(ctx)
+	rtt := time.Since(start)
+	if err != nil {
+		c.StatusHandler.updateCheck(HealthCritical, fmt.Sprintf("HTTP/2 %s ping %s failed: %s", checkType, c.H2PING, err))
+		return
+	}
+
+	c.StatusHandler.updateCheck(HealthPassing, fmt.Sprintf("HTTP/2 %s ping %s: Success, RTT: %s", checkType, c.H2PING, rtt))
+}
+
+// truncate limits the size of the output reported by a check.
+func truncate(output string, size int) string {
+	if size > 0 && len(output) > size {
+		return output[:size]
+	}
+
+	return output
+}
+
 // RandomStagger returns an interval between 0 and the duration
 func RandomStagger(interval time.Duration) time.Duration {
 	if interval == 0 {
@@ -0,0 +1,101 @@
+package checker
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultEventsBuffer is the number of transitions a subscriber may lag
+// behind before transitions are dropped.
+const DefaultEventsBuffer = 1024
+
+// Transition is emitted when the status reported by a check changes. It is also
+// emitted when a check stops flapping, Settled is then set and the status may be
+// the same as the previous one, since the changes of a flapping check are ignored.
+//
+// Removed is set when the server stops running the check, because it was deleted
+// or because it is now run by another server, in which case Moved is set too. The
+// status of a removed check is its last status.
+type Transition struct {
+	CheckID  string    `json:"check_id"`
+	Previous string    `json:"previous"`
+	Status   string    `json:"status"`
+	Output   string    `json:"output"`
+	Flapping bool      `json:"flapping"`
+	Settled  bool      `json:"settled,omitempty"`
+	Removed  bool      `json:"removed,omitempty"`
+	Moved    bool      `json:"moved,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Consecutive results counted by the status handler when the transition happened.
+	SuccessCount int `json:"success_count"`
+	FailureCount int `json:"failure_count"`
+
+	// PreviousDuration is how long the check stayed in the previous status.
+	PreviousDuration time.Duration `json:"previous_duration"`
+}
+
+// Events fans out the transitions of all checks to the subscribers.
+type Events struct {
+	lock        sync.RWMutex
+	subscribers map[chan *Transition]struct{}
+	dropped     func(t *Transition)
+}
+
+func NewEvents() *Events {
+	return &Events{
+		subscribers: make(map[chan *Transition]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving all transitions published from now on.
+// Transitions are dropped if the subscriber lags more than the buffer behind.
+func (e *Events) Subscribe(buffer int) chan *Transition {
+	if buffer <= 0 {
+		buffer = DefaultEventsBuffer
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	ch := make(chan *Transition, buffer)
+	e.subscribers[ch] = struct{}{}
+
+	return ch
+}
+
+// Unsubscribe stops the delivery of transitions to the channel and closes it.
+func (e *Events) Unsubscribe(ch chan *Transition) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, ok := e.subscribers[ch]; ok {
+		delete(e.subscribers, ch)
+		close(ch)
+	}
+}
+
+// OnDropped sets a function called for every transition dropped
+// because a subscriber couldn't keep up.
+func (e *Events) OnDropped(fn func(t *Transition)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.dropped = fn
+}
+
+// Publish delivers the transition to all subscribers without blocking.
+func (e *Events) Publish(t *Transition) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- t:
+		default:
+			if e.dropped != nil {
+				e.dropped(t)
+			}
+		}
+	}
+}
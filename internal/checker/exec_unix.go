@@ -0,0 +1,34 @@
+//go:build !windows
+
+package checker
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// newCommand returns a command which runs args directly, or through
+// the given shell when one is configured.
+func newCommand(shell string, args []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, errors.New("need an executable to run")
+	}
+
+	if shell != "" {
+		return exec.Command(shell, "-c", joinArgs(args)), nil
+	}
+
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// setSysProcAttr runs the command in its own process group, so that
+// the whole subtree can be killed on timeout.
+func setSysProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killCommandSubtree kills the process group of the command.
+func killCommandSubtree(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
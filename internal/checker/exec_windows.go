@@ -0,0 +1,31 @@
+//go:build windows
+
+package checker
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// newCommand returns a command which runs args directly, or through
+// the given shell when one is configured.
+func newCommand(shell string, args []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, errors.New("need an executable to run")
+	}
+
+	if shell != "" {
+		return exec.Command(shell, "/C", joinArgs(args)), nil
+	}
+
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// setSysProcAttr is a no-op on Windows.
+func setSysProcAttr(cmd *exec.Cmd) {}
+
+// killCommandSubtree kills the command process. Windows has no process
+// groups, so children of the command are not reaped.
+func killCommandSubtree(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
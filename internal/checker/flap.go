@@ -0,0 +1,96 @@
+package checker
+
+const (
+	// DefaultFlapWindow is the number of recent results considered
+	// by the flap detection when the window is not set.
+	DefaultFlapWindow = 21
+
+	// Weights of the oldest and the newest state change in the window,
+	// recent changes count more than old ones.
+	flapOldestWeight = 0.8
+	flapNewestWeight = 1.2
+)
+
+// FlapNotifier is implemented by notifiers which want to know when
+// a check starts or stops flapping.
+type FlapNotifier interface {
+	UpdateFlapping(flapping bool)
+}
+
+// flapDetector detects checks oscillating between statuses, based on the
+// percentage of state changes over a sliding window of recent results.
+// A check starts flapping once the percentage reaches the high threshold,
+// and stops flapping once it drops below the low threshold.
+type flapDetector struct {
+	window   int
+	low      float64
+	high     float64
+	results  []string
+	flapping bool
+}
+
+func newFlapDetector(window int, low, high float64) *flapDetector {
+	if window < 3 {
+		window = DefaultFlapWindow
+	}
+
+	if low <= 0 || low > high {
+		low = high
+	}
+
+	return &flapDetector{
+		window:  window,
+		low:     low,
+		high:    high,
+		results: make([]string, 0, window),
+	}
+}
+
+// record adds the status of a result to the window, and reports whether
+// the flapping state of the check changed.
+func (d *flapDetector) record(status string) bool {
+	if len(d.results) == d.window {
+		copy(d.results, d.results[1:])
+		d.results = d.results[:d.window-1]
+	}
+	d.results = append(d.results, status)
+
+	// Not enough history to tell yet.
+	if len(d.results) < d.window {
+		return false
+	}
+
+	change := d.percentStateChange()
+	switch {
+	case !d.flapping && change >= d.high:
+		d.flapping = true
+		return true
+	case d.flapping && change < d.low:
+		d.flapping = false
+		return true
+	default:
+		return false
+	}
+}
+
+// percentStateChange computes the weighted percentage of state changes in the window.
+func (d *flapDetector) percentStateChange() float64 {
+	changes := len(d.results) - 1
+	if changes <= 0 {
+		return 0
+	}
+
+	step := 0.0
+	if changes > 1 {
+		step = (flapNewestWeight - flapOldestWeight) / float64(changes-1)
+	}
+
+	total := 0.0
+	for i := 1; i < len(d.results); i++ {
+		if d.results[i] != d.results[i-1] {
+			total += flapOldestWeight + float64(i-1)*step
+		}
+	}
+
+	return total / float64(changes) * 100
+}
@@ -0,0 +1,95 @@
+package checker
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultForwardInterval is how often the results forwarded by the other servers are received.
+const DefaultForwardInterval = time.Second
+
+// RemoteResult is the result of a remote check measured by an agent of the location.
+type RemoteResult struct {
+	CheckID  string
+	Location string
+	Status   string
+	Output   string
+	Latency  time.Duration
+	Time     time.Time
+}
+
+// ResultQueue forwards the results of the remote checks reported to a server to the
+// server running the check, so that the agents may connect to any server of a cluster.
+type ResultQueue interface {
+	// PushResult queues the result for the server running the check.
+	PushResult(result *RemoteResult) error
+
+	// PopResults removes the results queued for the checks with the given IDs
+	// and returns them, ordered by the time they were measured at.
+	PopResults(checkIDs []string) ([]*RemoteResult, error)
+}
+
+// ForwardResults forwards to the queue the results reported for the remote checks
+// run by another server, and reports the results forwarded by the other servers
+// to the remote checks run by this one every interval, until the context is done.
+func (m *Manager) ForwardResults(ctx context.Context, queue ResultQueue, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultForwardInterval
+	}
+
+	m.lock.Lock()
+	m.queue = queue
+	m.lock.Unlock()
+
+	defer func() {
+		m.lock.Lock()
+		m.queue = nil
+		m.lock.Unlock()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.ReceiveResults(queue); err != nil {
+				m.logger.Errorw("Failed to receive the forwarded results", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReceiveResults reports the results forwarded by the other servers to the remote
+// checks run by this one. The results older than the last one of their location
+// are dropped, e.g. when the agents of a location report to different servers.
+func (m *Manager) ReceiveResults(queue ResultQueue) error {
+	var checkIDs []string
+
+	m.lock.Lock()
+	for checkID, runner := range m.checks {
+		if _, ok := runner.(*CheckRemote); ok {
+			checkIDs = append(checkIDs, checkID)
+		}
+	}
+	m.lock.Unlock()
+
+	if len(checkIDs) == 0 {
+		return nil
+	}
+
+	results, err := queue.PopResults(checkIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if err = m.Report(r.CheckID, r.Location, r.Status, r.Output, r.Latency, r.Time); err != nil {
+			m.logger.Debugw("Forwarded result dropped", "check", r.CheckID, "location", r.Location, "error", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	hv1 "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GrpcHealthProbe connects to gRPC application and queries health service for application/service status.
+type GrpcHealthProbe struct {
+	request     *hv1.HealthCheckRequest
+	timeout     time.Duration
+	dialOptions []grpc.DialOption
+}
+
+// NewGrpcHealthProbe constructs GrpcHealthProbe from target string in format
+// server[/service]
+// If service is omitted, health of the entire application is probed
+func NewGrpcHealthProbe(target string, timeout time.Duration, tlsConfig *tls.Config) *GrpcHealthProbe {
+	serverAndService := strings.SplitN(target, "/", 2)
+
+	request := hv1.HealthCheckRequest{}
+	if len(serverAndService) > 1 {
+		request.Service = serverAndService[1]
+	}
+
+	var dialOptions []grpc.DialOption
+	if tlsConfig != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	return &GrpcHealthProbe{
+		request:     &request,
+		timeout:     timeout,
+		dialOptions: dialOptions,
+	}
+}
+
+// Check if the target of this GrpcHealthProbe is healthy.
+// An error is returned only if the health service could not be queried,
+// otherwise the serving status reported by the target is returned.
+func (probe *GrpcHealthProbe) Check(target string) (hv1.HealthCheckResponse_ServingStatus, error) {
+	serverAndService := strings.SplitN(target, "/", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), probe.timeout)
+	defer cancel()
+
+	connection, err := grpc.NewClient(serverAndService[0], probe.dialOptions...)
+	if err != nil {
+		return hv1.HealthCheckResponse_UNKNOWN, err
+	}
+	defer connection.Close()
+
+	client := hv1.NewHealthClient(connection)
+	response, err := client.Check(ctx, probe.request)
+	if err != nil {
+		return hv1.HealthCheckResponse_UNKNOWN, fmt.Errorf("gRPC %s health check failed: %w", target, err)
+	}
+
+	return response.Status, nil
+}
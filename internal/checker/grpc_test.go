@@ -0,0 +1,126 @@
+package checker
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	hv1 "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// statusRecorder records the last status reported by a check.
+type statusRecorder struct {
+	lock   sync.Mutex
+	status string
+	output string
+}
+
+func (r *statusRecorder) UpdateCheck(status, output string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.status, r.output = status, output
+}
+
+func (r *statusRecorder) last() (string, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.status, r.output
+}
+
+// startHealthServer serves the standard health service on a random port.
+func startHealthServer(t *testing.T) (*health.Server, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthServer := health.NewServer()
+	server := grpc.NewServer()
+	hv1.RegisterHealthServer(server, healthServer)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return healthServer, listener.Addr().String()
+}
+
+func TestGrpcHealthProbe(t *testing.T) {
+	healthServer, address := startHealthServer(t)
+	healthServer.SetServingStatus("api", hv1.HealthCheckResponse_NOT_SERVING)
+
+	tests := []struct {
+		name   string
+		target string
+		status hv1.HealthCheckResponse_ServingStatus
+		err    bool
+	}{
+		{name: "server", target: address, status: hv1.HealthCheckResponse_SERVING},
+		{name: "service", target: address + "/api", status: hv1.HealthCheckResponse_NOT_SERVING},
+		{name: "unknown service", target: address + "/unknown", status: hv1.HealthCheckResponse_UNKNOWN, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := NewGrpcHealthProbe(tt.target, time.Second, nil)
+
+			status, err := probe.Check(tt.target)
+			if (err != nil) != tt.err {
+				t.Fatalf("Check() error = %v, want error %v", err, tt.err)
+			}
+
+			if status != tt.status {
+				t.Errorf("Check() = %s, want %s", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestCheckGRPC(t *testing.T) {
+	healthServer, address := startHealthServer(t)
+
+	tests := []struct {
+		name    string
+		serving hv1.HealthCheckResponse_ServingStatus
+		target  string
+		status  string
+	}{
+		{name: "serving", serving: hv1.HealthCheckResponse_SERVING, target: address + "/api", status: HealthPassing},
+		{name: "not serving", serving: hv1.HealthCheckResponse_NOT_SERVING, target: address + "/api", status: HealthCritical},
+		{name: "unknown", serving: hv1.HealthCheckResponse_UNKNOWN, target: address + "/api", status: HealthWarning},
+		{name: "service unknown", serving: hv1.HealthCheckResponse_SERVICE_UNKNOWN, target: address + "/api", status: HealthWarning},
+		{name: "not registered", target: address + "/missing", status: HealthCritical},
+		{name: "unreachable", target: "127.0.0.1:1", status: HealthCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthServer.SetServingStatus("api", tt.serving)
+
+			logger := zap.NewNop().Sugar()
+			recorder := &statusRecorder{}
+			check := &CheckGRPC{
+				GRPC:          tt.target,
+				Logger:        logger,
+				StatusHandler: NewStatusHandler(recorder, logger, 0, 0, 0),
+				OutputMaxSize: DefaultBufSize,
+				probe:         NewGrpcHealthProbe(tt.target, time.Second, nil),
+			}
+
+			check.check()
+
+			if status, output := recorder.last(); status != tt.status {
+				t.Errorf("status = %q (%s), want %q", status, output, tt.status)
+			}
+		})
+	}
+}
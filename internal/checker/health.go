@@ -26,9 +26,12 @@ type HealthCheck struct {
 	Notes       string
 	Status      string
 	Output      string
+	Flapping    bool
 	ServiceID   string
 	ServiceName string
 	ServiceTags []string
+	Labels      map[string]string `json:",omitempty"`
+	Locations   []*LocationStatus `json:",omitempty"` // The status in each location of a remote check.
 	Type        string
 	Namespace   string `json:",omitempty"`
 	Partition   string `json:",omitempty"`
@@ -0,0 +1,676 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Check is implemented by every check runner managed by the Manager.
+type Check interface {
+	Start()
+	Stop()
+	CheckType() CheckType
+}
+
+// Definition is a check together with the details about its execution.
+type Definition struct {
+	Check *HealthCheck
+	Type  *CheckType
+}
+
+// DefinitionStore is the source of the checks loaded by the Manager at startup.
+type DefinitionStore interface {
+	Definitions(ctx context.Context) ([]*Definition, error)
+}
+
+// NotifierFactory returns an additional notifier for the given check,
+// e.g. to keep the history of its results.
+type NotifierFactory func(check *HealthCheck) CheckNotifier
+
+// Manager owns the lifecycle of all running checks. Checks can be
+// added, updated and removed at runtime without restarting the others.
+//
+// When servers share the checks, e.g. in a cluster, the Manager only runs the
+// checks it owns. It keeps the definitions of the others, so that it can take
+// them over when they change owner, and reports them in the State with the
+// status shared by their owner, see ShareStatus.
+type Manager struct {
+	state     *State
+	events    *Events
+	shares    *Events
+	scheduler *Scheduler
+	ttlStore  TTLStore
+	logger    *zap.SugaredLogger
+
+	lock        sync.Mutex
+	definitions map[string]*Definition
+	owner       func(checkID string) bool
+	checks      map[string]Check
+	handlers    map[string]*StatusHandler
+	factories   []NotifierFactory
+
+	// reapAfter is how long each check may stay critical before its service is deregistered,
+	// restored are the checks whose critical time was looked up in their history.
+	reapAfter map[string]time.Duration
+	restored  map[string]bool
+
+	// shared is the ModifyIndex of the status of each check last saved in the StatusStore.
+	shared map[string]uint64
+
+	// queue forwards the results of the remote checks run by the other servers, see ForwardResults.
+	queue ResultQueue
+
+	// remoteWatchers are signalled whenever the definition of a remote check is added, updated or removed.
+	remoteWatchers map[chan<- struct{}]struct{}
+}
+
+func NewManager(state *State, ttlStore TTLStore, logger *zap.SugaredLogger) *Manager {
+	dropped := func(t *Transition) {
+		logger.Warnw("Transition dropped, a subscriber is too slow", "check", t.CheckID, "status", t.Status)
+	}
+
+	events := NewEvents()
+	events.OnDropped(dropped)
+
+	shares := NewEvents()
+	shares.OnDropped(dropped)
+
+	return &Manager{
+		state:       state,
+		events:      events,
+		shares:      shares,
+		scheduler:   NewScheduler(logger),
+		ttlStore:    ttlStore,
+		logger:      logger,
+		definitions: make(map[string]*Definition),
+		checks:      make(map[string]Check),
+		handlers:    make(map[string]*StatusHandler),
+		reapAfter:   make(map[string]time.Duration),
+		restored:    make(map[string]bool),
+		shared:      make(map[string]uint64),
+
+		remoteWatchers: make(map[chan<- struct{}]struct{}),
+	}
+}
+
+// Events returns the transitions of all checks run by the Manager.
+func (m *Manager) Events() *Events {
+	return m.events
+}
+
+// SharedEvents returns the transitions of the checks run by the other servers,
+// as their status is shared with the Manager, see ShareStatus. The check deleted
+// while run by another server is published as removed.
+func (m *Manager) SharedEvents() *Events {
+	return m.shares
+}
+
+// Scheduler returns the scheduler of the interval checks, it must be
+// run for them to run.
+func (m *Manager) Scheduler() *Scheduler {
+	return m.scheduler
+}
+
+// AddNotifier registers a factory of notifiers, which are attached to
+// every check started afterward.
+func (m *Manager) AddNotifier(factory NotifierFactory) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.factories = append(m.factories, factory)
+}
+
+// Load starts all checks of the store. Invalid checks are logged and skipped,
+// so that one bad definition doesn't prevent the others from running.
+func (m *Manager) Load(ctx context.Context, store DefinitionStore) error {
+	definitions, err := store.Definitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range definitions {
+		if err := m.Add(def.Check, def.Type); err != nil {
+			m.logger.Errorw("Failed to start check", "check", def.Check.CheckID, "error", err)
+		}
+	}
+
+	m.logger.Infow("Checks loaded.", "count", len(definitions))
+
+	return nil
+}
+
+// SetOwner restricts the checks run to the ones the owner function accepts,
+// call Rebalance whenever its answers change. All checks are run by default.
+func (m *Manager) SetOwner(owner func(checkID string) bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.owner = owner
+}
+
+// Add starts a new check, if it is owned by the server. It fails if
+// a check with the same ID already exists.
+func (m *Manager) Add(check *HealthCheck, chkType *CheckType) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.definitions[check.CheckID]; ok {
+		return fmt.Errorf("check %q already exists", check.CheckID)
+	}
+
+	return m.define(check, chkType)
+}
+
+// Update replaces a check, only the check with the given ID is restarted.
+func (m *Manager) Update(check *HealthCheck, chkType *CheckType) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.definitions[check.CheckID]; !ok {
+		return fmt.Errorf("check %q does not exist", check.CheckID)
+	}
+
+	return m.update(check, chkType)
+}
+
+// update replaces the definition of the check, and restarts it if it is running.
+func (m *Manager) update(check *HealthCheck, chkType *CheckType) error {
+	if _, ok := m.checks[check.CheckID]; !ok {
+		return m.define(check, chkType)
+	}
+
+	// Keep reporting the last known status until the check runs again.
+	if existing, ok := m.state.Check(check.CheckID); ok && check.Status == "" {
+		updated := *check
+		updated.Status = existing.Status
+		updated.Output = existing.Output
+		check = &updated
+	}
+
+	// An invalid definition leaves the check running as it was.
+	if err := validate(check, chkType); err != nil {
+		return err
+	}
+
+	// The check stays in the state, so that it's not reported as removed
+	// and keeps how long it has been critical.
+	maintenance := m.handlers[check.CheckID].Maintenance()
+	m.stop(check.CheckID)
+
+	if err := m.add(check, chkType); err != nil {
+		return err
+	}
+
+	m.handlers[check.CheckID].SetMaintenance(maintenance)
+	m.setDefinition(check, chkType)
+
+	return nil
+}
+
+// Remove stops the check with the given ID and forgets about it.
+func (m *Manager) Remove(checkID string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.deregister(checkID)
+}
+
+// Definition returns the definition of the check with the given ID,
+// whether it is run by the server or not.
+func (m *Manager) Definition(checkID string) (*Definition, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	def, ok := m.definitions[checkID]
+	return def, ok
+}
+
+// Rebalance starts the checks the server became the owner of, and stops
+// the checks now owned by another server.
+func (m *Manager) Rebalance() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var started, stopped int
+	for checkID, def := range m.definitions {
+		_, running := m.checks[checkID]
+		owned := m.owns(checkID)
+
+		switch {
+		case owned && !running:
+			if err := m.add(def.Check, def.Type); err != nil {
+				m.logger.Errorw("Failed to start check", "check", checkID, "error", err)
+				continue
+			}
+			started++
+		case !owned && running:
+			// The check stays in the state, with its last status until its new owner shares it.
+			m.removed(m.events, checkID, true)
+			m.stop(checkID)
+			stopped++
+		}
+	}
+
+	if started > 0 || stopped > 0 {
+		m.logger.Infow("Checks rebalanced.", "started", started, "stopped", stopped, "running", len(m.checks))
+	}
+}
+
+// Get returns the running check with the given ID.
+func (m *Manager) Get(checkID string) (Check, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	check, ok := m.checks[checkID]
+	return check, ok
+}
+
+// SetMaintenance puts the check with the given ID under maintenance,
+// or ends its maintenance if the reason is empty.
+func (m *Manager) SetMaintenance(checkID, reason string) {
+	m.lock.Lock()
+	handler, ok := m.handlers[checkID]
+	m.lock.Unlock()
+
+	if ok {
+		handler.SetMaintenance(reason)
+	}
+}
+
+// Report updates the remote check with the result reported by an agent of the location.
+// The result of a remote check run by another server is forwarded to it, see ForwardResults.
+func (m *Manager) Report(checkID, location, status, output string, latency time.Duration, at time.Time) error {
+	m.lock.Lock()
+	runner, ok := m.checks[checkID]
+	def, defined := m.definitions[checkID]
+	queue := m.queue
+	m.lock.Unlock()
+
+	if !ok && defined && def.Type.IsRemote() && queue != nil {
+		if at.IsZero() {
+			at = time.Now()
+		}
+
+		return queue.PushResult(&RemoteResult{
+			CheckID:  checkID,
+			Location: location,
+			Status:   status,
+			Output:   output,
+			Latency:  latency,
+			Time:     at,
+		})
+	}
+
+	if !ok {
+		return fmt.Errorf("check %q does not exist", checkID)
+	}
+
+	remote, ok := runner.(*CheckRemote)
+	if !ok {
+		return fmt.Errorf("check %q is not a remote check", checkID)
+	}
+
+	return remote.Report(location, status, output, latency, at)
+}
+
+// RemoteDefinitions returns the remote checks run from the location, whether
+// they are run by the server or not.
+func (m *Manager) RemoteDefinitions(location string) []*Definition {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var definitions []*Definition
+	for _, def := range m.definitions {
+		if def.Type.IsRemote() && slices.Contains(def.Type.Locations, location) {
+			definitions = append(definitions, def)
+		}
+	}
+
+	return definitions
+}
+
+// NotifyRemote registers a channel which is signalled whenever a remote check
+// is added, updated or removed. The channel should be buffered, signals are
+// dropped if it is full.
+func (m *Manager) NotifyRemote(ch chan<- struct{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.remoteWatchers[ch] = struct{}{}
+}
+
+// StopNotifyRemote removes a channel registered with NotifyRemote.
+func (m *Manager) StopNotifyRemote(ch chan<- struct{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.remoteWatchers, ch)
+}
+
+// Stop stops all running checks.
+func (m *Manager) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for checkID := range m.checks {
+		m.remove(checkID)
+	}
+
+	m.logger.Infow("All checks stopped.")
+}
+
+// define records the definition of the check, and starts it if it is owned by the server.
+func (m *Manager) define(check *HealthCheck, chkType *CheckType) error {
+	if m.owns(check.CheckID) {
+		if err := m.add(check, chkType); err != nil {
+			return err
+		}
+	} else {
+		if err := validate(check, chkType); err != nil {
+			return err
+		}
+
+		m.mirror(check, nil)
+	}
+
+	m.setDefinition(check, chkType)
+
+	return nil
+}
+
+// setDefinition records the definition of the check, the agents are told
+// whenever a remote check is added, updated or removed.
+func (m *Manager) setDefinition(check *HealthCheck, chkType *CheckType) {
+	previous, ok := m.definitions[check.CheckID]
+	m.definitions[check.CheckID] = &Definition{Check: check, Type: chkType}
+
+	if chkType.IsRemote() || (ok && previous.Type.IsRemote()) {
+		m.notifyRemote()
+	}
+}
+
+func (m *Manager) owns(checkID string) bool {
+	return m.owner == nil || m.owner(checkID)
+}
+
+func validate(check *HealthCheck, chkType *CheckType) error {
+	if check.CheckID == "" {
+		return fmt.Errorf("check ID is required")
+	}
+
+	if err := chkType.Validate(); err != nil {
+		return fmt.Errorf("check %q is not valid: %w", check.CheckID, err)
+	}
+
+	return nil
+}
+
+func (m *Manager) add(check *HealthCheck, chkType *CheckType) error {
+	if err := validate(check, chkType); err != nil {
+		return err
+	}
+
+	logger := m.logger.With("check", check.CheckID)
+
+	// New checks are critical until they report otherwise.
+	health := *check
+	if health.Status == "" {
+		health.Status = HealthCritical
+	}
+
+	// Failures before warning defaults to failures before critical.
+	successBeforePassing, failuresBeforeCritical := chkType.SuccessBeforePassing, chkType.FailuresBeforeCritical
+	failuresBeforeWarning := chkType.FailuresBeforeWarning
+	if failuresBeforeWarning == 0 {
+		failuresBeforeWarning = chkType.FailuresBeforeCritical
+	}
+
+	// The thresholds of remote checks are applied to each of their
+	// locations, the combined status is reported as is.
+	if chkType.IsRemote() {
+		successBeforePassing, failuresBeforeWarning, failuresBeforeCritical = 0, 0, 0
+	}
+
+	notifiers := Notifiers{m.state.Notifier(check.CheckID)}
+	for _, factory := range m.factories {
+		notifiers = append(notifiers, factory(&health))
+	}
+
+	statusHandler := NewStatusHandler(notifiers, logger, successBeforePassing, failuresBeforeWarning, failuresBeforeCritical)
+
+	statusHandler.EmitTransitions(check.CheckID, health.Status, m.events)
+	statusHandler.EnableFlapDetection(chkType.FlapWindow, chkType.FlapLowThreshold, chkType.FlapHighThreshold)
+
+	runner, err := m.newCheck(&health, chkType, statusHandler, logger)
+	if err != nil {
+		return err
+	}
+
+	m.state.AddCheck(&health)
+	m.checks[check.CheckID] = runner
+	m.handlers[check.CheckID] = statusHandler
+
+	if chkType.DeregisterCriticalServiceAfter > 0 && check.ServiceID != "" {
+		m.reapAfter[check.CheckID] = chkType.DeregisterCriticalServiceAfter
+	}
+
+	if ttl, ok := runner.(*CheckTTL); ok {
+		TTLChecks.Register(ttl)
+	}
+
+	runner.Start()
+
+	return nil
+}
+
+func (m *Manager) remove(checkID string) {
+	if m.stop(checkID) {
+		m.state.RemoveCheck(checkID)
+	}
+}
+
+// deregister forgets about the deleted check, and stops it if it is running.
+func (m *Manager) deregister(checkID string) {
+	if _, ok := m.definitions[checkID]; !ok {
+		return
+	}
+
+	if _, running := m.checks[checkID]; running {
+		m.removed(m.events, checkID, false)
+	} else {
+		m.removed(m.shares, checkID, false)
+	}
+
+	if m.definitions[checkID].Type.IsRemote() {
+		m.notifyRemote()
+	}
+
+	delete(m.definitions, checkID)
+	m.stop(checkID)
+	m.state.RemoveCheck(checkID)
+}
+
+// removed emits the Transition of the check the server stops running, so that its
+// alerts are resolved if it was deleted, or dropped if it moved to another server.
+func (m *Manager) removed(events *Events, checkID string, moved bool) {
+	check, ok := m.state.Check(checkID)
+	if !ok {
+		return
+	}
+
+	events.Publish(&Transition{
+		CheckID:  checkID,
+		Previous: check.Status,
+		Status:   check.Status,
+		Output:   check.Output,
+		Removed:  true,
+		Moved:    moved,
+		Time:     time.Now(),
+	})
+}
+
+// stop stops the check and forgets about it, it reports whether the check was running.
+func (m *Manager) stop(checkID string) bool {
+	runner, ok := m.checks[checkID]
+	if !ok {
+		return false
+	}
+
+	runner.Stop()
+	TTLChecks.Deregister(checkID)
+	delete(m.checks, checkID)
+	delete(m.handlers, checkID)
+	delete(m.reapAfter, checkID)
+	delete(m.restored, checkID)
+	delete(m.shared, checkID)
+
+	return true
+}
+
+func (m *Manager) notifyRemote() {
+	for ch := range m.remoteWatchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// newCheck builds the check runner matching the check type.
+func (m *Manager) newCheck(check *HealthCheck, chkType *CheckType, statusHandler *StatusHandler, logger *zap.SugaredLogger) (Check, error) {
+	interval := chkType.Interval
+	if interval > 0 && interval < MinInterval {
+		logger.Warnw("Check has interval below minimum", "minimum_interval", MinInterval)
+		interval = MinInterval
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         chkType.TLSServerName,
+		InsecureSkipVerify: chkType.TLSSkipVerify,
+	}
+
+	switch {
+	case chkType.IsRemote():
+		return &CheckRemote{
+			CheckID:       check.CheckID,
+			Type:          chkType,
+			StaleAfter:    StaleIntervals*interval + chkType.Timeout,
+			Logger:        logger,
+			StatusHandler: statusHandler,
+		}, nil
+	case chkType.IsTTL():
+		ttl := &CheckTTL{
+			CheckID:       check.CheckID,
+			ServiceID:     check.ServiceID,
+			TTL:           chkType.TTL,
+			Logger:        logger,
+			OutputMaxSize: chkType.OutputMaxSize,
+			Store:         m.ttlStore,
+			StatusHandler: statusHandler,
+		}
+		// The status may be pushed to any server sharing the checks.
+		if m.owner != nil {
+			ttl.PollInterval = TTLPollInterval
+		}
+		return ttl, nil
+	case chkType.IsHTTP():
+		return &CheckHTTP{
+			HTTP:             chkType.HTTP,
+			Header:           chkType.Header,
+			Method:           chkType.Method,
+			Body:             chkType.Body,
+			Interval:         interval,
+			Timeout:          chkType.Timeout,
+			Logger:           logger,
+			TLSClientConfig:  tlsConfig,
+			OutputMaxSize:    chkType.OutputMaxSize,
+			StatusHandler:    statusHandler,
+			DisableRedirects: chkType.DisableRedirects,
+			ProxyHTTP:        chkType.ProxyHTTP,
+			Scheduler:        m.scheduler,
+		}, nil
+	case chkType.IsH2PING():
+		h2ping := &CheckH2PING{
+			ServiceID:     check.ServiceID,
+			H2PING:        chkType.H2PING,
+			Interval:      interval,
+			Timeout:       chkType.Timeout,
+			Logger:        logger,
+			StatusHandler: statusHandler,
+			Scheduler:     m.scheduler,
+		}
+		if chkType.H2PingUseTLS {
+			h2ping.TLSClientConfig = tlsConfig
+		}
+		return h2ping, nil
+	case chkType.IsTCP():
+		tcp := &CheckTCP{
+			ServiceID:     check.ServiceID,
+			TCP:           chkType.TCP,
+			Interval:      interval,
+			Timeout:       chkType.Timeout,
+			Logger:        logger,
+			StatusHandler: statusHandler,
+			Scheduler:     m.scheduler,
+		}
+		if chkType.TCPUseTLS {
+			tcp.TLSClientConfig = tlsConfig
+		}
+		return tcp, nil
+	case chkType.IsUDP():
+		return &CheckUDP{
+			ServiceID:     check.ServiceID,
+			UDP:           chkType.UDP,
+			Interval:      interval,
+			Timeout:       chkType.Timeout,
+			Logger:        logger,
+			StatusHandler: statusHandler,
+			Scheduler:     m.scheduler,
+		}, nil
+	case chkType.IsGRPC():
+		grpc := &CheckGRPC{
+			ServiceID:     check.ServiceID,
+			GRPC:          chkType.GRPC,
+			Interval:      interval,
+			Timeout:       chkType.Timeout,
+			Logger:        logger,
+			OutputMaxSize: chkType.OutputMaxSize,
+			StatusHandler: statusHandler,
+			ProxyGRPC:     chkType.ProxyGRPC,
+			Scheduler:     m.scheduler,
+		}
+		if chkType.GRPCUseTLS {
+			grpc.TLSClientConfig = tlsConfig
+		}
+		return grpc, nil
+	case chkType.IsScript():
+		return &CheckMonitor{
+			ServiceID:     check.ServiceID,
+			ScriptArgs:    chkType.ScriptArgs,
+			Shell:         chkType.Shell,
+			Interval:      interval,
+			Timeout:       chkType.Timeout,
+			Logger:        logger,
+			OutputMaxSize: chkType.OutputMaxSize,
+			StatusHandler: statusHandler,
+			Scheduler:     m.scheduler,
+		}, nil
+	case chkType.IsAlias():
+		return &CheckAlias{
+			CheckID:       check.CheckID,
+			Node:          chkType.AliasNode,
+			ServiceID:     chkType.AliasService,
+			State:         m.state,
+			Logger:        logger,
+			StatusHandler: statusHandler,
+		}, nil
+	default:
+		return nil, fmt.Errorf("check type is not valid")
+	}
+}
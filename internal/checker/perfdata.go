@@ -0,0 +1,128 @@
+package checker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PerfData is a single metric of the Nagios plugin performance data,
+// written as 'label'=value[UOM];[warn];[crit];[min];[max].
+type PerfData struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	UOM   string  `json:"uom,omitempty"`
+	Warn  string  `json:"warn,omitempty"`
+	Crit  string  `json:"crit,omitempty"`
+	Min   string  `json:"min,omitempty"`
+	Max   string  `json:"max,omitempty"`
+}
+
+// ParsePerfData splits the output of a Nagios plugin into the human-readable
+// text and the performance data. The performance data follows the "|" of the
+// first line, and the "|" of the long text, on the next lines, after which every
+// line is performance data. Metrics that can't be parsed, including those with
+// an unknown "U" value, are skipped.
+func ParsePerfData(output string) (string, []PerfData) {
+	first, long, multiline := strings.Cut(output, "\n")
+	text, data, _ := strings.Cut(first, "|")
+	text = strings.TrimRight(text, " ")
+
+	metrics := parseMetrics(data)
+	if multiline {
+		longText, longMetrics := parseLongOutput(long)
+		text += "\n" + longText
+		metrics = append(metrics, longMetrics...)
+	}
+
+	return text, metrics
+}
+
+// parseLongOutput splits the lines following the first line of the output of a
+// Nagios plugin into the long text and the performance data after its "|".
+func parseLongOutput(output string) (string, []PerfData) {
+	text, data, ok := strings.Cut(output, "|")
+	if !ok {
+		return output, nil
+	}
+
+	return strings.TrimRight(text, " \n"), parseMetrics(data)
+}
+
+func parseMetrics(data string) []PerfData {
+	var metrics []PerfData
+	for _, field := range splitPerfData(data) {
+		if metric, ok := parseMetric(field); ok {
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
+
+// splitPerfData splits the performance data on whitespace and newlines,
+// keeping single-quoted labels that contain spaces together.
+func splitPerfData(data string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted := false
+
+	for _, r := range data {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			field.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\n' || r == '\r') && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+func parseMetric(field string) (PerfData, bool) {
+	idx := strings.LastIndex(field, "=")
+	if idx <= 0 {
+		return PerfData{}, false
+	}
+
+	label := field[:idx]
+	if len(label) >= 2 && strings.HasPrefix(label, "'") && strings.HasSuffix(label, "'") {
+		// Two single quotes are used to escape a quote inside the label.
+		label = strings.ReplaceAll(label[1:len(label)-1], "''", "'")
+	}
+
+	parts := strings.Split(field[idx+1:], ";")
+	value := parts[0]
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.-+eE", r)
+	})
+
+	uom := ""
+	if end >= 0 {
+		value, uom = value[:end], value[end:]
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return PerfData{}, false
+	}
+
+	metric := PerfData{Label: label, Value: number, UOM: uom}
+	thresholds := []*string{&metric.Warn, &metric.Crit, &metric.Min, &metric.Max}
+	for i, part := range parts[1:] {
+		if i < len(thresholds) {
+			*thresholds[i] = part
+		}
+	}
+
+	return metric, true
+}
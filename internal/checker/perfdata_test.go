@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePerfData(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		text    string
+		metrics []string
+	}{
+		{
+			name:   "without perfdata",
+			output: "DISK OK",
+			text:   "DISK OK",
+		},
+		{
+			name:    "first line",
+			output:  "DISK OK - free space: / 3326 MB | /=2643MB;5948;5958;0;5968",
+			text:    "DISK OK - free space: / 3326 MB",
+			metrics: []string{"/"},
+		},
+		{
+			name:    "long text without perfdata",
+			output:  "DISK OK | /=2643MB\n/ 15272 MB (77%);\n/boot 68 MB (69%);",
+			text:    "DISK OK\n/ 15272 MB (77%);\n/boot 68 MB (69%);",
+			metrics: []string{"/"},
+		},
+		{
+			name:    "perfdata after the long text",
+			output:  "DISK OK | /=2643MB;5948;5958;0;5968\n/ 15272 MB (77%);\n/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n/home=69357MB;253404;253409;0;253414 \n'/var log'=818MB;970;975;0;980",
+			text:    "DISK OK\n/ 15272 MB (77%);\n/boot 68 MB (69%);",
+			metrics: []string{"/", "/boot", "/home", "/var log"},
+		},
+		{
+			name:    "perfdata only after the long text",
+			output:  "DISK OK\nall mounted|/boot=68MB\n/home=69357MB",
+			text:    "DISK OK\nall mounted",
+			metrics: []string{"/boot", "/home"},
+		},
+		{
+			name:    "unknown value",
+			output:  "OK | a=U b=1",
+			text:    "OK",
+			metrics: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, metrics := ParsePerfData(tt.output)
+			if text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+
+			var labels []string
+			for _, metric := range metrics {
+				labels = append(labels, metric.Label)
+			}
+
+			if !reflect.DeepEqual(labels, tt.metrics) {
+				t.Errorf("metrics = %q, want %q", labels, tt.metrics)
+			}
+		})
+	}
+}
+
+func TestParseMetric(t *testing.T) {
+	metric, ok := parseMetric("'it''s'=2643.5MB;5948;5958;0;5968")
+	want := PerfData{Label: "it's", Value: 2643.5, UOM: "MB", Warn: "5948", Crit: "5958", Min: "0", Max: "5968"}
+	if !ok || metric != want {
+		t.Errorf("parseMetric() = %+v, %v, want %+v", metric, ok, want)
+	}
+}
@@ -0,0 +1,127 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultReapInterval is how often the services with critical checks are looked for.
+const DefaultReapInterval = 30 * time.Second
+
+// ServiceDeregistrar removes the services reaped by the Manager from the catalog.
+type ServiceDeregistrar interface {
+	DeregisterService(ctx context.Context, serviceID, reason string) error
+}
+
+// CriticalHistory tells when a check became critical from its past results, so that
+// how long a check has been critical survives the restarts and the moves of the check
+// to another server. It may be implemented by the ServiceDeregistrar.
+type CriticalHistory interface {
+	CriticalSince(ctx context.Context, checkID string) (time.Time, bool, error)
+}
+
+// RunReaper deregisters the services having a check critical for longer than
+// its DeregisterCriticalServiceAfter, until the context is done. In a cluster,
+// every server reaps the services of the checks it runs.
+func (m *Manager) RunReaper(ctx context.Context, interval time.Duration, catalog ServiceDeregistrar) {
+	if interval <= 0 {
+		interval = DefaultReapInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.ReapServices(ctx, catalog)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReapServices deregisters the services having a check critical for longer than
+// its DeregisterCriticalServiceAfter, and stops all checks of these services.
+func (m *Manager) ReapServices(ctx context.Context, catalog ServiceDeregistrar) {
+	if history, ok := catalog.(CriticalHistory); ok {
+		m.restoreCriticalSince(ctx, history)
+	}
+
+	reasons := make(map[string]string)
+
+	m.lock.Lock()
+	for checkID, critical := range m.state.CriticalChecks() {
+		timeout, ok := m.reapAfter[checkID]
+		if !ok || critical.CriticalFor <= timeout {
+			continue
+		}
+
+		serviceID := critical.Check.ServiceID
+		if _, ok := reasons[serviceID]; !ok {
+			reasons[serviceID] = fmt.Sprintf("check %q has been critical for %s, longer than %s", checkID, critical.CriticalFor.Round(time.Second), timeout)
+		}
+	}
+	m.lock.Unlock()
+
+	for serviceID, reason := range reasons {
+		if err := catalog.DeregisterService(ctx, serviceID, reason); err != nil {
+			m.logger.Errorw("Failed to deregister critical service", "service", serviceID, "error", err)
+			continue
+		}
+
+		removed := m.RemoveService(serviceID)
+		m.logger.Infow("Critical service deregistered", "service", serviceID, "checks", removed, "reason", reason)
+	}
+}
+
+// restoreCriticalSince looks up in the history when the critical checks which may be
+// reaped became critical, the first time they are critical since they were started.
+func (m *Manager) restoreCriticalSince(ctx context.Context, history CriticalHistory) {
+	var checkIDs []string
+
+	m.lock.Lock()
+	for checkID := range m.state.CriticalChecks() {
+		if _, ok := m.reapAfter[checkID]; ok && !m.restored[checkID] {
+			checkIDs = append(checkIDs, checkID)
+		}
+	}
+	m.lock.Unlock()
+
+	for _, checkID := range checkIDs {
+		since, ok, err := history.CriticalSince(ctx, checkID)
+		if err != nil {
+			m.logger.Errorw("Failed to look up since when the check is critical", "check", checkID, "error", err)
+			continue
+		}
+
+		m.lock.Lock()
+		if _, running := m.reapAfter[checkID]; running {
+			m.restored[checkID] = true
+			if ok {
+				m.state.SetCriticalSince(checkID, since)
+			}
+		}
+		m.lock.Unlock()
+	}
+}
+
+// RemoveService stops all checks of the service and forgets about them,
+// it returns the IDs of the removed checks.
+func (m *Manager) RemoveService(serviceID string) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var removed []string
+	for checkID, def := range m.definitions {
+		if def.Check.ServiceID != serviceID {
+			continue
+		}
+
+		m.deregister(checkID)
+		removed = append(removed, checkID)
+	}
+
+	return removed
+}
@@ -0,0 +1,44 @@
+package checker
+
+import "sync"
+
+// TTLChecks holds the running TTL checks, so that their status
+// can be pushed through the API.
+var TTLChecks = NewTTLRegistry()
+
+// TTLRegistry is a concurrency safe index of TTL checks by check ID.
+type TTLRegistry struct {
+	lock   sync.RWMutex
+	checks map[string]*CheckTTL
+}
+
+func NewTTLRegistry() *TTLRegistry {
+	return &TTLRegistry{
+		checks: make(map[string]*CheckTTL),
+	}
+}
+
+// Register adds the check to the registry, replacing any check with the same ID.
+func (r *TTLRegistry) Register(check *CheckTTL) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.checks[check.CheckID] = check
+}
+
+// Deregister removes the check with the given ID from the registry.
+func (r *TTLRegistry) Deregister(checkID string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.checks, checkID)
+}
+
+// Get returns the check with the given ID.
+func (r *TTLRegistry) Get(checkID string) (*CheckTTL, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	check, ok := r.checks[checkID]
+	return check, ok
+}
@@ -0,0 +1,273 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StaleIntervals is how many intervals a remote check waits for the result of
+// a location before it is considered critical there, e.g. because its agents
+// are disconnected.
+const StaleIntervals = 3
+
+// LocationStatus is the status of a remote check in one of its locations.
+type LocationStatus struct {
+	Location  string
+	Status    string
+	Output    string
+	UpdatedAt time.Time
+}
+
+// LocationNotifier is implemented by notifiers which want to know
+// the status of a remote check in each of its locations.
+type LocationNotifier interface {
+	UpdateLocations(locations []*LocationStatus)
+}
+
+// CheckRemote is run by the agents of its locations, which report their
+// results to the server. Nothing is run locally.
+//
+// The thresholds of the check are applied to the results of each location by a
+// StatusHandler of its own, the statuses of the locations are then combined:
+// the check is critical if it is critical in at least Quorum locations, warning
+// if it is failing in at least Quorum locations, and passing otherwise. A location
+// which doesn't report a result within StaleAfter counts as a failure.
+type CheckRemote struct {
+	CheckID       string
+	Type          *CheckType
+	StaleAfter    time.Duration
+	Logger        *zap.SugaredLogger
+	StatusHandler *StatusHandler
+
+	lock      sync.Mutex
+	locations map[string]*remoteLocation
+	started   time.Time
+
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+	stopWg   sync.WaitGroup
+}
+
+// remoteLocation is the state of a remote check in one of its locations.
+type remoteLocation struct {
+	handler  *StatusHandler
+	status   LocationStatus
+	reported time.Time // When the last result was reported, or considered stale.
+	measured time.Time // When the last result was measured by the agent.
+}
+
+func (c *CheckRemote) CheckType() CheckType {
+	return *c.Type
+}
+
+// Start is used to start waiting for the results, runs until Stop()
+func (c *CheckRemote) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	// Failures before warning defaults to failures before critical.
+	failuresBeforeWarning := c.Type.FailuresBeforeWarning
+	if failuresBeforeWarning == 0 {
+		failuresBeforeWarning = c.Type.FailuresBeforeCritical
+	}
+
+	c.lock.Lock()
+	c.started = time.Now()
+	c.locations = make(map[string]*remoteLocation, len(c.Type.Locations))
+	for _, name := range c.Type.Locations {
+		notifier := &locationNotifier{check: c, location: name}
+		handler := NewStatusHandler(notifier, c.Logger.With("location", name), c.Type.SuccessBeforePassing, failuresBeforeWarning, c.Type.FailuresBeforeCritical)
+		c.locations[name] = &remoteLocation{handler: handler, status: LocationStatus{Location: name}}
+	}
+	c.lock.Unlock()
+
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	c.stopWg.Add(1)
+	go c.run()
+}
+
+// Stop is used to stop waiting for the results.
+func (c *CheckRemote) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+
+	// Wait for the c.run() goroutine to complete before returning.
+	c.stopWg.Wait()
+}
+
+// Report updates the check with the result an agent of the location measured
+// at the given time. Results older than the last one of the location, e.g.
+// buffered by an agent while disconnected, are rejected.
+func (c *CheckRemote) Report(location, status, output string, latency time.Duration, at time.Time) error {
+	now := time.Now()
+	if at.IsZero() || at.After(now) {
+		at = now
+	}
+
+	c.lock.Lock()
+	l, ok := c.locations[location]
+	stale := ok && !at.After(l.measured)
+	if ok && !stale {
+		l.measured = at
+		if at.After(l.reported) {
+			l.reported = at
+		}
+	}
+	c.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("check %q is not run from location %q", c.CheckID, location)
+	}
+
+	if stale {
+		return fmt.Errorf("result of check %q measured at %s is older than the last one of location %q", c.CheckID, at, location)
+	}
+
+	c.Logger.Debugw("Check result reported", "location", location, "status", status, "time", at)
+	l.handler.updateResult(status, truncate(output, c.Type.OutputMaxSize), latency, at)
+
+	return nil
+}
+
+// Locations returns the status of the check in each of its locations.
+func (c *CheckRemote) Locations() []*LocationStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.snapshot()
+}
+
+// run is used to mark the locations critical when their results stop coming.
+func (c *CheckRemote) run() {
+	defer c.stopWg.Done()
+
+	ticker := time.NewTicker(max(c.StaleAfter/StaleIntervals, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for name, handler := range c.stale(now) {
+				c.Logger.Warnw("No result reported by the location", "location", name, "stale_after", c.StaleAfter)
+				handler.updateCheck(HealthCritical, fmt.Sprintf("No result reported for %s", c.StaleAfter))
+			}
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// stale returns the handlers of the locations which didn't report a result
+// within StaleAfter, they are considered stale again after another StaleAfter.
+func (c *CheckRemote) stale(now time.Time) map[string]*StatusHandler {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stale := make(map[string]*StatusHandler)
+	for name, l := range c.locations {
+		since := l.reported
+		if since.IsZero() {
+			since = c.started
+		}
+
+		if now.Sub(since) >= c.StaleAfter {
+			l.reported = now
+			stale[name] = l.handler
+		}
+	}
+
+	return stale
+}
+
+// update records the status of the location, reported by its status handler,
+// and reports the combined status of all locations.
+func (c *CheckRemote) update(location, status, output string, latency time.Duration, at time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	l, ok := c.locations[location]
+	if !ok {
+		return
+	}
+
+	l.status.Status = status
+	l.status.Output = output
+	l.status.UpdatedAt = at
+
+	status, output = c.aggregate()
+	c.StatusHandler.updateLocations(c.snapshot())
+	c.StatusHandler.updateResult(status, output, latency, at)
+}
+
+// aggregate combines the statuses of the locations which reported a result.
+func (c *CheckRemote) aggregate() (string, string) {
+	quorum := c.Type.QuorumSize()
+
+	var reported, critical, failing int
+	var outputs []string
+	for _, name := range c.Type.Locations {
+		l := c.locations[name]
+		if l.status.Status == "" {
+			continue
+		}
+
+		reported++
+		switch l.status.Status {
+		case HealthCritical:
+			critical++
+			failing++
+		case HealthWarning:
+			failing++
+		}
+
+		outputs = append(outputs, fmt.Sprintf("%s: %s", name, l.status.Output))
+	}
+
+	status := HealthPassing
+	switch {
+	case critical >= quorum:
+		status = HealthCritical
+	case failing >= quorum:
+		status = HealthWarning
+	}
+
+	summary := fmt.Sprintf("Failing in %d of %d locations (%d critical, quorum %d)", failing, reported, critical, quorum)
+	output := summary + "\n" + strings.Join(outputs, "\n")
+
+	return status, truncate(output, c.Type.OutputMaxSize)
+}
+
+// snapshot returns a copy of the status of the locations.
+func (c *CheckRemote) snapshot() []*LocationStatus {
+	locations := make([]*LocationStatus, 0, len(c.Type.Locations))
+	for _, name := range c.Type.Locations {
+		status := c.locations[name].status
+		locations = append(locations, &status)
+	}
+
+	return locations
+}
+
+// locationNotifier reports the status of a location to its remote check.
+type locationNotifier struct {
+	check    *CheckRemote
+	location string
+}
+
+func (n *locationNotifier) UpdateCheck(status, output string) {
+	n.check.update(n.location, status, output, 0, time.Now())
+}
+
+func (n *locationNotifier) UpdateResult(result *Result) {
+	n.check.update(n.location, result.Status, result.Output, result.Latency, result.Time)
+}
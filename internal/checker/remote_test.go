@@ -0,0 +1,208 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var reportedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// startRemote starts the remote check, as if it started when the first result is reported.
+func startRemote(t *testing.T, chkType *CheckType) (*CheckRemote, *statusRecorder) {
+	t.Helper()
+
+	recorder := &statusRecorder{}
+	logger := zap.NewNop().Sugar()
+	c := &CheckRemote{
+		CheckID:       "web",
+		Type:          chkType,
+		StaleAfter:    time.Minute,
+		Logger:        logger,
+		StatusHandler: NewStatusHandler(recorder, logger, 0, 0, 0),
+	}
+
+	c.Start()
+	t.Cleanup(c.Stop)
+
+	c.lock.Lock()
+	c.started = reportedAt
+	c.lock.Unlock()
+
+	return c, recorder
+}
+
+func TestRemoteQuorum(t *testing.T) {
+	locations := []string{"eu", "us", "asia"}
+
+	tests := []struct {
+		name    string
+		quorum  int
+		results map[string]string
+		status  string
+	}{
+		{
+			name:    "all passing",
+			results: map[string]string{"eu": HealthPassing, "us": HealthPassing, "asia": HealthPassing},
+			status:  HealthPassing,
+		},
+		{
+			name:    "critical below the default quorum",
+			results: map[string]string{"eu": HealthCritical, "us": HealthPassing, "asia": HealthPassing},
+			status:  HealthPassing,
+		},
+		{
+			name:    "critical at the default quorum",
+			results: map[string]string{"eu": HealthCritical, "us": HealthCritical, "asia": HealthPassing},
+			status:  HealthCritical,
+		},
+		{
+			name:    "failing at the quorum",
+			results: map[string]string{"eu": HealthCritical, "us": HealthWarning, "asia": HealthPassing},
+			status:  HealthWarning,
+		},
+		{
+			name:    "only the reported locations count",
+			results: map[string]string{"eu": HealthCritical},
+			status:  HealthPassing,
+		},
+		{
+			name:    "quorum of one",
+			quorum:  1,
+			results: map[string]string{"eu": HealthPassing, "us": HealthWarning, "asia": HealthPassing},
+			status:  HealthWarning,
+		},
+		{
+			name:    "quorum of all",
+			quorum:  3,
+			results: map[string]string{"eu": HealthCritical, "us": HealthCritical, "asia": HealthPassing},
+			status:  HealthPassing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := startRemote(t, &CheckType{TCP: "web:80", Interval: time.Minute, Locations: locations, Quorum: tt.quorum})
+
+			i := 0
+			for _, location := range locations {
+				status, ok := tt.results[location]
+				if !ok {
+					continue
+				}
+
+				i++
+				if err := c.Report(location, status, location+" "+status, 0, reportedAt.Add(time.Duration(i)*time.Second)); err != nil {
+					t.Fatalf("Report(%s) error = %v", location, err)
+				}
+			}
+
+			if status, output := recorder.last(); status != tt.status {
+				t.Errorf("status = %s, want %s, output:\n%s", status, tt.status, output)
+			}
+		})
+	}
+}
+
+func TestRemoteThresholdsPerLocation(t *testing.T) {
+	c, recorder := startRemote(t, &CheckType{TCP: "web:80", Interval: time.Minute, Locations: []string{"eu", "us"}, Quorum: 1, FailuresBeforeCritical: 2})
+
+	_ = c.Report("eu", HealthPassing, "", 0, reportedAt.Add(time.Second))
+	_ = c.Report("us", HealthPassing, "", 0, reportedAt.Add(2*time.Second))
+	_ = c.Report("eu", HealthCritical, "", 0, reportedAt.Add(3*time.Second))
+	_ = c.Report("us", HealthCritical, "", 0, reportedAt.Add(4*time.Second))
+
+	// Each location counts its own failures.
+	if status, _ := recorder.last(); status != HealthPassing {
+		t.Fatalf("status = %s after a single failure per location, want passing", status)
+	}
+
+	_ = c.Report("us", HealthCritical, "", 0, reportedAt.Add(5*time.Second))
+	if status, _ := recorder.last(); status != HealthCritical {
+		t.Errorf("status = %s after two failures in us, want critical", status)
+	}
+}
+
+func TestRemoteRejectsOutOfOrder(t *testing.T) {
+	c, recorder := startRemote(t, &CheckType{TCP: "web:80", Interval: time.Minute, Locations: []string{"eu"}})
+
+	if err := c.Report("eu", HealthPassing, "", 0, reportedAt.Add(10*time.Second)); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	// Results measured before the last one, or at the same time, e.g. buffered
+	// by an agent and forwarded by another server, are rejected.
+	for _, at := range []time.Time{reportedAt.Add(5 * time.Second), reportedAt.Add(10 * time.Second)} {
+		if err := c.Report("eu", HealthCritical, "", 0, at); err == nil {
+			t.Errorf("Report() at %s succeeded, want the result rejected", at)
+		}
+	}
+
+	if status, _ := recorder.last(); status != HealthPassing {
+		t.Errorf("status = %s, want passing", status)
+	}
+
+	if err := c.Report("asia", HealthCritical, "", 0, reportedAt.Add(20*time.Second)); err == nil {
+		t.Error("Report() from a location the check isn't run from succeeded")
+	}
+
+	if err := c.Report("eu", HealthCritical, "", 0, reportedAt.Add(20*time.Second)); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if status, _ := recorder.last(); status != HealthCritical {
+		t.Errorf("status = %s, want critical", status)
+	}
+
+	locations := c.Locations()
+	if len(locations) != 1 || !locations[0].UpdatedAt.Equal(reportedAt.Add(20*time.Second)) {
+		t.Errorf("locations = %+v, want eu updated at the time of its last result", locations[0])
+	}
+}
+
+func TestRemoteStaleLocations(t *testing.T) {
+	c, recorder := startRemote(t, &CheckType{TCP: "web:80", Interval: time.Minute, Locations: []string{"eu", "us"}, Quorum: 1})
+
+	// As run does when its ticker fires.
+	expire := func(now time.Time) []string {
+		var names []string
+		for name, handler := range c.stale(now) {
+			handler.updateCheck(HealthCritical, "No result reported")
+			names = append(names, name)
+		}
+
+		return names
+	}
+
+	_ = c.Report("eu", HealthPassing, "", 0, reportedAt.Add(30*time.Second))
+
+	if names := expire(reportedAt.Add(59 * time.Second)); len(names) != 0 {
+		t.Fatalf("%v stale before StaleAfter", names)
+	}
+
+	// us never reported a result since the check started.
+	if names := expire(reportedAt.Add(time.Minute)); len(names) != 1 || names[0] != "us" {
+		t.Fatalf("stale locations = %v, want [us]", names)
+	}
+
+	if status, _ := recorder.last(); status != HealthCritical {
+		t.Fatalf("status = %s with a stale location, want critical", status)
+	}
+
+	// eu is stale a minute after its last result, us again a minute after it was considered stale.
+	if names := expire(reportedAt.Add(90 * time.Second)); len(names) != 1 || names[0] != "eu" {
+		t.Fatalf("stale locations = %v, want [eu]", names)
+	}
+
+	if names := expire(reportedAt.Add(2 * time.Minute)); len(names) != 1 || names[0] != "us" {
+		t.Fatalf("stale locations = %v, want [us] again", names)
+	}
+
+	// A location reporting again recovers.
+	_ = c.Report("eu", HealthPassing, "", 0, reportedAt.Add(2*time.Minute+time.Second))
+	_ = c.Report("us", HealthPassing, "", 0, reportedAt.Add(2*time.Minute+2*time.Second))
+	if status, _ := recorder.last(); status != HealthPassing {
+		t.Errorf("status = %s once the locations reported again, want passing", status)
+	}
+}
@@ -0,0 +1,388 @@
+package checker
+
+import (
+	"container/heap"
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultWorkers is the number of checks run concurrently by default.
+	DefaultWorkers = 64
+
+	// DefaultHostConcurrency is the number of checks of the same
+	// host run concurrently by default.
+	DefaultHostConcurrency = 4
+)
+
+// Job is a check run periodically by the Scheduler.
+type Job struct {
+	host     string
+	interval time.Duration
+	run      func()
+
+	// due is when the job must run next, index is its position in the heap.
+	due     time.Time
+	index   int
+	running bool
+	stopped bool
+}
+
+// SchedulerStats tells how well the Scheduler keeps up with the checks. The lag
+// is how late the checks run, i.e. how long they waited for a worker or for a
+// slot of their host once due.
+type SchedulerStats struct {
+	Workers         int           `json:"workers"`
+	HostConcurrency int           `json:"host_concurrency"`
+	Scheduled       int           `json:"scheduled"`
+	Queued          int           `json:"queued"`
+	Running         int           `json:"running"`
+	Runs            uint64        `json:"runs"`
+	LastLag         time.Duration `json:"last_lag"`
+	AverageLag      time.Duration `json:"average_lag"`
+	MaxLag          time.Duration `json:"max_lag"`
+}
+
+// Scheduler runs the interval checks on a bounded pool of workers, instead of one
+// goroutine per check. The checks wait in a min-heap until they are due, and at most
+// HostConcurrency checks of the same host run at once so that a backend with many
+// checks isn't hammered. A check runs again an interval after its run completes.
+type Scheduler struct {
+	// Workers is the number of checks run concurrently, DefaultWorkers if not positive.
+	Workers int
+
+	// HostConcurrency is the number of checks of the same host run concurrently,
+	// DefaultHostConcurrency if zero and unlimited if negative.
+	HostConcurrency int
+
+	logger *zap.SugaredLogger
+
+	lock  sync.Mutex
+	work  *sync.Cond // signalled when a job is ready
+	done  *sync.Cond // broadcast when a job completed
+	wake  chan struct{}
+	timer jobHeap
+
+	// ready are the jobs due which may run, pending are the jobs due waiting for
+	// a slot of their host. hosts counts the ready and running jobs of each host.
+	ready   []*Job
+	pending map[string][]*Job
+	hosts   map[string]int
+	closed  bool
+
+	workers    int
+	hostLimit  int
+	running    int
+	runs       uint64
+	lastLag    time.Duration
+	averageLag time.Duration
+	maxLag     time.Duration
+	warnedAt   time.Time
+}
+
+func NewScheduler(logger *zap.SugaredLogger) *Scheduler {
+	s := &Scheduler{
+		logger:  logger,
+		wake:    make(chan struct{}, 1),
+		pending: make(map[string][]*Job),
+		hosts:   make(map[string]int),
+	}
+
+	s.work = sync.NewCond(&s.lock)
+	s.done = sync.NewCond(&s.lock)
+
+	return s
+}
+
+// Run runs the due checks on the workers until the context is done. The checks
+// scheduled before are run once it's called.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.lock.Lock()
+	s.workers = s.Workers
+	if s.workers <= 0 {
+		s.workers = DefaultWorkers
+	}
+
+	s.hostLimit = s.HostConcurrency
+	if s.hostLimit == 0 {
+		s.hostLimit = DefaultHostConcurrency
+	}
+	s.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker()
+		}()
+	}
+
+	s.dispatch(ctx)
+
+	s.lock.Lock()
+	s.closed = true
+	s.work.Broadcast()
+	s.lock.Unlock()
+
+	wg.Wait()
+}
+
+// Schedule runs the function every interval, the first run is staggered
+// over the interval to spread the load.
+func (s *Scheduler) Schedule(interval time.Duration, host string, run func()) *Job {
+	job := &Job{
+		host:     host,
+		interval: interval,
+		run:      run,
+		due:      time.Now().Add(RandomStagger(interval)),
+	}
+
+	s.lock.Lock()
+	heap.Push(&s.timer, job)
+	s.lock.Unlock()
+
+	s.signal()
+
+	return job
+}
+
+// Stop stops running the job, it waits for the current run to complete
+// so that no result is reported once it returns.
+func (s *Scheduler) Stop(job *Job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	job.stopped = true
+	if job.index >= 0 && job.index < len(s.timer) && s.timer[job.index] == job {
+		heap.Remove(&s.timer, job.index)
+	}
+
+	for job.running {
+		s.done.Wait()
+	}
+}
+
+// Stats returns the current load and lag of the Scheduler.
+func (s *Scheduler) Stats() *SchedulerStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	queued := len(s.ready)
+	for _, jobs := range s.pending {
+		queued += len(jobs)
+	}
+
+	return &SchedulerStats{
+		Workers:         s.workers,
+		HostConcurrency: s.hostLimit,
+		Scheduled:       len(s.timer),
+		Queued:          queued,
+		Running:         s.running,
+		Runs:            s.runs,
+		LastLag:         s.lastLag,
+		AverageLag:      s.averageLag,
+		MaxLag:          s.maxLag,
+	}
+}
+
+// dispatch queues the jobs as soon as they are due, until the context is done.
+func (s *Scheduler) dispatch(ctx context.Context) {
+	for {
+		s.lock.Lock()
+		now := time.Now()
+		for len(s.timer) > 0 && !s.timer[0].due.After(now) {
+			s.enqueue(heap.Pop(&s.timer).(*Job))
+		}
+
+		wait := time.Duration(-1)
+		if len(s.timer) > 0 {
+			wait = s.timer[0].due.Sub(now)
+		}
+		s.lock.Unlock()
+
+		if !s.wait(ctx, wait) {
+			return
+		}
+	}
+}
+
+// wait waits for the duration, or forever if it's negative, unless the dispatcher is
+// woken up. It reports false once the context is done.
+func (s *Scheduler) wait(ctx context.Context, d time.Duration) bool {
+	var next <-chan time.Time
+	if d >= 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		next = timer.C
+	}
+
+	select {
+	case <-next:
+	case <-s.wake:
+	case <-ctx.Done():
+		return false
+	}
+
+	return true
+}
+
+// enqueue makes the job ready, unless its host already runs as many checks as allowed.
+func (s *Scheduler) enqueue(job *Job) {
+	if job.host != "" && s.hostLimit > 0 && s.hosts[job.host] >= s.hostLimit {
+		s.pending[job.host] = append(s.pending[job.host], job)
+		return
+	}
+
+	if job.host != "" {
+		s.hosts[job.host]++
+	}
+
+	s.ready = append(s.ready, job)
+	s.work.Signal()
+}
+
+// release frees the slot of the job's host, for the next job of the host waiting for it.
+func (s *Scheduler) release(job *Job) {
+	if job.host == "" {
+		return
+	}
+
+	for jobs := s.pending[job.host]; len(jobs) > 0; jobs = s.pending[job.host] {
+		next := jobs[0]
+		if len(jobs) == 1 {
+			delete(s.pending, job.host)
+		} else {
+			s.pending[job.host] = jobs[1:]
+		}
+
+		if !next.stopped {
+			// The slot goes to the next job as is.
+			s.ready = append(s.ready, next)
+			s.work.Signal()
+			return
+		}
+	}
+
+	if s.hosts[job.host]--; s.hosts[job.host] <= 0 {
+		delete(s.hosts, job.host)
+	}
+}
+
+func (s *Scheduler) worker() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		for len(s.ready) == 0 && !s.closed {
+			s.work.Wait()
+		}
+
+		if s.closed {
+			return
+		}
+
+		job := s.ready[0]
+		s.ready[0] = nil
+		s.ready = s.ready[1:]
+
+		if job.stopped {
+			s.release(job)
+			continue
+		}
+
+		job.running = true
+		s.running++
+		s.record(job, time.Since(job.due))
+		s.lock.Unlock()
+
+		job.run()
+
+		s.lock.Lock()
+		job.running = false
+		s.running--
+		s.release(job)
+		s.done.Broadcast()
+
+		if !job.stopped {
+			job.due = time.Now().Add(job.interval)
+			heap.Push(&s.timer, job)
+			s.signal()
+		}
+	}
+}
+
+// record updates the lag statistics, and warns when the checks run
+// later than their interval, i.e. when there are too few workers.
+func (s *Scheduler) record(job *Job, lag time.Duration) {
+	s.runs++
+	s.lastLag = lag
+	s.averageLag += (lag - s.averageLag) / 16
+	s.maxLag = max(s.maxLag, lag)
+
+	if lag > job.interval && time.Since(s.warnedAt) > time.Minute {
+		s.warnedAt = time.Now()
+		s.logger.Warnw("Checks run late, consider adding workers", "lag", lag, "workers", s.workers, "queued", len(s.ready))
+	}
+}
+
+// signal wakes up the dispatcher, so that it waits for the earliest job.
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// jobHeap orders the jobs by due time.
+type jobHeap []*Job
+
+func (h jobHeap) Len() int {
+	return len(h)
+}
+
+func (h jobHeap) Less(i, j int) bool {
+	return h[i].due.Before(h[j].due)
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x any) {
+	job := x.(*Job)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	job.index = -1
+	*h = old[:n-1]
+
+	return job
+}
+
+// targetHost returns the host of a URL or of a host:port address,
+// which the concurrency of the checks is limited by.
+func targetHost(target string) string {
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return host
+	}
+
+	return target
+}
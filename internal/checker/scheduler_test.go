@@ -0,0 +1,224 @@
+package checker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// concurrency tracks how many runs of the jobs of each host are in progress,
+// the runs block until released.
+type concurrency struct {
+	lock    sync.Mutex
+	current map[string]int
+	max     map[string]int
+	runs    map[string]int
+	release chan struct{}
+}
+
+func newConcurrency() *concurrency {
+	return &concurrency{
+		current: make(map[string]int),
+		max:     make(map[string]int),
+		runs:    make(map[string]int),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *concurrency) job(host string) func() {
+	return func() {
+		c.lock.Lock()
+		c.current[host]++
+		c.runs[host]++
+		c.max[host] = max(c.max[host], c.current[host])
+		c.lock.Unlock()
+
+		<-c.release
+
+		c.lock.Lock()
+		c.current[host]--
+		c.lock.Unlock()
+	}
+}
+
+func (c *concurrency) stats(host string) (maximum, runs int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.max[host], c.runs[host]
+}
+
+// startScheduler runs the scheduler until the test ends, the jobs are released first.
+func startScheduler(t *testing.T, s *Scheduler, c *concurrency) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		select {
+		case <-c.release:
+		default:
+			close(c.release)
+		}
+		cancel()
+		<-done
+	})
+}
+
+func eventually(t *testing.T, condition func() bool) bool {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSchedulerHostConcurrency(t *testing.T) {
+	s := NewScheduler(zap.NewNop().Sugar())
+	s.Workers = 16
+	s.HostConcurrency = 2
+
+	c := newConcurrency()
+	for i := 0; i < 6; i++ {
+		s.Schedule(10*time.Millisecond, "db", c.job("db"))
+	}
+	for i := 0; i < 3; i++ {
+		s.Schedule(10*time.Millisecond, "web", c.job("web"))
+	}
+	for i := 0; i < 3; i++ {
+		s.Schedule(10*time.Millisecond, "", c.job(""))
+	}
+
+	startScheduler(t, s, c)
+
+	// Two runs per host, and the jobs without host, run while the others wait.
+	saturated := eventually(t, func() bool {
+		stats := s.Stats()
+		return stats.Running == 7 && stats.Queued == 5
+	})
+	if !saturated {
+		t.Fatalf("stats = %+v, want 7 running and 5 queued", s.Stats())
+	}
+
+	// Let the jobs run a while, each run releasing the slot to the next job of its host.
+	for i := 0; i < 50; i++ {
+		c.release <- struct{}{}
+	}
+
+	for host, limit := range map[string]int{"db": 2, "web": 2, "": 3} {
+		if maximum, runs := c.stats(host); maximum != limit {
+			t.Errorf("at most %d runs of %q at once over %d runs, want %d", maximum, host, runs, limit)
+		}
+	}
+
+	if _, runs := c.stats("db"); runs < 6 {
+		t.Errorf("%d runs of db, want every job of the host to run", runs)
+	}
+}
+
+func TestSchedulerWorkers(t *testing.T) {
+	s := NewScheduler(zap.NewNop().Sugar())
+	s.Workers = 3
+	s.HostConcurrency = -1
+
+	c := newConcurrency()
+	for i := 0; i < 10; i++ {
+		s.Schedule(10*time.Millisecond, "db", c.job("db"))
+	}
+
+	startScheduler(t, s, c)
+
+	saturated := eventually(t, func() bool {
+		stats := s.Stats()
+		return stats.Running == 3 && stats.Queued == 7
+	})
+	if !saturated {
+		t.Fatalf("stats = %+v, want the 3 workers running and 7 jobs queued", s.Stats())
+	}
+
+	for i := 0; i < 30; i++ {
+		c.release <- struct{}{}
+	}
+
+	if maximum, runs := c.stats("db"); maximum != 3 {
+		t.Errorf("at most %d runs at once over %d runs, want the 3 workers", maximum, runs)
+	}
+
+	if stats := s.Stats(); stats.Workers != 3 || stats.HostConcurrency != -1 || stats.Scheduled+stats.Queued+stats.Running != 10 {
+		t.Errorf("stats = %+v, want the 10 jobs on 3 workers without host limit", stats)
+	}
+}
+
+func TestSchedulerLag(t *testing.T) {
+	s := NewScheduler(zap.NewNop().Sugar())
+	s.Workers = 1
+
+	c := newConcurrency()
+	s.Schedule(0, "", c.job("first"))
+	startScheduler(t, s, c)
+
+	if !eventually(t, func() bool { return s.Stats().Running == 1 }) {
+		t.Fatalf("stats = %+v, want the job running", s.Stats())
+	}
+
+	// The second job is due at once but waits for the single worker.
+	s.Schedule(0, "", c.job("second"))
+	if !eventually(t, func() bool { return s.Stats().Queued == 1 }) {
+		t.Fatalf("stats = %+v, want the second job queued", s.Stats())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	c.release <- struct{}{}
+
+	if !eventually(t, func() bool { return s.Stats().Runs >= 2 }) {
+		t.Fatalf("stats = %+v, want the second job run", s.Stats())
+	}
+
+	stats := s.Stats()
+	if stats.MaxLag < 100*time.Millisecond || stats.LastLag < 100*time.Millisecond || stats.AverageLag <= 0 || stats.AverageLag > stats.MaxLag {
+		t.Errorf("stats = %+v, want a lag of 100ms or more for the second run", stats)
+	}
+}
+
+func TestSchedulerStopWaitsForRun(t *testing.T) {
+	s := NewScheduler(zap.NewNop().Sugar())
+
+	c := newConcurrency()
+	job := s.Schedule(0, "", c.job(""))
+	startScheduler(t, s, c)
+
+	if !eventually(t, func() bool { return s.Stats().Running == 1 }) {
+		t.Fatalf("stats = %+v, want the job running", s.Stats())
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop(job)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop() returned while the job was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.release <- struct{}{}
+	<-stopped
+
+	if stats := s.Stats(); stats.Scheduled != 0 || stats.Running != 0 {
+		t.Errorf("stats = %+v, want the job stopped", stats)
+	}
+}
@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// DefaultShareInterval is how often the servers sharing the checks exchange their status.
+const DefaultShareInterval = 5 * time.Second
+
+// StatusStore holds the status of the checks shared by several servers, e.g. in
+// a cluster, so that each server reports the status of all checks in its State,
+// not only of the checks it runs.
+type StatusStore interface {
+	// SaveStatuses stores the status of the checks run by the server.
+	SaveStatuses(ctx context.Context, checks []*HealthCheck) error
+
+	// Statuses returns the stored status of the checks with the given IDs.
+	Statuses(ctx context.Context, checkIDs []string) ([]*HealthCheck, error)
+}
+
+// ShareStatus exchanges the status of the checks with the other servers
+// every interval, until the context is done.
+func (m *Manager) ShareStatus(ctx context.Context, store StatusStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultShareInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.ExchangeStatus(ctx, store); err != nil {
+				m.logger.Errorw("Failed to share the status of the checks", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ExchangeStatus saves the status of the checks run by the server which changed
+// since they were last saved, and reports the status of the checks run by the
+// other servers in the State.
+func (m *Manager) ExchangeStatus(ctx context.Context, store StatusStore) error {
+	var changed []*HealthCheck
+	var others []string
+
+	m.lock.Lock()
+	for checkID := range m.definitions {
+		if _, running := m.checks[checkID]; !running {
+			others = append(others, checkID)
+			continue
+		}
+
+		check, ok := m.state.Check(checkID)
+		if !ok {
+			continue
+		}
+
+		if index, ok := m.shared[checkID]; !ok || index != check.ModifyIndex {
+			changed = append(changed, check)
+		}
+	}
+	m.lock.Unlock()
+
+	if len(changed) > 0 {
+		if err := store.SaveStatuses(ctx, changed); err != nil {
+			return err
+		}
+
+		m.lock.Lock()
+		for _, check := range changed {
+			if _, running := m.checks[check.CheckID]; running {
+				m.shared[check.CheckID] = check.ModifyIndex
+			}
+		}
+		m.lock.Unlock()
+	}
+
+	if len(others) == 0 {
+		return nil
+	}
+
+	statuses, err := store.Statuses(ctx, others)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, status := range statuses {
+		def, ok := m.definitions[status.CheckID]
+		if _, running := m.checks[status.CheckID]; ok && !running {
+			m.mirror(def.Check, status)
+		}
+	}
+
+	return nil
+}
+
+// mirror reports in the State the check run by another server, with the given
+// status. The check keeps its current status if status is nil, a new check is
+// critical until its status is known. The changes of the status shared by the
+// owner are published to the SharedEvents.
+func (m *Manager) mirror(check *HealthCheck, status *HealthCheck) {
+	mirrored := *check
+	existing, ok := m.state.Check(check.CheckID)
+
+	switch {
+	case status != nil:
+		mirrored.Status = status.Status
+		mirrored.Output = status.Output
+		mirrored.Flapping = status.Flapping
+		mirrored.Locations = status.Locations
+	case ok:
+		mirrored.Status = existing.Status
+		mirrored.Output = existing.Output
+		mirrored.Flapping = existing.Flapping
+		mirrored.Locations = existing.Locations
+	case mirrored.Status == "":
+		mirrored.Status = HealthCritical
+	}
+
+	if ok {
+		mirrored.ModifyIndex = existing.ModifyIndex
+		if reflect.DeepEqual(existing, &mirrored) {
+			return
+		}
+		mirrored.ModifyIndex++
+
+		if status != nil && (existing.Status != mirrored.Status || existing.Flapping != mirrored.Flapping) {
+			m.shares.Publish(&Transition{
+				CheckID:  check.CheckID,
+				Previous: existing.Status,
+				Status:   mirrored.Status,
+				Output:   mirrored.Output,
+				Flapping: mirrored.Flapping,
+				Time:     time.Now(),
+			})
+		}
+	}
+
+	m.state.AddCheck(&mirrored)
+}
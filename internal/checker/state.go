@@ -0,0 +1,229 @@
+package checker
+
+import (
+	"sync"
+	"time"
+)
+
+// State holds the latest status of every check known to Orbit,
+// and notifies the watchers whenever one of them changes.
+type State struct {
+	lock     sync.RWMutex
+	checks   map[string]*HealthCheck
+	watchers map[chan<- struct{}]struct{}
+
+	// criticalSince is when each critical check became critical.
+	criticalSince map[string]time.Time
+}
+
+// CriticalCheck is a check in the critical state, and for how long.
+type CriticalCheck struct {
+	Check       *HealthCheck
+	CriticalFor time.Duration
+}
+
+func NewState() *State {
+	return &State{
+		checks:        make(map[string]*HealthCheck),
+		watchers:      make(map[chan<- struct{}]struct{}),
+		criticalSince: make(map[string]time.Time),
+	}
+}
+
+// AddCheck adds the check to the state, replacing any check with the same ID.
+func (s *State) AddCheck(check *HealthCheck) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c := *check
+	s.checks[check.CheckID] = &c
+	s.updateCriticalSince(check.CheckID, check.Status)
+	s.notifyChange()
+}
+
+// RemoveCheck removes the check with the given ID from the state.
+func (s *State) RemoveCheck(checkID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.checks[checkID]; !ok {
+		return
+	}
+
+	delete(s.checks, checkID)
+	delete(s.criticalSince, checkID)
+	s.notifyChange()
+}
+
+// UpdateCheck is used to update the status of a check.
+// Watchers are only notified if the status or output changed.
+func (s *State) UpdateCheck(checkID, status, output string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	check, ok := s.checks[checkID]
+	if !ok || (check.Status == status && check.Output == output) {
+		return
+	}
+
+	// Replace the check, so copies handed out earlier are not mutated.
+	c := *check
+	c.Status = status
+	c.Output = output
+	c.ModifyIndex++
+	s.checks[checkID] = &c
+	s.updateCriticalSince(checkID, status)
+	s.notifyChange()
+}
+
+// UpdateFlapping is used to flag a check as flapping.
+func (s *State) UpdateFlapping(checkID string, flapping bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	check, ok := s.checks[checkID]
+	if !ok || check.Flapping == flapping {
+		return
+	}
+
+	c := *check
+	c.Flapping = flapping
+	c.ModifyIndex++
+	s.checks[checkID] = &c
+	s.notifyChange()
+}
+
+// UpdateLocations is used to update the status of a remote check in each of its locations.
+func (s *State) UpdateLocations(checkID string, locations []*LocationStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	check, ok := s.checks[checkID]
+	if !ok {
+		return
+	}
+
+	c := *check
+	c.Locations = locations
+	c.ModifyIndex++
+	s.checks[checkID] = &c
+	s.notifyChange()
+}
+
+// Check returns a copy of the check with the given ID.
+func (s *State) Check(checkID string) (*HealthCheck, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	check, ok := s.checks[checkID]
+	if !ok {
+		return nil, false
+	}
+
+	c := *check
+	return &c, true
+}
+
+// Checks returns a copy of all checks.
+func (s *State) Checks() []*HealthCheck {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	checks := make([]*HealthCheck, 0, len(s.checks))
+	for _, check := range s.checks {
+		c := *check
+		checks = append(checks, &c)
+	}
+
+	return checks
+}
+
+// CriticalChecks returns the checks in the critical state, keyed by check ID.
+func (s *State) CriticalChecks() map[string]*CriticalCheck {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := time.Now()
+	checks := make(map[string]*CriticalCheck, len(s.criticalSince))
+	for checkID, since := range s.criticalSince {
+		c := *s.checks[checkID]
+		checks[checkID] = &CriticalCheck{
+			Check:       &c,
+			CriticalFor: now.Sub(since),
+		}
+	}
+
+	return checks
+}
+
+// SetCriticalSince sets when the check became critical if it is critical,
+// unless it is known to be critical for longer already.
+func (s *State) SetCriticalSince(checkID string, since time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if current, ok := s.criticalSince[checkID]; ok && since.Before(current) {
+		s.criticalSince[checkID] = since
+	}
+}
+
+// Notify registers a channel which is signalled whenever a check changes.
+// The channel should be buffered, signals are dropped if it is full.
+func (s *State) Notify(ch chan<- struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.watchers[ch] = struct{}{}
+}
+
+// StopNotify removes a channel registered with Notify.
+func (s *State) StopNotify(ch chan<- struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.watchers, ch)
+}
+
+// Notifier returns a CheckNotifier which updates the check with the given ID.
+func (s *State) Notifier(checkID string) CheckNotifier {
+	return &stateNotifier{state: s, checkID: checkID}
+}
+
+// updateCriticalSince keeps track of when the check became critical,
+// a check which stays critical keeps its original time.
+func (s *State) updateCriticalSince(checkID, status string) {
+	if status != HealthCritical {
+		delete(s.criticalSince, checkID)
+		return
+	}
+
+	if _, ok := s.criticalSince[checkID]; !ok {
+		s.criticalSince[checkID] = time.Now()
+	}
+}
+
+func (s *State) notifyChange() {
+	for ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type stateNotifier struct {
+	state   *State
+	checkID string
+}
+
+func (n *stateNotifier) UpdateCheck(status, output string) {
+	n.state.UpdateCheck(n.checkID, status, output)
+}
+
+func (n *stateNotifier) UpdateFlapping(flapping bool) {
+	n.state.UpdateFlapping(n.checkID, flapping)
+}
+
+func (n *stateNotifier) UpdateLocations(locations []*LocationStatus) {
+	n.state.UpdateLocations(n.checkID, locations)
+}
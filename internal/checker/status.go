@@ -1,8 +1,17 @@
 package checker
 
-import "go.uber.org/zap"
+import (
+	"sync"
+	"time"
 
+	"go.uber.org/zap"
+)
+
+// StatusHandler applies the thresholds to the results of a check before
+// reporting them, and emits a Transition whenever the reported status changes.
+// It is safe for concurrent use.
 type StatusHandler struct {
+	lock                   sync.Mutex
 	inner                  CheckNotifier
 	logger                 *zap.SugaredLogger
 	successBeforePassing   int
@@ -10,6 +19,26 @@ type StatusHandler struct {
 	failuresBeforeWarning  int
 	failuresBeforeCritical int
 	failuresCounter        int
+
+	// started is the time the current check run began, see measure, and perfData
+	// the performance data of the result being reported, see updatePerfData.
+	started  time.Time
+	perfData []PerfData
+
+	// flap detects the check oscillating between statuses, nil if disabled.
+	flap *flapDetector
+
+	// The status last reported and since when, used to emit transitions.
+	checkID string
+	events  *Events
+	status  string
+	since   time.Time
+
+	// maintenance is the reason the check is under maintenance, empty otherwise.
+	// The last status is kept to be reported as soon as the maintenance ends.
+	maintenance string
+	lastStatus  string
+	lastOutput  string
 }
 
 // NewStatusHandler set counters values to threshold in order to immediately update status after first check.
@@ -25,14 +54,109 @@ func NewStatusHandler(inner CheckNotifier, logger *zap.SugaredLogger, successBef
 	}
 }
 
+// EnableFlapDetection turns on the flap detection over the given number of
+// recent results. The thresholds are percentages of state changes.
+func (s *StatusHandler) EnableFlapDetection(window int, lowThreshold, highThreshold float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if highThreshold <= 0 {
+		return
+	}
+
+	s.flap = newFlapDetector(window, lowThreshold, highThreshold)
+}
+
+// EmitTransitions publishes a Transition to events whenever the reported
+// status of the check changes from its current status.
+func (s *StatusHandler) EmitTransitions(checkID, status string, events *Events) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkID = checkID
+	s.events = events
+	s.status = status
+	s.since = time.Now()
+}
+
+// SetMaintenance puts the check under maintenance for the given reason, it reports
+// the maintenance status until called with an empty reason. The status the results
+// led to, once the thresholds are applied, is then reported again.
+func (s *StatusHandler) SetMaintenance(reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if reason == s.maintenance {
+		return
+	}
+
+	s.maintenance = reason
+	if reason != "" {
+		s.logger.Infow("Check is under maintenance", "reason", reason)
+		s.record(HealthMaint, reason, time.Now())
+		s.notify(HealthMaint, reason, time.Now())
+		return
+	}
+
+	s.logger.Infow("Check maintenance ended")
+	if s.lastStatus != "" {
+		s.record(s.lastStatus, s.lastOutput, time.Now())
+		s.notify(s.lastStatus, s.lastOutput, time.Now())
+	}
+}
+
+// Maintenance returns the reason the check is under maintenance, empty if it is not.
+func (s *StatusHandler) Maintenance() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.maintenance
+}
+
 func (s *StatusHandler) updateCheck(status, output string) {
+	s.update(status, output, time.Now())
+}
+
+// update applies the result produced at the given time.
+func (s *StatusHandler) update(status, output string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// The thresholds are still applied under maintenance, the status they
+	// lead to is reported as soon as the maintenance ends.
+	if s.maintenance != "" {
+		s.record(HealthMaint, output, at)
+		if reported, ok := s.evaluate(status); ok {
+			s.lastStatus, s.lastOutput = reported, output
+		}
+		s.notify(HealthMaint, s.maintenance, at)
+		return
+	}
+
+	s.record(status, output, at)
+	settled := s.detectFlapping(status)
+
+	previous := s.status
+	if reported, ok := s.evaluate(status); ok {
+		s.notify(reported, output, at)
+	}
+
+	// The status didn't change as the check stopped flapping,
+	// it's emitted again since it was ignored while flapping.
+	if settled && s.status == previous {
+		s.settle()
+	}
+}
+
+// evaluate counts the result against the thresholds, it returns the status
+// to report and whether it must be reported.
+func (s *StatusHandler) evaluate(status string) (string, bool) {
 	if status == HealthPassing || status == HealthWarning {
 		s.successCounter++
 		s.failuresCounter = 0
 		if s.successCounter >= s.successBeforePassing {
 			s.logger.Debug("Check status updated", "status", status)
-			s.inner.UpdateCheck(status, output)
-			return
+			return status, true
 		}
 		s.logger.Warn("Check passed but has not reached success threshold",
 			"status", status,
@@ -44,14 +168,12 @@ func (s *StatusHandler) updateCheck(status, output string) {
 		s.successCounter = 0
 		if s.failuresCounter >= s.failuresBeforeCritical {
 			s.logger.Warn("Check is now critical", "check")
-			s.inner.UpdateCheck(status, output)
-			return
+			return status, true
 		}
 		// Defaults to same value as failuresBeforeCritical if not set.
 		if s.failuresCounter >= s.failuresBeforeWarning {
 			s.logger.Warn("Check is now warning", "check")
-			s.inner.UpdateCheck(HealthWarning, output)
-			return
+			return HealthWarning, true
 		}
 		s.logger.Warn("Check failed but has not reached warning/failure threshold",
 			"status", status,
@@ -60,4 +182,162 @@ func (s *StatusHandler) updateCheck(status, output string) {
 			"failure_threshold", s.failuresBeforeCritical,
 		)
 	}
+
+	return "", false
+}
+
+// measure runs the check and keeps track of when it started,
+// so that the latency can be reported along with the result.
+func (s *StatusHandler) measure(check func()) {
+	s.lock.Lock()
+	s.started = time.Now()
+	s.lock.Unlock()
+
+	check()
+
+	s.lock.Lock()
+	s.started = time.Time{}
+	s.lock.Unlock()
+}
+
+// updateResult updates the check with a result measured elsewhere, e.g. by a
+// remote agent, so that its latency and time are reported along with it.
+func (s *StatusHandler) updateResult(status, output string, latency time.Duration, at time.Time) {
+	s.lock.Lock()
+	s.started = at.Add(-latency)
+	s.lock.Unlock()
+
+	s.update(status, output, at)
+
+	s.lock.Lock()
+	s.started = time.Time{}
+	s.lock.Unlock()
+}
+
+// updatePerfData updates the check with the result of a Nagios plugin, so that
+// its performance data is reported along with it.
+func (s *StatusHandler) updatePerfData(status, output string, perfData []PerfData) {
+	s.lock.Lock()
+	s.perfData = perfData
+	s.lock.Unlock()
+
+	s.update(status, output, time.Now())
+
+	s.lock.Lock()
+	s.perfData = nil
+	s.lock.Unlock()
+}
+
+// record passes the result to the inner notifier before the thresholds are
+// applied, if it's interested in every result.
+func (s *StatusHandler) record(status, output string, at time.Time) {
+	rr, ok := s.inner.(ResultRecorder)
+	if !ok {
+		return
+	}
+
+	result := &Result{Status: status, Output: output, Time: at, PerfData: s.perfData}
+	if !s.started.IsZero() {
+		result.Latency = at.Sub(s.started)
+	}
+
+	rr.RecordResult(result)
+}
+
+// updateLocations forwards the status of the locations of a remote
+// check to the inner notifier, if it's interested in it.
+func (s *StatusHandler) updateLocations(locations []*LocationStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if ln, ok := s.inner.(LocationNotifier); ok {
+		ln.UpdateLocations(locations)
+	}
+}
+
+// notify forwards the status to the inner notifier, along with the
+// latency and the time of the result if it's interested in them.
+func (s *StatusHandler) notify(status, output string, at time.Time) {
+	if status != HealthMaint {
+		s.lastStatus, s.lastOutput = status, output
+	}
+
+	if rn, ok := s.inner.(ResultNotifier); ok && !s.started.IsZero() {
+		rn.UpdateResult(&Result{Status: status, Output: output, Latency: at.Sub(s.started), Time: at, PerfData: s.perfData})
+	} else {
+		s.inner.UpdateCheck(status, output)
+	}
+
+	s.transition(status, output)
+}
+
+// transition emits a Transition if the status differs from the last reported one.
+func (s *StatusHandler) transition(status, output string) {
+	if s.events == nil || status == s.status {
+		return
+	}
+
+	now := time.Now()
+	t := &Transition{
+		CheckID:      s.checkID,
+		Previous:     s.status,
+		Status:       status,
+		Output:       output,
+		Flapping:     s.flap != nil && s.flap.flapping,
+		Time:         now,
+		SuccessCount: s.successCounter,
+		FailureCount: s.failuresCounter,
+	}
+	if !s.since.IsZero() {
+		t.PreviousDuration = now.Sub(s.since)
+	}
+
+	s.status = status
+	s.since = now
+	s.events.Publish(t)
+}
+
+// settle emits a Transition of the current status once the check stopped flapping.
+func (s *StatusHandler) settle() {
+	if s.events == nil || s.status == "" {
+		return
+	}
+
+	now := time.Now()
+	t := &Transition{
+		CheckID:      s.checkID,
+		Previous:     s.status,
+		Status:       s.status,
+		Output:       s.lastOutput,
+		Settled:      true,
+		Time:         now,
+		SuccessCount: s.successCounter,
+		FailureCount: s.failuresCounter,
+	}
+	if !s.since.IsZero() {
+		t.PreviousDuration = now.Sub(s.since)
+	}
+
+	s.events.Publish(t)
+}
+
+// detectFlapping records the status of the result, and tells the inner
+// notifier when the check starts or stops flapping. It reports whether
+// the check just stopped flapping.
+func (s *StatusHandler) detectFlapping(status string) bool {
+	if s.flap == nil || !s.flap.record(status) {
+		return false
+	}
+
+	if s.flap.flapping {
+		s.logger.Warnw("Check started flapping", "state_change", s.flap.percentStateChange())
+	} else {
+		s.logger.Infow("Check stopped flapping", "state_change", s.flap.percentStateChange())
+	}
+
+	if fn, ok := s.inner.(FlapNotifier); ok {
+		fn.UpdateFlapping(s.flap.flapping)
+	}
+
+	return !s.flap.flapping
 }
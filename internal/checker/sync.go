@@ -0,0 +1,174 @@
+package checker
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// DefaultSyncInterval is how often the definitions are reloaded when
+// the store can't stream their changes.
+const DefaultSyncInterval = 30 * time.Second
+
+// DefinitionChange is a check added, updated or deleted in the store.
+type DefinitionChange struct {
+	CheckID string
+
+	// Definition is the new definition of the check, nil if it was deleted.
+	Definition *Definition
+}
+
+// DefinitionStream is a stream of the changes of the definitions.
+type DefinitionStream interface {
+	// Next blocks until the next change, it fails if the stream is broken.
+	Next(ctx context.Context) (*DefinitionChange, error)
+	Close(ctx context.Context) error
+}
+
+// DefinitionWatcher is implemented by the stores able to stream the changes
+// of the definitions, e.g. made through the API of another server.
+type DefinitionWatcher interface {
+	WatchDefinitions(ctx context.Context) (DefinitionStream, error)
+}
+
+// Sync applies the changes of the definitions of the store to the running checks,
+// until the context is done. The changes are streamed if the store supports it,
+// the definitions are reloaded every interval otherwise, or while the stream is broken.
+func (m *Manager) Sync(ctx context.Context, store DefinitionStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+
+	watcher, _ := store.(DefinitionWatcher)
+	streaming := watcher != nil
+
+	for {
+		if watcher != nil {
+			err := m.watch(ctx, store, watcher)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if streaming {
+				m.logger.Warnw("Check changes can't be streamed, falling back to polling", "interval", interval, "error", err)
+				streaming = false
+			}
+		}
+
+		if err := m.Reload(ctx, store); err != nil {
+			m.logger.Errorw("Failed to reload the checks", "error", err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reload applies the definitions of the store: the new checks are started, the
+// checks which changed are restarted, and the checks deleted are stopped.
+func (m *Manager) Reload(ctx context.Context, store DefinitionStore) error {
+	definitions, err := store.Definitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	loaded := make(map[string]bool, len(definitions))
+	for _, def := range definitions {
+		loaded[def.Check.CheckID] = true
+		m.apply(def)
+	}
+
+	for checkID := range m.definitions {
+		if !loaded[checkID] {
+			m.logger.Infow("Check deleted from the store", "check", checkID)
+			m.deregister(checkID)
+		}
+	}
+
+	return nil
+}
+
+// Apply applies the change of the definition of the check.
+func (m *Manager) Apply(change *DefinitionChange) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if change.Definition != nil {
+		m.apply(change.Definition)
+		return
+	}
+
+	if _, ok := m.definitions[change.CheckID]; ok {
+		m.logger.Infow("Check deleted from the store", "check", change.CheckID)
+		m.deregister(change.CheckID)
+	}
+}
+
+// watch streams the changes of the definitions, and applies them until the
+// stream fails. The definitions are reloaded once the stream is opened, so
+// that the changes made while it wasn't are applied too.
+func (m *Manager) watch(ctx context.Context, store DefinitionStore, watcher DefinitionWatcher) error {
+	stream, err := watcher.WatchDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stream.Close(context.WithoutCancel(ctx))
+	}()
+
+	if err = m.Reload(ctx, store); err != nil {
+		return err
+	}
+
+	m.logger.Infow("Streaming the check changes.")
+
+	for {
+		change, err := stream.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		m.Apply(change)
+	}
+}
+
+// apply adds the check, or updates it if its definition changed.
+func (m *Manager) apply(def *Definition) {
+	checkID := def.Check.CheckID
+
+	existing, ok := m.definitions[checkID]
+	if ok && sameDefinition(existing, def) {
+		return
+	}
+
+	var err error
+	if ok {
+		m.logger.Infow("Check changed in the store", "check", checkID)
+		err = m.update(def.Check, def.Type)
+	} else {
+		m.logger.Infow("Check added to the store", "check", checkID)
+		err = m.define(def.Check, def.Type)
+	}
+
+	if err != nil {
+		m.logger.Errorw("Failed to start check", "check", checkID, "error", err)
+	}
+}
+
+// sameDefinition reports whether the definitions only differ by the status of
+// the check, e.g. when the change was already applied through the API.
+func sameDefinition(a, b *Definition) bool {
+	x, y := *a.Check, *b.Check
+	x.Status, y.Status = "", ""
+	x.Output, y.Output = "", ""
+	x.Flapping, y.Flapping = false, false
+	x.Locations, y.Locations = nil, nil
+
+	return reflect.DeepEqual(x, y) && reflect.DeepEqual(a.Type, b.Type)
+}
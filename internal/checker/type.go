@@ -1,6 +1,14 @@
 package checker
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EnableScriptChecks allows the checks which run a command. They are disabled by
+// default, since anyone able to define a check could run any command as orbit.
+var EnableScriptChecks = false
 
 type CheckType struct {
 	Name   string
@@ -37,6 +45,12 @@ type CheckType struct {
 	FailuresBeforeWarning  int
 	FailuresBeforeCritical int
 
+	// Flap detection over the last FlapWindow results, disabled if
+	// FlapHighThreshold is 0. Thresholds are percentages of state changes.
+	FlapWindow        int
+	FlapLowThreshold  float64
+	FlapHighThreshold float64
+
 	// Definition fields used when exposing checks through a proxy
 	ProxyHTTP string
 	ProxyGRPC string
@@ -46,4 +60,104 @@ type CheckType struct {
 	// longer than this duration.
 	DeregisterCriticalServiceAfter time.Duration
 	OutputMaxSize                  int
+
+	// Locations the check is run from by the remote agents, instead of by the server.
+	// The check is critical if it is critical in at least Quorum locations, a
+	// majority of them if Quorum is 0.
+	Locations []string
+	Quorum    int
+}
+
+// Validate returns an error message if the check is invalid
+func (c *CheckType) Validate() error {
+	var errs []error
+
+	intervalCheck := c.IsScript() || c.HTTP != "" || c.H2PING != "" || c.TCP != "" || c.UDP != "" || c.GRPC != ""
+
+	if c.Interval > 0 && c.TTL > 0 {
+		errs = append(errs, errors.New("interval and TTL cannot both be specified"))
+	}
+	if c.IsScript() && !EnableScriptChecks {
+		errs = append(errs, errors.New("script checks are disabled, set enable_script_checks to allow them"))
+	}
+	if intervalCheck && c.Interval <= 0 {
+		errs = append(errs, errors.New("interval must be > 0 for Script, HTTP, H2PING, TCP, UDP or gRPC checks"))
+	}
+	if intervalCheck && c.IsAlias() {
+		errs = append(errs, errors.New("interval cannot be set for Alias checks"))
+	}
+	if c.IsAlias() && c.TTL > 0 {
+		errs = append(errs, errors.New("TTL must be not be set for Alias checks"))
+	}
+	if !intervalCheck && !c.IsAlias() && c.TTL <= 0 {
+		errs = append(errs, errors.New("TTL must be > 0 for TTL checks"))
+	}
+	if c.FlapHighThreshold < 0 || c.FlapHighThreshold > 100 || c.FlapLowThreshold < 0 || c.FlapLowThreshold > c.FlapHighThreshold {
+		errs = append(errs, errors.New("flap thresholds must satisfy 0 <= low <= high <= 100"))
+	}
+	if len(c.Locations) > 0 && !intervalCheck {
+		errs = append(errs, errors.New("locations can only be set for Script, HTTP, H2PING, TCP, UDP or gRPC checks"))
+	}
+	if c.Quorum < 0 || c.Quorum > len(c.Locations) {
+		errs = append(errs, fmt.Errorf("invalid quorum %d, must be between 0 and the number of locations", c.Quorum))
+	}
+	if c.OutputMaxSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid output max size %d, must be >= 0", c.OutputMaxSize))
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsAlias checks if this is an alias check.
+func (c *CheckType) IsAlias() bool {
+	return c.AliasNode != "" || c.AliasService != ""
+}
+
+// IsScript checks if this is a check that execs some kind of script.
+func (c *CheckType) IsScript() bool {
+	return len(c.ScriptArgs) > 0
+}
+
+// IsTTL checks if this is a TTL type
+func (c *CheckType) IsTTL() bool {
+	return c.TTL > 0
+}
+
+// IsHTTP checks if this is a HTTP type
+func (c *CheckType) IsHTTP() bool {
+	return c.HTTP != "" && c.Interval > 0
+}
+
+// IsH2PING checks if this is a H2PING type
+func (c *CheckType) IsH2PING() bool {
+	return c.H2PING != "" && c.Interval > 0
+}
+
+// IsTCP checks if this is a TCP type
+func (c *CheckType) IsTCP() bool {
+	return c.TCP != "" && c.Interval > 0
+}
+
+// IsUDP checks if this is a UDP type
+func (c *CheckType) IsUDP() bool {
+	return c.UDP != "" && c.Interval > 0
+}
+
+// IsGRPC checks if this is a GRPC type
+func (c *CheckType) IsGRPC() bool {
+	return c.GRPC != "" && c.Interval > 0
+}
+
+// IsRemote checks if this check is run by remote agents
+func (c *CheckType) IsRemote() bool {
+	return len(c.Locations) > 0
+}
+
+// QuorumSize returns how many locations must fail for the check to fail
+func (c *CheckType) QuorumSize() int {
+	if c.Quorum > 0 {
+		return c.Quorum
+	}
+
+	return len(c.Locations)/2 + 1
 }
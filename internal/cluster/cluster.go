@@ -0,0 +1,261 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultLeaseTTL is how long a member is considered alive after its last heartbeat.
+	DefaultLeaseTTL = 15 * time.Second
+
+	// LeaderLease is the name of the lease held by the leader.
+	LeaderLease = "leader"
+)
+
+// Member is an orbit server of the cluster.
+type Member struct {
+	ID        string    `bson:"_id" json:"id"`
+	Address   string    `bson:"address" json:"address"`
+	StartedAt time.Time `bson:"started_at" json:"started_at"`
+	RenewedAt time.Time `bson:"renewed_at" json:"renewed_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// Store holds the leases of the members and of the leader, it is shared by all members.
+type Store interface {
+	// Heartbeat registers the member, or renews its lease, until its ExpiresAt.
+	Heartbeat(ctx context.Context, member *Member) error
+
+	// Members returns the members whose lease didn't expire at the given time.
+	Members(ctx context.Context, now time.Time) ([]*Member, error)
+
+	// Leave removes the member.
+	Leave(ctx context.Context, id string) error
+
+	// AcquireLease acquires the named lease for the holder until expiresAt, or renews it
+	// if the holder already holds it. It reports whether the holder holds the lease.
+	AcquireLease(ctx context.Context, name, holder string, now, expiresAt time.Time) (bool, error)
+
+	// ReleaseLease releases the named lease, if the holder holds it.
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// Cluster keeps the lease of the member alive, and tracks the other live members.
+// The checks are distributed among the members by consistent hashing, and one of
+// them is elected leader to run the singleton jobs.
+//
+// A member which fails to renew its lease before it expires considers itself
+// out of the cluster: it owns no check and isn't leader until it renews it,
+// since the other members took over meanwhile.
+type Cluster struct {
+	store  Store
+	self   Member
+	ttl    time.Duration
+	logger *zap.SugaredLogger
+
+	lock     sync.RWMutex
+	members  []*Member
+	ring     *Ring
+	leader   bool
+	renewed  time.Time
+	watchers map[chan<- struct{}]struct{}
+}
+
+func New(store Store, id, address string, ttl time.Duration, logger *zap.SugaredLogger) *Cluster {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+
+	return &Cluster{
+		store:    store,
+		self:     Member{ID: id, Address: address, StartedAt: time.Now()},
+		ttl:      ttl,
+		logger:   logger.With("member", id),
+		ring:     NewRing(nil),
+		watchers: make(map[chan<- struct{}]struct{}),
+	}
+}
+
+// ID returns the ID of the member.
+func (c *Cluster) ID() string {
+	return c.self.ID
+}
+
+// Join registers the member and loads the live members, so that the ownership
+// of the checks is known before they are loaded.
+func (c *Cluster) Join(ctx context.Context) error {
+	return c.Tick(ctx, time.Now())
+}
+
+// Run renews the lease of the member every third of the lease TTL,
+// until the context is done.
+func (c *Cluster) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := c.Tick(ctx, now); err != nil {
+				c.logger.Errorw("Failed to renew the cluster membership", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Tick renews the lease of the member, loads the live members and tries to
+// acquire or renew the leadership. The watchers are signalled if the members
+// or the leadership changed.
+func (c *Cluster) Tick(ctx context.Context, now time.Time) error {
+	self := c.self
+	self.RenewedAt = now
+	self.ExpiresAt = now.Add(c.ttl)
+
+	err := c.store.Heartbeat(ctx, &self)
+
+	var members []*Member
+	if err == nil {
+		members, err = c.store.Members(ctx, now)
+	}
+
+	leader := false
+	if err == nil {
+		leader, err = c.store.AcquireLease(ctx, LeaderLease, c.self.ID, now, now.Add(c.ttl))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err == nil {
+		c.renewed = now
+	} else if now.Sub(c.renewed) < c.ttl {
+		// The lease is still valid, keep the current view until it expires.
+		return err
+	} else {
+		members, leader = nil, false
+	}
+
+	changed := !sameMembers(c.members, members) || c.leader != leader
+	if leader != c.leader {
+		if leader {
+			c.logger.Infow("Elected cluster leader")
+		} else {
+			c.logger.Infow("Lost the cluster leadership")
+		}
+	}
+
+	if !sameMembers(c.members, members) {
+		ids := make([]string, 0, len(members))
+		for _, member := range members {
+			ids = append(ids, member.ID)
+		}
+
+		c.ring = NewRing(ids)
+		c.logger.Infow("Cluster members changed", "members", ids)
+	}
+
+	c.members = members
+	c.leader = leader
+
+	if changed {
+		c.notifyChange()
+	}
+
+	return err
+}
+
+// Leave removes the member from the cluster and releases the leadership,
+// the other members take over its checks immediately.
+func (c *Cluster) Leave(ctx context.Context) error {
+	c.lock.Lock()
+	c.members = nil
+	c.ring = NewRing(nil)
+	c.leader = false
+	c.lock.Unlock()
+
+	if err := c.store.ReleaseLease(ctx, LeaderLease, c.self.ID); err != nil {
+		return err
+	}
+
+	return c.store.Leave(ctx, c.self.ID)
+}
+
+// Owns reports whether the member runs the check with the given ID.
+func (c *Cluster) Owns(checkID string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.ring.Owner(checkID) == c.self.ID
+}
+
+// IsLeader reports whether the member is the leader of the cluster.
+func (c *Cluster) IsLeader() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.leader
+}
+
+// Members returns the live members of the cluster.
+func (c *Cluster) Members() []*Member {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return slices.Clone(c.members)
+}
+
+// Notify registers a channel which is signalled whenever the members or the
+// leadership change. The channel should be buffered, signals are dropped if it is full.
+func (c *Cluster) Notify(ch chan<- struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.watchers[ch] = struct{}{}
+}
+
+// StopNotify removes a channel registered with Notify.
+func (c *Cluster) StopNotify(ch chan<- struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.watchers, ch)
+}
+
+// OnChange calls fn whenever the members or the leadership change, until the context is done.
+func (c *Cluster) OnChange(ctx context.Context, fn func()) {
+	changes := make(chan struct{}, 1)
+	c.Notify(changes)
+	defer c.StopNotify(changes)
+
+	for {
+		select {
+		case <-changes:
+			fn()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Cluster) notifyChange() {
+	for ch := range c.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sameMembers reports whether both lists have the same members, in the same order.
+func sameMembers(a, b []*Member) bool {
+	return slices.EqualFunc(a, b, func(x, y *Member) bool {
+		return x.ID == y.ID
+	})
+}
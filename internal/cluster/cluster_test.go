@@ -0,0 +1,394 @@
+package cluster_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/cluster"
+	"github.com/betterde/orbit/internal/database/mongodb"
+)
+
+const testTTL = 15 * time.Second
+
+var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// unreachableStore fails every call while down, like a member cut off from MongoDB.
+type unreachableStore struct {
+	cluster.Store
+	down atomic.Bool
+}
+
+var errUnreachable = errors.New("store unreachable")
+
+func (s *unreachableStore) Heartbeat(ctx context.Context, member *cluster.Member) error {
+	if s.down.Load() {
+		return errUnreachable
+	}
+
+	return s.Store.Heartbeat(ctx, member)
+}
+
+// forEachStore runs the test against the MemoryStore, and against the ClusterStore
+// of MongoDB if the URI of a test database is set in ORBIT_TEST_MONGODB_URI. The
+// collections of the cluster are dropped before each test.
+func forEachStore(t *testing.T, test func(t *testing.T, newStore func() cluster.Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, func() cluster.Store { return cluster.NewMemoryStore() })
+	})
+
+	t.Run("mongodb", func(t *testing.T) {
+		connectMongo(t)
+
+		test(t, func() cluster.Store {
+			for _, name := range []string{mongodb.MemberCollection, mongodb.LeaseCollection} {
+				if err := mongodb.Database.Collection(name).Drop(context.Background()); err != nil {
+					t.Fatalf("failed to drop %s: %s", name, err)
+				}
+			}
+
+			return mongodb.NewClusterStore()
+		})
+	})
+}
+
+var mongoOnce struct {
+	sync.Once
+	err error
+}
+
+// connectMongo connects to the test database once, the test is skipped without one.
+func connectMongo(t *testing.T) {
+	t.Helper()
+
+	uri := os.Getenv("ORBIT_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("ORBIT_TEST_MONGODB_URI isn't set")
+	}
+
+	mongoOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		mongodb.Client, mongoOnce.err = mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if mongoOnce.err == nil {
+			mongoOnce.err = mongodb.Client.Ping(ctx, nil)
+		}
+
+		mongodb.SetDatabase("orbit_test")
+	})
+
+	if mongoOnce.err != nil {
+		t.Fatalf("failed to connect to the test database: %s", mongoOnce.err)
+	}
+}
+
+// newMembers joins the members to the cluster of the store, and ticks them
+// again so that they all know each other.
+func newMembers(t *testing.T, stores []cluster.Store, ids ...string) []*cluster.Cluster {
+	t.Helper()
+
+	members := make([]*cluster.Cluster, 0, len(ids))
+	for i, id := range ids {
+		member := cluster.New(stores[i%len(stores)], id, id+":8080", testTTL, zap.NewNop().Sugar())
+		if err := member.Tick(context.Background(), start); err != nil {
+			t.Fatalf("%s failed to join: %s", id, err)
+		}
+
+		members = append(members, member)
+	}
+
+	tick(t, start, members...)
+
+	return members
+}
+
+func tick(t *testing.T, now time.Time, members ...*cluster.Cluster) {
+	t.Helper()
+
+	for _, member := range members {
+		if err := member.Tick(context.Background(), now); err != nil {
+			t.Fatalf("%s failed to renew its lease: %s", member.ID(), err)
+		}
+	}
+}
+
+func memberIDs(c *cluster.Cluster) []string {
+	var ids []string
+	for _, member := range c.Members() {
+		ids = append(ids, member.ID)
+	}
+
+	return ids
+}
+
+func leaders(members ...*cluster.Cluster) []string {
+	var ids []string
+	for _, member := range members {
+		if member.IsLeader() {
+			ids = append(ids, member.ID())
+		}
+	}
+
+	return ids
+}
+
+// assertOwners checks that every key is owned by exactly one of the members,
+// and returns the owner of each key.
+func assertOwners(t *testing.T, keys int, members ...*cluster.Cluster) map[string]string {
+	t.Helper()
+
+	owners := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("check-%d", i)
+		for _, member := range members {
+			if !member.Owns(key) {
+				continue
+			}
+
+			if owner, ok := owners[key]; ok {
+				t.Fatalf("%s is owned by %s and %s", key, owner, member.ID())
+			}
+			owners[key] = member.ID()
+		}
+
+		if _, ok := owners[key]; !ok {
+			t.Fatalf("%s isn't owned by any member", key)
+		}
+	}
+
+	return owners
+}
+
+func TestJoin(t *testing.T) {
+	forEachStore(t, testJoin)
+}
+
+func testJoin(t *testing.T, newStore func() cluster.Store) {
+	members := newMembers(t, []cluster.Store{newStore()}, "a", "b", "c")
+
+	for _, member := range members {
+		if ids := memberIDs(member); fmt.Sprint(ids) != "[a b c]" {
+			t.Errorf("%s sees the members %v, want [a b c]", member.ID(), ids)
+		}
+	}
+
+	owned := make(map[string]int)
+	for _, owner := range assertOwners(t, 1000, members...) {
+		owned[owner]++
+	}
+
+	for _, member := range members {
+		if owned[member.ID()] < 200 {
+			t.Errorf("%s owns %d of 1000 checks, they are not spread evenly", member.ID(), owned[member.ID()])
+		}
+	}
+}
+
+func TestSingleLeader(t *testing.T) {
+	forEachStore(t, testSingleLeader)
+}
+
+func testSingleLeader(t *testing.T, newStore func() cluster.Store) {
+	members := newMembers(t, []cluster.Store{newStore()}, "a", "b", "c")
+
+	for i := 1; i <= 5; i++ {
+		tick(t, start.Add(time.Duration(i)*testTTL/3), members...)
+
+		if ids := leaders(members...); len(ids) != 1 || ids[0] != "a" {
+			t.Fatalf("the leaders are %v after %d renewals, want [a]", ids, i)
+		}
+	}
+}
+
+func TestLeave(t *testing.T) {
+	forEachStore(t, testLeave)
+}
+
+func testLeave(t *testing.T, newStore func() cluster.Store) {
+	store := newStore()
+	members := newMembers(t, []cluster.Store{store}, "a", "b", "c")
+
+	if err := members[0].Leave(context.Background()); err != nil {
+		t.Fatalf("a failed to leave: %s", err)
+	}
+
+	if members[0].IsLeader() || members[0].Owns("check-0") {
+		t.Fatal("a is still leader or owns checks after leaving")
+	}
+
+	// The others take over before the lease of the member expires.
+	remaining := members[1:]
+	tick(t, start.Add(time.Second), remaining...)
+
+	for _, member := range remaining {
+		if ids := memberIDs(member); fmt.Sprint(ids) != "[b c]" {
+			t.Errorf("%s sees the members %v, want [b c]", member.ID(), ids)
+		}
+	}
+
+	if ids := leaders(remaining...); len(ids) != 1 {
+		t.Errorf("the leaders are %v, want a single leader", ids)
+	}
+
+	assertOwners(t, 1000, remaining...)
+}
+
+func TestExpiry(t *testing.T) {
+	forEachStore(t, testExpiry)
+}
+
+func testExpiry(t *testing.T, newStore func() cluster.Store) {
+	shared := newStore()
+	cutOff := &unreachableStore{Store: shared}
+	members := newMembers(t, []cluster.Store{cutOff, shared, shared}, "a", "b", "c")
+	remaining := members[1:]
+
+	cutOff.down.Store(true)
+
+	// The member keeps its checks and its leadership until its lease expires.
+	now := start.Add(testTTL / 3)
+	if err := members[0].Tick(context.Background(), now); err == nil {
+		t.Fatal("a renewed its lease while cut off")
+	}
+	tick(t, now, remaining...)
+
+	if !members[0].IsLeader() || len(members[0].Members()) != 3 {
+		t.Fatal("a stepped down before its lease expired")
+	}
+
+	if ids := memberIDs(members[1]); fmt.Sprint(ids) != "[a b c]" {
+		t.Fatalf("b sees the members %v before the lease of a expired, want [a b c]", ids)
+	}
+
+	// Once expired, the member steps down and the others take over.
+	now = start.Add(testTTL + time.Second)
+	_ = members[0].Tick(context.Background(), now)
+	tick(t, now, remaining...)
+
+	if members[0].IsLeader() || members[0].Owns("check-0") || len(members[0].Members()) != 0 {
+		t.Fatal("a is still leader or owns checks after its lease expired")
+	}
+
+	if ids := memberIDs(members[1]); fmt.Sprint(ids) != "[b c]" {
+		t.Fatalf("b sees the members %v after the lease of a expired, want [b c]", ids)
+	}
+
+	if ids := leaders(members...); len(ids) != 1 || ids[0] == "a" {
+		t.Fatalf("the leaders are %v after the lease of a expired, want b or c", ids)
+	}
+
+	assertOwners(t, 1000, remaining...)
+
+	// The member joins again as soon as it reaches the store.
+	cutOff.down.Store(false)
+	now = now.Add(testTTL / 3)
+	tick(t, now, members...)
+	tick(t, now, members...)
+
+	assertOwners(t, 1000, members...)
+	if ids := leaders(members...); len(ids) != 1 {
+		t.Fatalf("the leaders are %v after a joined again, want a single leader", ids)
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	forEachStore(t, testRebalance)
+}
+
+func testRebalance(t *testing.T, newStore func() cluster.Store) {
+	members := newMembers(t, []cluster.Store{newStore()}, "a", "b", "c")
+	before := assertOwners(t, 1000, members...)
+
+	changes := make(chan struct{}, 1)
+	members[1].Notify(changes)
+	defer members[1].StopNotify(changes)
+
+	if err := members[0].Leave(context.Background()); err != nil {
+		t.Fatalf("a failed to leave: %s", err)
+	}
+
+	remaining := members[1:]
+	tick(t, start.Add(time.Second), remaining...)
+
+	select {
+	case <-changes:
+	default:
+		t.Fatal("b wasn't notified that a left")
+	}
+
+	// Only the checks of the member which left move.
+	for key, owner := range assertOwners(t, 1000, remaining...) {
+		if before[key] != "a" && before[key] != owner {
+			t.Errorf("%s moved from %s to %s", key, before[key], owner)
+		}
+	}
+}
+
+func TestRebalanceChecks(t *testing.T) {
+	forEachStore(t, testRebalanceChecks)
+}
+
+func testRebalanceChecks(t *testing.T, newStore func() cluster.Store) {
+	members := newMembers(t, []cluster.Store{newStore()}, "a", "b")
+
+	managers := make([]*checker.Manager, 0, len(members))
+	for _, member := range members {
+		manager := checker.NewManager(checker.NewState(), nil, zap.NewNop().Sugar())
+		manager.SetOwner(member.Owns)
+		defer manager.Stop()
+
+		for i := 0; i < 20; i++ {
+			check := &checker.HealthCheck{CheckID: fmt.Sprintf("check-%d", i)}
+			if err := manager.Add(check, &checker.CheckType{TTL: time.Hour}); err != nil {
+				t.Fatalf("failed to add %s: %s", check.CheckID, err)
+			}
+		}
+
+		managers = append(managers, manager)
+	}
+
+	running := func(checkID string) int {
+		count := 0
+		for _, manager := range managers {
+			if _, ok := manager.Get(checkID); ok {
+				count++
+			}
+		}
+		return count
+	}
+
+	for i := 0; i < 20; i++ {
+		if count := running(fmt.Sprintf("check-%d", i)); count != 1 {
+			t.Fatalf("check-%d runs on %d members, want 1", i, count)
+		}
+	}
+
+	if err := members[1].Leave(context.Background()); err != nil {
+		t.Fatalf("b failed to leave: %s", err)
+	}
+	managers[1].Rebalance()
+
+	tick(t, start.Add(time.Second), members[0])
+	managers[0].Rebalance()
+
+	for i := 0; i < 20; i++ {
+		checkID := fmt.Sprintf("check-%d", i)
+		if _, ok := managers[0].Get(checkID); !ok {
+			t.Errorf("%s doesn't run on a after b left", checkID)
+		}
+		if _, ok := managers[1].Get(checkID); ok {
+			t.Errorf("%s still runs on b after it left", checkID)
+		}
+	}
+}
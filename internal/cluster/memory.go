@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store shared by the members of a single process,
+// e.g. to run several members side by side.
+type MemoryStore struct {
+	lock    sync.Mutex
+	members map[string]Member
+	leases  map[string]lease
+}
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		members: make(map[string]Member),
+		leases:  make(map[string]lease),
+	}
+}
+
+func (s *MemoryStore) Heartbeat(_ context.Context, member *Member) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	m := *member
+	if existing, ok := s.members[m.ID]; ok {
+		m.StartedAt = existing.StartedAt
+	}
+	s.members[m.ID] = m
+
+	return nil
+}
+
+func (s *MemoryStore) Members(_ context.Context, now time.Time) ([]*Member, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var members []*Member
+	for _, member := range s.members {
+		if member.ExpiresAt.After(now) {
+			m := member
+			members = append(members, &m)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	return members, nil
+}
+
+func (s *MemoryStore) Leave(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.members, id)
+
+	return nil
+}
+
+func (s *MemoryStore) AcquireLease(_ context.Context, name, holder string, now, expiresAt time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if l, ok := s.leases[name]; ok && l.holder != holder && l.expiresAt.After(now) {
+		return false, nil
+	}
+
+	s.leases[name] = lease{holder: holder, expiresAt: expiresAt}
+
+	return true, nil
+}
+
+func (s *MemoryStore) ReleaseLease(_ context.Context, name, holder string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if l, ok := s.leases[name]; ok && l.holder == holder {
+		delete(s.leases, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// VirtualNodes is the number of points of each member on the ring,
+// so that the keys are spread evenly among the members.
+const VirtualNodes = 128
+
+// Ring distributes keys among members by consistent hashing, only the keys
+// of a member which leaves or joins move to or from other members.
+type Ring struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+func NewRing(ids []string) *Ring {
+	r := &Ring{owners: make(map[uint32]string, len(ids)*VirtualNodes)}
+
+	for _, id := range ids {
+		for i := 0; i < VirtualNodes; i++ {
+			point := hash(id + "#" + strconv.Itoa(i))
+
+			// On the unlikely collision, the lowest ID wins on every member.
+			if owner, ok := r.owners[point]; ok && owner < id {
+				continue
+			}
+
+			r.owners[point] = id
+		}
+	}
+
+	r.points = make([]uint32, 0, len(r.owners))
+	for point := range r.owners {
+		r.points = append(r.points, point)
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+
+	return r
+}
+
+// Owner returns the ID of the member owning the key, empty if the ring is empty.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= h
+	})
+
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]]
+}
+
+// hash returns the FNV-1a hash of the key, mixed with the finalizer of
+// MurmurHash3 so that similar keys are spread over the ring.
+func hash(key string) uint32 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return uint32(x >> 32)
+}
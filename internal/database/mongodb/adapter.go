@@ -67,6 +67,38 @@ func Init(ctx context.Context) {
 	if err != nil {
 		journal.Logger.Panicw("An error occurred while communicating with the MongoDB server!", err)
 	}
+
+	SetDatabase(viper.GetString("database.mongodb.db"))
+
+	err = createResultCollection(currentCtx, viper.GetDuration("history.retention"))
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the check results collection!", err)
+	}
+
+	err = createCatalogIndexes(currentCtx)
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the catalog indexes!", err)
+	}
+
+	err = createDeliveryIndexes(currentCtx)
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the delivery indexes!", err)
+	}
+
+	err = createIncidentIndexes(currentCtx)
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the incident indexes!", err)
+	}
+
+	err = createClusterIndexes(currentCtx)
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the cluster indexes!", err)
+	}
+
+	err = createForwardedResultIndexes(currentCtx)
+	if err != nil {
+		journal.Logger.Panicw("Unable to create the forwarded result indexes!", err)
+	}
 }
 
 func SetDatabase(name string) *mongo.Database {
@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/dao"
+)
+
+// AuditCollection stores the changes made to the catalog.
+const AuditCollection = "audit_logs"
+
+// Audit records the entry in the audit log.
+func Audit(ctx context.Context, entry *dao.AuditLog) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := Database.Collection(AuditCollection).InsertOne(ctx, entry)
+
+	return err
+}
@@ -0,0 +1,247 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+)
+
+const (
+	// NodeCollection stores the nodes of the catalog.
+	NodeCollection = "nodes"
+
+	// ServiceCollection stores the service instances of the catalog.
+	ServiceCollection = "services"
+)
+
+// ErrCheckTaken is returned when a check is saved with the ID of a check of another
+// service, so that a client can't take over the checks of the other services.
+var ErrCheckTaken = errors.New("check ID is used by another service")
+
+// SaveNode creates or replaces the node, keeping its creation time.
+func SaveNode(ctx context.Context, node *dao.Node) error {
+	filter := bson.D{{Key: "_id", Value: node.ID}}
+	collection := Database.Collection(NodeCollection)
+
+	existing := &dao.Node{}
+	err := collection.FindOne(ctx, filter).Decode(existing)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	node.UpdatedAt = time.Now()
+	node.CreatedAt = existing.CreatedAt
+	if node.CreatedAt.IsZero() {
+		node.CreatedAt = node.UpdatedAt
+	}
+
+	_, err = collection.ReplaceOne(ctx, filter, node, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+// EnsureNode creates the node if it doesn't exist yet.
+func EnsureNode(ctx context.Context, nodeID string) error {
+	now := time.Now()
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: "address", Value: ""},
+		{Key: "created_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}}}
+
+	_, err := Database.Collection(NodeCollection).UpdateOne(ctx, bson.D{{Key: "_id", Value: nodeID}}, update, options.Update().SetUpsert(true))
+
+	return err
+}
+
+// SaveService creates or replaces the service, keeping its creation time.
+func SaveService(ctx context.Context, service *dao.Service) error {
+	filter := bson.D{{Key: "_id", Value: service.ID}}
+	collection := Database.Collection(ServiceCollection)
+
+	existing := &dao.Service{}
+	err := collection.FindOne(ctx, filter).Decode(existing)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	service.UpdatedAt = time.Now()
+	service.CreatedAt = existing.CreatedAt
+	if service.CreatedAt.IsZero() {
+		service.CreatedAt = service.UpdatedAt
+	}
+
+	_, err = collection.ReplaceOne(ctx, filter, service, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+// SaveCheck creates or replaces the check, keeping its creation time. It fails
+// with ErrCheckTaken if the check exists with another service.
+func SaveCheck(ctx context.Context, check *dao.Check) error {
+	filter := bson.D{{Key: "_id", Value: check.ID}}
+	collection := Database.Collection(CheckCollection)
+
+	existing := &dao.Check{}
+	err := collection.FindOne(ctx, filter).Decode(existing)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	if err == nil && existing.ServiceID != check.ServiceID {
+		return checkTaken(existing)
+	}
+
+	check.UpdatedAt = time.Now()
+	check.CreatedAt = existing.CreatedAt
+	if check.CreatedAt.IsZero() {
+		check.CreatedAt = check.UpdatedAt
+	}
+
+	_, err = collection.ReplaceOne(ctx, filter, check, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+// EnsureServiceChecks fails with ErrCheckTaken if one of the checks
+// exists with another service than the given one.
+func EnsureServiceChecks(ctx context.Context, serviceID string, checkIDs []string) error {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: checkIDs}}},
+		{Key: "service_id", Value: bson.D{{Key: "$ne", Value: serviceID}}},
+	}
+
+	existing := &dao.Check{}
+	err := Database.Collection(CheckCollection).FindOne(ctx, filter).Decode(existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkTaken(existing)
+}
+
+func checkTaken(existing *dao.Check) error {
+	if existing.ServiceID == "" {
+		return fmt.Errorf("%w: %q is a check of node %q", ErrCheckTaken, existing.ID, existing.Node)
+	}
+
+	return fmt.Errorf("%w: %q is a check of service %q", ErrCheckTaken, existing.ID, existing.ServiceID)
+}
+
+// SaveServiceChecks creates or replaces the checks of the service, and deletes its
+// checks which are not part of them anymore. It returns the IDs of the deleted checks.
+func SaveServiceChecks(ctx context.Context, serviceID string, checks []*dao.Check) ([]string, error) {
+	checkIDs := make([]string, 0, len(checks))
+	for _, check := range checks {
+		if err := SaveCheck(ctx, check); err != nil {
+			return nil, err
+		}
+
+		checkIDs = append(checkIDs, check.ID)
+	}
+
+	return deleteChecks(ctx, bson.D{
+		{Key: "service_id", Value: serviceID},
+		{Key: "_id", Value: bson.D{{Key: "$nin", Value: checkIDs}}},
+	})
+}
+
+// RemoveCheck deletes the check along with its TTL state, it reports whether the check existed.
+func RemoveCheck(ctx context.Context, checkID string) (bool, error) {
+	checkIDs, err := deleteChecks(ctx, bson.D{{Key: "_id", Value: checkID}})
+
+	return len(checkIDs) > 0, err
+}
+
+// RemoveService deletes the service and all its checks.
+// It returns the IDs of the deleted checks.
+func RemoveService(ctx context.Context, serviceID string) ([]string, error) {
+	if _, err := Database.Collection(ServiceCollection).DeleteOne(ctx, bson.D{{Key: "_id", Value: serviceID}}); err != nil {
+		return nil, err
+	}
+
+	return deleteChecks(ctx, bson.D{{Key: "service_id", Value: serviceID}})
+}
+
+// RemoveNode deletes the node, its services and all their checks.
+// It returns the IDs of the deleted checks.
+func RemoveNode(ctx context.Context, nodeID string) ([]string, error) {
+	filter := bson.D{{Key: "node", Value: nodeID}}
+	if _, err := Database.Collection(ServiceCollection).DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	if _, err := Database.Collection(NodeCollection).DeleteOne(ctx, bson.D{{Key: "_id", Value: nodeID}}); err != nil {
+		return nil, err
+	}
+
+	return deleteChecks(ctx, filter)
+}
+
+// deleteChecks deletes the checks matching the filter along with their TTL state.
+func deleteChecks(ctx context.Context, filter bson.D) ([]string, error) {
+	collection := Database.Collection(CheckCollection)
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []*dao.Check
+	if err = cursor.All(ctx, &checks); err != nil {
+		return nil, err
+	}
+
+	checkIDs := make([]string, 0, len(checks))
+	for _, check := range checks {
+		checkIDs = append(checkIDs, check.ID)
+	}
+
+	if len(checkIDs) == 0 {
+		return checkIDs, nil
+	}
+
+	idFilter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: checkIDs}}}}
+	if _, err = collection.DeleteMany(ctx, idFilter); err != nil {
+		return nil, err
+	}
+
+	if _, err = Database.Collection(TTLStateCollection).DeleteMany(ctx, idFilter); err != nil {
+		journal.Logger.Errorw("Failed to delete TTL check states", "checks", checkIDs, "error", err)
+	}
+
+	if _, err = Database.Collection(StatusCollection).DeleteMany(ctx, idFilter); err != nil {
+		journal.Logger.Errorw("Failed to delete the shared check statuses", "checks", checkIDs, "error", err)
+	}
+
+	return checkIDs, nil
+}
+
+// createCatalogIndexes creates the indexes used to look up the services.
+func createCatalogIndexes(ctx context.Context) error {
+	_, err := Database.Collection(ServiceCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "node", Value: 1}}},
+		{Keys: bson.D{{Key: "name", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection(CheckCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "service_id", Value: 1}},
+	})
+
+	return err
+}
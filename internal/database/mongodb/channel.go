@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/notify"
+)
+
+const (
+	// ChannelCollection stores the notification channels.
+	ChannelCollection = "channels"
+
+	// DeliveryCollection stores the outcome of sending the notifications to the channels.
+	DeliveryCollection = "deliveries"
+)
+
+// ChannelStore loads the notification channels from MongoDB.
+type ChannelStore struct{}
+
+func NewChannelStore() *ChannelStore {
+	return &ChannelStore{}
+}
+
+func (s *ChannelStore) Channels(ctx context.Context) ([]*notify.Channel, error) {
+	cursor, err := Database.Collection(ChannelCollection).Find(ctx, bson.D{{Key: "disabled", Value: bson.D{{Key: "$ne", Value: true}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*dao.Channel
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	channels := make([]*notify.Channel, 0, len(docs))
+	for _, doc := range docs {
+		channel, err := doc.Channel()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid notification channel", "channel", doc.ID, "error", err)
+			continue
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+// DeliveryLog records the notification deliveries in MongoDB.
+type DeliveryLog struct{}
+
+func NewDeliveryLog() *DeliveryLog {
+	return &DeliveryLog{}
+}
+
+func (l *DeliveryLog) LogDelivery(ctx context.Context, delivery *notify.Delivery) error {
+	doc := &dao.Delivery{
+		ID:          primitive.NewObjectID(),
+		ChannelID:   delivery.ChannelID,
+		ChannelType: delivery.ChannelType,
+		Receiver:    delivery.Notification.Receiver,
+		GroupKey:    delivery.Notification.GroupKey,
+		CheckIDs:    delivery.Notification.CheckIDs(),
+		Status:      delivery.Notification.Status,
+		Alerts:      delivery.Notification.Alerts,
+		Attempts:    delivery.Attempts,
+		Success:     delivery.Error == "",
+		Error:       delivery.Error,
+		CreatedAt:   delivery.Time,
+	}
+
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+
+	_, err := Database.Collection(DeliveryCollection).InsertOne(ctx, doc)
+
+	return err
+}
+
+// createDeliveryIndexes creates the indexes used to look up the deliveries.
+func createDeliveryIndexes(ctx context.Context) error {
+	_, err := Database.Collection(DeliveryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "check_ids", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return err
+}
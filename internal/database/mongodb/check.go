@@ -0,0 +1,187 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/journal"
+)
+
+// CheckCollection stores the health check definitions.
+const CheckCollection = "checks"
+
+// CheckStore loads the check definitions from MongoDB.
+type CheckStore struct{}
+
+func NewCheckStore() *CheckStore {
+	return &CheckStore{}
+}
+
+func (s *CheckStore) Definitions(ctx context.Context) ([]*checker.Definition, error) {
+	cursor, err := Database.Collection(CheckCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []*dao.Check
+	if err = cursor.All(ctx, &checks); err != nil {
+		return nil, err
+	}
+
+	definitions := make([]*checker.Definition, 0, len(checks))
+	for _, check := range checks {
+		chkType, err := check.CheckType()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid check definition", "check", check.ID, "error", err)
+			continue
+		}
+
+		definitions = append(definitions, &checker.Definition{
+			Check: check.HealthCheck(),
+			Type:  chkType,
+		})
+	}
+
+	return definitions, nil
+}
+
+// WatchDefinitions streams the changes of the checks collection. It requires
+// change streams, i.e. a replica set, which some compatible servers such as
+// FerretDB don't support: the definitions are then polled instead.
+func (s *CheckStore) WatchDefinitions(ctx context.Context) (checker.DefinitionStream, error) {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	stream, err := Database.Collection(CheckCollection).Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkStream{stream: stream}, nil
+}
+
+// checkEvent is the part of a change event of the checks collection used to apply it.
+type checkEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *dao.Check `bson:"fullDocument"`
+}
+
+type checkStream struct {
+	stream *mongo.ChangeStream
+}
+
+func (s *checkStream) Next(ctx context.Context) (*checker.DefinitionChange, error) {
+	for s.stream.Next(ctx) {
+		var event checkEvent
+		if err := s.stream.Decode(&event); err != nil {
+			journal.Logger.Errorw("Skipping invalid check change", "error", err)
+			continue
+		}
+
+		change := &checker.DefinitionChange{CheckID: event.DocumentKey.ID}
+
+		switch event.OperationType {
+		case "insert", "update", "replace":
+			// The check may have been deleted since, when the update is looked up.
+			if event.FullDocument == nil {
+				return change, nil
+			}
+
+			chkType, err := event.FullDocument.CheckType()
+			if err != nil {
+				journal.Logger.Errorw("Skipping invalid check definition", "check", change.CheckID, "error", err)
+				continue
+			}
+
+			change.Definition = &checker.Definition{
+				Check: event.FullDocument.HealthCheck(),
+				Type:  chkType,
+			}
+
+			return change, nil
+		case "delete":
+			return change, nil
+		case "drop", "rename", "dropDatabase", "invalidate":
+			return nil, fmt.Errorf("the %s collection was invalidated by a %s", CheckCollection, event.OperationType)
+		}
+	}
+
+	if err := s.stream.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("the change stream of the %s collection was closed", CheckCollection)
+}
+
+func (s *checkStream) Close(ctx context.Context) error {
+	return s.stream.Close(ctx)
+}
+
+// DeregisterService deletes the service and all its checks, and records the removal in the audit log.
+func (s *CheckStore) DeregisterService(ctx context.Context, serviceID, reason string) error {
+	checkIDs, err := RemoveService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	entry := &dao.AuditLog{
+		Action:    dao.AuditActionServiceReaped,
+		Actor:     "reaper",
+		ServiceID: serviceID,
+		CheckIDs:  checkIDs,
+		Reason:    reason,
+	}
+
+	if err = Audit(ctx, entry); err != nil {
+		journal.Logger.Errorw("Failed to audit the service removal", "service", serviceID, "error", err)
+	}
+
+	return nil
+}
+
+// CriticalSince returns when the check became critical according to its results,
+// i.e. the time of its first critical result since its last result which wasn't.
+func (s *CheckStore) CriticalSince(ctx context.Context, checkID string) (time.Time, bool, error) {
+	collection := Database.Collection(ResultCollection)
+	latest := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	earliest := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	last := &dao.CheckResult{}
+	err := collection.FindOne(ctx, bson.D{
+		{Key: "check_id", Value: checkID},
+		{Key: "status", Value: bson.D{{Key: "$ne", Value: checker.HealthCritical}}},
+	}, latest).Decode(last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, err
+	}
+
+	first := &dao.CheckResult{}
+	err = collection.FindOne(ctx, bson.D{
+		{Key: "check_id", Value: checkID},
+		{Key: "status", Value: checker.HealthCritical},
+		{Key: "timestamp", Value: bson.D{{Key: "$gt", Value: last.Timestamp}}},
+	}, earliest).Decode(first)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, nil
+	}
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return first.Timestamp, true, nil
+}
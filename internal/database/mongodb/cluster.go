@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/internal/cluster"
+)
+
+const (
+	// MemberCollection stores the lease of each member of the cluster.
+	MemberCollection = "cluster_members"
+
+	// LeaseCollection stores the leases of the cluster, e.g. of the leader.
+	LeaseCollection = "cluster_leases"
+)
+
+// ClusterStore stores the leases of the cluster in MongoDB. The leases are
+// compared with the clocks of the members, which must be kept in sync.
+type ClusterStore struct{}
+
+func NewClusterStore() *ClusterStore {
+	return &ClusterStore{}
+}
+
+func (s *ClusterStore) Heartbeat(ctx context.Context, member *cluster.Member) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "address", Value: member.Address},
+			{Key: "renewed_at", Value: member.RenewedAt},
+			{Key: "expires_at", Value: member.ExpiresAt},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "started_at", Value: member.StartedAt}}},
+	}
+
+	_, err := Database.Collection(MemberCollection).UpdateByID(ctx, member.ID, update, options.Update().SetUpsert(true))
+
+	return err
+}
+
+func (s *ClusterStore) Members(ctx context.Context, now time.Time) ([]*cluster.Member, error) {
+	filter := bson.D{{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}}}
+	cursor, err := Database.Collection(MemberCollection).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*cluster.Member
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *ClusterStore) Leave(ctx context.Context, id string) error {
+	_, err := Database.Collection(MemberCollection).DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	return err
+}
+
+// AcquireLease takes the lease if it is held by the holder or expired. The upsert
+// fails with a duplicate key error if another holder holds the lease.
+func (s *ClusterStore) AcquireLease(ctx context.Context, name, holder string, now, expiresAt time.Time) (bool, error) {
+	filter := bson.D{
+		{Key: "_id", Value: name},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "holder", Value: holder}},
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "holder", Value: holder},
+		{Key: "expires_at", Value: expiresAt},
+	}}}
+
+	_, err := Database.Collection(LeaseCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *ClusterStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := Database.Collection(LeaseCollection).DeleteOne(ctx, bson.D{{Key: "_id", Value: name}, {Key: "holder", Value: holder}})
+
+	return err
+}
+
+// createClusterIndexes removes the members which left without
+// a word, an hour after their lease expired.
+func createClusterIndexes(ctx context.Context) error {
+	_, err := Database.Collection(MemberCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(time.Hour.Seconds())),
+	})
+
+	return err
+}
@@ -0,0 +1,112 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// ForwardedResultCollection stores the results of the remote checks reported to
+// a member of the cluster, until the member running the check receives them.
+const ForwardedResultCollection = "forwarded_results"
+
+type forwardedResult struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	CheckID  string             `bson:"check_id"`
+	Location string             `bson:"location"`
+	Status   string             `bson:"status"`
+	Output   string             `bson:"output"`
+	Latency  time.Duration      `bson:"latency"`
+	Time     time.Time          `bson:"time"`
+}
+
+// ResultQueue forwards the results of the remote checks among the members of a cluster.
+type ResultQueue struct {
+	Timeout time.Duration
+}
+
+func NewResultQueue() *ResultQueue {
+	return &ResultQueue{Timeout: 5 * time.Second}
+}
+
+func (q *ResultQueue) PushResult(result *checker.RemoteResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), q.Timeout)
+	defer cancel()
+
+	doc := &forwardedResult{
+		CheckID:  result.CheckID,
+		Location: result.Location,
+		Status:   result.Status,
+		Output:   result.Output,
+		Latency:  result.Latency,
+		Time:     result.Time,
+	}
+
+	_, err := Database.Collection(ForwardedResultCollection).InsertOne(ctx, doc)
+
+	return err
+}
+
+// PopResults deletes the results it returns, a result popped concurrently by
+// two members, e.g. while the check moves, is returned to both.
+func (q *ResultQueue) PopResults(checkIDs []string) ([]*checker.RemoteResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.Timeout)
+	defer cancel()
+
+	collection := Database.Collection(ForwardedResultCollection)
+
+	filter := bson.D{{Key: "check_id", Value: bson.D{{Key: "$in", Value: checkIDs}}}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*forwardedResult
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	results := make([]*checker.RemoteResult, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+		results = append(results, &checker.RemoteResult{
+			CheckID:  doc.CheckID,
+			Location: doc.Location,
+			Status:   doc.Status,
+			Output:   doc.Output,
+			Latency:  doc.Latency,
+			Time:     doc.Time,
+		})
+	}
+
+	if _, err = collection.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// createForwardedResultIndexes drops the results nobody received within an hour,
+// e.g. of a check deleted meanwhile.
+func createForwardedResultIndexes(ctx context.Context) error {
+	_, err := Database.Collection(ForwardedResultCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "check_id", Value: 1}, {Key: "time", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "time", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(time.Hour.Seconds())),
+		},
+	})
+
+	return err
+}
@@ -0,0 +1,175 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/internal/incident"
+)
+
+// IncidentCollection stores the incidents and their timeline.
+const IncidentCollection = "incidents"
+
+// MaxTimeline is the number of events kept in the timeline of an incident,
+// the oldest are dropped, e.g. when a check keeps flapping.
+const MaxTimeline = 1000
+
+// IncidentStore stores the incidents in MongoDB.
+type IncidentStore struct{}
+
+func NewIncidentStore() *IncidentStore {
+	return &IncidentStore{}
+}
+
+func (s *IncidentStore) Create(ctx context.Context, doc *incident.Incident) error {
+	doc.ID = primitive.NewObjectID().Hex()
+	_, err := Database.Collection(IncidentCollection).InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return incident.ErrOpen
+	}
+
+	return err
+}
+
+func (s *IncidentStore) Get(ctx context.Context, id string) (*incident.Incident, error) {
+	doc := &incident.Incident{}
+	err := Database.Collection(IncidentCollection).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, incident.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (s *IncidentStore) FindOpen(ctx context.Context, key string) (*incident.Incident, error) {
+	filter := bson.D{{Key: "key", Value: key}, {Key: "status", Value: bson.D{{Key: "$ne", Value: incident.StatusResolved}}}}
+
+	doc := &incident.Incident{}
+	err := Database.Collection(IncidentCollection).FindOne(ctx, filter).Decode(doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (s *IncidentStore) FindByStatus(ctx context.Context, status string) ([]*incident.Incident, error) {
+	cursor, err := Database.Collection(IncidentCollection).Find(ctx, bson.D{{Key: "status", Value: status}}, options.Find().SetProjection(bson.D{{Key: "timeline", Value: 0}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []*incident.Incident
+	if err = cursor.All(ctx, &incidents); err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+func (s *IncidentStore) FindOpenByCheck(ctx context.Context, checkID string) ([]*incident.Incident, error) {
+	filter := bson.D{{Key: "check_ids", Value: checkID}, {Key: "status", Value: bson.D{{Key: "$ne", Value: incident.StatusResolved}}}}
+
+	cursor, err := Database.Collection(IncidentCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []*incident.Incident
+	if err = cursor.All(ctx, &incidents); err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+func (s *IncidentStore) AddEvent(ctx context.Context, id, checkID string, event *incident.Event) error {
+	update := bson.D{
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: bson.D{
+			{Key: "$each", Value: bson.A{event}},
+			{Key: "$slice", Value: -MaxTimeline},
+		}}}},
+		{Key: "$max", Value: bson.D{{Key: "updated_at", Value: event.Time}}},
+	}
+
+	if checkID != "" {
+		update = append(update, bson.E{Key: "$addToSet", Value: bson.D{{Key: "check_ids", Value: checkID}}})
+	}
+
+	result, err := Database.Collection(IncidentCollection).UpdateByID(ctx, id, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return incident.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *IncidentStore) Update(ctx context.Context, doc *incident.Incident, previous string, event *incident.Event) error {
+	filter := bson.D{{Key: "_id", Value: doc.ID}, {Key: "status", Value: previous}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: doc.Status},
+			{Key: "assigned_to", Value: doc.AssignedTo},
+			{Key: "escalations", Value: doc.Escalations},
+			{Key: "acknowledged_at", Value: doc.AcknowledgedAt},
+			{Key: "acknowledged_by", Value: doc.AcknowledgedBy},
+			{Key: "resolved_at", Value: doc.ResolvedAt},
+			{Key: "resolved_by", Value: doc.ResolvedBy},
+			{Key: "time_to_acknowledge", Value: doc.TimeToAcknowledge},
+			{Key: "time_to_resolve", Value: doc.TimeToResolve},
+			{Key: "updated_at", Value: event.Time},
+		}},
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: bson.D{
+			{Key: "$each", Value: bson.A{event}},
+			{Key: "$slice", Value: -MaxTimeline},
+		}}}},
+	}
+
+	result, err := Database.Collection(IncidentCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return incident.ErrConflict
+	}
+
+	return nil
+}
+
+// createIncidentIndexes creates the indexes used to look up the open incidents. One
+// incident per key may be open, so that the servers of a cluster don't open the same
+// incident twice. Partial indexes don't support $ne, the statuses of the open
+// incidents are the ones sorted before resolved.
+func createIncidentIndexes(ctx context.Context) error {
+	open := bson.D{{Key: "status", Value: bson.D{{Key: "$lt", Value: incident.StatusResolved}}}}
+
+	_, err := Database.Collection(IncidentCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}, {Key: "status", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("key_open").SetUnique(true).SetPartialFilterExpression(open),
+		},
+		{Keys: bson.D{{Key: "check_ids", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "opened_at", Value: -1}}},
+	})
+
+	return err
+}
@@ -0,0 +1,46 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/maintenance"
+)
+
+// MaintenanceCollection stores the maintenance windows.
+const MaintenanceCollection = "maintenances"
+
+// MaintenanceStore loads the maintenance windows from MongoDB.
+type MaintenanceStore struct{}
+
+func NewMaintenanceStore() *MaintenanceStore {
+	return &MaintenanceStore{}
+}
+
+func (s *MaintenanceStore) Windows(ctx context.Context) ([]*maintenance.Window, error) {
+	cursor, err := Database.Collection(MaintenanceCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var maintenances []*dao.Maintenance
+	if err = cursor.All(ctx, &maintenances); err != nil {
+		return nil, err
+	}
+
+	windows := make([]*maintenance.Window, 0, len(maintenances))
+	for _, m := range maintenances {
+		window, err := m.Window()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid maintenance window", "maintenance", m.ID.Hex(), "error", err)
+			continue
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
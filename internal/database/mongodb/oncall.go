@@ -0,0 +1,106 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/oncall"
+)
+
+const (
+	// ScheduleCollection stores the on-call schedules.
+	ScheduleCollection = "schedules"
+
+	// EscalationPolicyCollection stores the escalation policies.
+	EscalationPolicyCollection = "escalation_policies"
+
+	// UserCollection stores the users.
+	UserCollection = "users"
+)
+
+// OnCallStore loads the schedules, escalation policies and users from MongoDB.
+type OnCallStore struct{}
+
+func NewOnCallStore() *OnCallStore {
+	return &OnCallStore{}
+}
+
+func (s *OnCallStore) Schedules(ctx context.Context) ([]*oncall.Schedule, error) {
+	cursor, err := Database.Collection(ScheduleCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*dao.Schedule
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*oncall.Schedule, 0, len(docs))
+	for _, doc := range docs {
+		schedule, err := doc.Schedule()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid on-call schedule", "schedule", doc.ID.Hex(), "error", err)
+			continue
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+func (s *OnCallStore) Policies(ctx context.Context) ([]*oncall.Policy, error) {
+	cursor, err := Database.Collection(EscalationPolicyCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*dao.EscalationPolicy
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	policies := make([]*oncall.Policy, 0, len(docs))
+	for _, doc := range docs {
+		policy, err := doc.Policy()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid escalation policy", "policy", doc.ID.Hex(), "error", err)
+			continue
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func (s *OnCallStore) Users(ctx context.Context, ids []string) ([]*oncall.User, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+
+	cursor, err := Database.Collection(UserCollection).Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: oids}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*dao.User
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	users := make([]*oncall.User, 0, len(docs))
+	for _, doc := range docs {
+		users = append(users, &oncall.User{ID: doc.ID.Hex(), Name: doc.Name, Email: doc.Email})
+	}
+
+	return users, nil
+}
@@ -0,0 +1,202 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/journal"
+)
+
+const (
+	// ResultCollection is the time series collection of check results.
+	ResultCollection = "check_results"
+
+	// DefaultResultRetention is how long check results are kept by default.
+	DefaultResultRetention = 30 * 24 * time.Hour
+
+	resultBatchSize = 100
+	resultQueueSize = 10000
+)
+
+// ResultWriter persists check results in batches, so that slow writes
+// never delay the checks themselves.
+type ResultWriter struct {
+	queue  chan *dao.CheckResult
+	stopCh chan struct{}
+	stopWg sync.WaitGroup
+}
+
+func NewResultWriter() *ResultWriter {
+	return &ResultWriter{
+		queue:  make(chan *dao.CheckResult, resultQueueSize),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start is used to start writing the queued results.
+func (w *ResultWriter) Start() {
+	w.stopWg.Add(1)
+	go w.run()
+}
+
+// Stop flushes the queued results and stops the writer.
+func (w *ResultWriter) Stop() {
+	close(w.stopCh)
+	w.stopWg.Wait()
+}
+
+// Notifier returns a CheckNotifier which records every result of the check,
+// before the thresholds are applied.
+func (w *ResultWriter) Notifier(check *checker.HealthCheck) checker.CheckNotifier {
+	return &resultNotifier{writer: w, checkID: check.CheckID}
+}
+
+func (w *ResultWriter) enqueue(result *dao.CheckResult) {
+	select {
+	case w.queue <- result:
+	default:
+		journal.Logger.Warnw("Check result queue is full, dropping result", "check", result.CheckID)
+	}
+}
+
+func (w *ResultWriter) run() {
+	defer w.stopWg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, resultBatchSize)
+	for {
+		select {
+		case result := <-w.queue:
+			batch = append(batch, result)
+			if len(batch) >= resultBatchSize {
+				batch = w.flush(batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case <-w.stopCh:
+			for {
+				select {
+				case result := <-w.queue:
+					batch = append(batch, result)
+				default:
+					w.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *ResultWriter) flush(batch []interface{}) []interface{} {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := Database.Collection(ResultCollection).InsertMany(ctx, batch); err != nil {
+		journal.Logger.Errorw("Failed to persist check results", "count", len(batch), "error", err)
+	}
+
+	return batch[:0]
+}
+
+type resultNotifier struct {
+	writer  *ResultWriter
+	checkID string
+}
+
+// UpdateCheck ignores the status reported once the thresholds are applied,
+// the results were already recorded.
+func (n *resultNotifier) UpdateCheck(string, string) {}
+
+func (n *resultNotifier) RecordResult(result *checker.Result) {
+	n.writer.enqueue(&dao.CheckResult{
+		Timestamp: result.Time,
+		CheckID:   n.checkID,
+		Status:    result.Status,
+		Output:    result.Output,
+		Latency:   float64(result.Latency) / float64(time.Millisecond),
+		PerfData:  result.PerfData,
+	})
+}
+
+// createResultCollection creates the time series collection of check results,
+// expiring the results after the retention. Deployments without time series
+// support get a regular collection with a TTL index instead.
+func createResultCollection(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		retention = DefaultResultRetention
+	}
+
+	expireAfter := int64(retention.Seconds())
+
+	names, err := Database.ListCollectionNames(ctx, bson.D{{Key: "name", Value: ResultCollection}})
+	if err != nil {
+		return err
+	}
+
+	if len(names) > 0 {
+		// Keep the retention of the existing collection in sync with the configuration.
+		err = Database.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: ResultCollection},
+			{Key: "expireAfterSeconds", Value: expireAfter},
+		}).Err()
+		if err == nil {
+			return nil
+		}
+
+		journal.Logger.Debugw("Check results collection is not a time series collection", "error", err)
+
+		return createResultIndexes(ctx, expireAfter)
+	}
+
+	opts := options.CreateCollection().
+		SetTimeSeriesOptions(options.TimeSeries().SetTimeField("timestamp").SetMetaField("check_id").SetGranularity("seconds")).
+		SetExpireAfterSeconds(expireAfter)
+
+	if err = Database.CreateCollection(ctx, ResultCollection, opts); err != nil {
+		journal.Logger.Warnw("Time series collections are not supported, falling back to a TTL index", "error", err)
+
+		return createResultIndexes(ctx, expireAfter)
+	}
+
+	return nil
+}
+
+func createResultIndexes(ctx context.Context, expireAfter int64) error {
+	// Update the expiry of the existing TTL index first, creating an index
+	// with different options would fail.
+	err := Database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: ResultCollection},
+		{Key: "index", Value: bson.D{
+			{Key: "keyPattern", Value: bson.D{{Key: "timestamp", Value: 1}}},
+			{Key: "expireAfterSeconds", Value: expireAfter},
+		}},
+	}).Err()
+	if err != nil {
+		journal.Logger.Debugw("Unable to update the check results TTL index", "error", err)
+	}
+
+	_, err = Database.Collection(ResultCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "timestamp", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(expireAfter)),
+		},
+		{
+			Keys: bson.D{{Key: "check_id", Value: 1}, {Key: "timestamp", Value: 1}},
+		},
+	})
+
+	return err
+}
@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/journal"
+	"github.com/betterde/orbit/internal/notify"
+)
+
+// SilenceCollection stores the silences of the alerts.
+const SilenceCollection = "silences"
+
+// SilenceStore loads the silences which haven't expired from MongoDB.
+type SilenceStore struct{}
+
+func NewSilenceStore() *SilenceStore {
+	return &SilenceStore{}
+}
+
+func (s *SilenceStore) Silences(ctx context.Context) ([]*notify.Silence, error) {
+	cursor, err := Database.Collection(SilenceCollection).Find(ctx, bson.D{{Key: "ends_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*dao.Silence
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	silences := make([]*notify.Silence, 0, len(docs))
+	for _, doc := range docs {
+		silence, err := doc.Silence()
+		if err != nil {
+			journal.Logger.Errorw("Skipping invalid silence", "silence", doc.ID.Hex(), "error", err)
+			continue
+		}
+
+		silences = append(silences, silence)
+	}
+
+	return silences, nil
+}
@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// StatusCollection stores the status of the checks shared by the members of a cluster.
+const StatusCollection = "check_statuses"
+
+type checkStatus struct {
+	CheckID   string                    `bson:"_id"`
+	Status    string                    `bson:"status"`
+	Output    string                    `bson:"output"`
+	Flapping  bool                      `bson:"flapping"`
+	Locations []*checker.LocationStatus `bson:"locations,omitempty"`
+	UpdatedAt time.Time                 `bson:"updated_at"`
+}
+
+// StatusStore shares the status of the checks among the members of a cluster.
+type StatusStore struct{}
+
+func NewStatusStore() *StatusStore {
+	return &StatusStore{}
+}
+
+func (s *StatusStore) SaveStatuses(ctx context.Context, checks []*checker.HealthCheck) error {
+	now := time.Now()
+
+	models := make([]mongo.WriteModel, 0, len(checks))
+	for _, check := range checks {
+		doc := checkStatus{
+			CheckID:   check.CheckID,
+			Status:    check.Status,
+			Output:    check.Output,
+			Flapping:  check.Flapping,
+			Locations: check.Locations,
+			UpdatedAt: now,
+		}
+
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: check.CheckID}}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+
+	_, err := Database.Collection(StatusCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+func (s *StatusStore) Statuses(ctx context.Context, checkIDs []string) ([]*checker.HealthCheck, error) {
+	filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: checkIDs}}}}
+	cursor, err := Database.Collection(StatusCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*checkStatus
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	checks := make([]*checker.HealthCheck, 0, len(docs))
+	for _, doc := range docs {
+		checks = append(checks, &checker.HealthCheck{
+			CheckID:   doc.CheckID,
+			Status:    doc.Status,
+			Output:    doc.Output,
+			Flapping:  doc.Flapping,
+			Locations: doc.Locations,
+		})
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// TTLStateCollection stores the last status pushed to each TTL check.
+const TTLStateCollection = "ttl_states"
+
+type ttlState struct {
+	CheckID   string    `bson:"_id"`
+	Status    string    `bson:"status"`
+	Output    string    `bson:"output"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// TTLStore persists the state of TTL checks in MongoDB.
+type TTLStore struct {
+	Timeout time.Duration
+}
+
+func NewTTLStore() *TTLStore {
+	return &TTLStore{Timeout: 5 * time.Second}
+}
+
+func (s *TTLStore) LoadTTL(checkID string) (*checker.TTLState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	var state ttlState
+	err := Database.Collection(TTLStateCollection).FindOne(ctx, bson.D{{Key: "_id", Value: checkID}}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &checker.TTLState{
+		Status:    state.Status,
+		Output:    state.Output,
+		UpdatedAt: state.UpdatedAt,
+	}, nil
+}
+
+func (s *TTLStore) SaveTTL(checkID string, state *checker.TTLState) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	doc := ttlState{
+		CheckID:   checkID,
+		Status:    state.Status,
+		Output:    state.Output,
+		UpdatedAt: state.UpdatedAt,
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := Database.Collection(TTLStateCollection).ReplaceOne(ctx, bson.D{{Key: "_id", Value: checkID}}, doc, opts)
+
+	return err
+}
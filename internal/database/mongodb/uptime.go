@@ -0,0 +1,229 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/betterde/orbit/dao"
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/journal"
+)
+
+// Uptime computes the availability of the check between from and to.
+// Every result holds until the next one, the status before the first result
+// of the window is taken from the last result preceding it.
+//
+// The time spent in each status is aggregated by MongoDB with $setWindowFields,
+// which requires MongoDB 5.0. The results are walked in order instead if the
+// server is older, or if the aggregation fails, e.g. on FerretDB.
+func Uptime(ctx context.Context, checkID string, from, to time.Time) (*dao.Uptime, error) {
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+
+	collection := Database.Collection(ResultCollection)
+
+	// The status the check was in when the window starts.
+	previous := &dao.CheckResult{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	err := collection.FindOne(ctx, bson.D{
+		{Key: "check_id", Value: checkID},
+		{Key: "timestamp", Value: bson.D{{Key: "$lt", Value: from}}},
+	}, opts).Decode(previous)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// An outage that started before the window is still an outage within it.
+	var outages int64
+	if previous.Status == checker.HealthCritical {
+		outages = 1
+	}
+
+	var durations map[string]time.Duration
+	if supportsWindowFields(ctx) {
+		var aggregated int64
+		durations, aggregated, err = aggregateUptime(ctx, collection, checkID, previous.Status, from, to)
+		if err == nil {
+			return computeUptime(checkID, from, to, durations, outages+aggregated), nil
+		}
+
+		journal.Logger.Warnw("Failed to aggregate the uptime, the results are walked instead", "check", checkID, "error", err)
+	}
+
+	var walked int64
+	durations, walked, err = walkUptime(ctx, collection, checkID, previous.Status, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeUptime(checkID, from, to, durations, outages+walked), nil
+}
+
+var windowFields struct {
+	once      sync.Once
+	supported bool
+}
+
+// supportsWindowFields reports whether the server supports $setWindowFields,
+// i.e. its version is 5.0 or later. The version is looked up once.
+func supportsWindowFields(ctx context.Context) bool {
+	windowFields.once.Do(func() {
+		var info struct {
+			VersionArray []int32 `bson:"versionArray"`
+		}
+
+		err := Database.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info)
+		windowFields.supported = err == nil && len(info.VersionArray) > 0 && info.VersionArray[0] >= 5
+	})
+
+	return windowFields.supported
+}
+
+// aggregateUptime returns the time spent in each status within the window, and
+// how many outages started in it. Each result lasts until the next one, the last
+// one until the end of the window, and the time before the first one is spent
+// in the previous status.
+func aggregateUptime(ctx context.Context, collection *mongo.Collection, checkID, previous string, from, to time.Time) (map[string]time.Duration, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "check_id", Value: checkID},
+			{Key: "timestamp", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		}}},
+		{{Key: "$setWindowFields", Value: bson.D{
+			{Key: "sortBy", Value: bson.D{{Key: "timestamp", Value: 1}}},
+			{Key: "output", Value: bson.D{
+				{Key: "next", Value: bson.D{{Key: "$shift", Value: bson.D{{Key: "output", Value: "$timestamp"}, {Key: "by", Value: 1}, {Key: "default", Value: to}}}}},
+				{Key: "previous", Value: bson.D{{Key: "$shift", Value: bson.D{{Key: "output", Value: "$status"}, {Key: "by", Value: -1}, {Key: "default", Value: previous}}}}},
+			}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$status"},
+			{Key: "duration", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$subtract", Value: bson.A{"$next", "$timestamp"}}}}}},
+			{Key: "outages", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$and", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{"$status", checker.HealthCritical}}},
+					bson.D{{Key: "$ne", Value: bson.A{"$previous", checker.HealthCritical}}},
+				}}},
+				1,
+				0,
+			}}}}}},
+			{Key: "first", Value: bson.D{{Key: "$min", Value: "$timestamp"}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var statuses []struct {
+		Status   string    `bson:"_id"`
+		Duration int64     `bson:"duration"` // In milliseconds.
+		Outages  int64     `bson:"outages"`
+		First    time.Time `bson:"first"`
+	}
+	if err = cursor.All(ctx, &statuses); err != nil {
+		return nil, 0, err
+	}
+
+	durations := make(map[string]time.Duration)
+	first := to
+	var outages int64
+	for _, s := range statuses {
+		durations[s.Status] += time.Duration(s.Duration) * time.Millisecond
+		outages += s.Outages
+		if s.First.Before(first) {
+			first = s.First
+		}
+	}
+
+	durations[previous] += first.Sub(from)
+
+	return durations, outages, nil
+}
+
+// walkUptime is aggregateUptime walking the results in order, for the servers
+// which don't support $setWindowFields.
+func walkUptime(ctx context.Context, collection *mongo.Collection, checkID, previous string, from, to time.Time) (map[string]time.Duration, int64, error) {
+	cursor, err := collection.Find(ctx, bson.D{
+		{Key: "check_id", Value: checkID},
+		{Key: "timestamp", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+	}, options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetProjection(bson.D{{Key: "status", Value: 1}, {Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	durations := make(map[string]time.Duration)
+	status, since := previous, from
+
+	var outages int64
+	for cursor.Next(ctx) {
+		result := &dao.CheckResult{}
+		if err = cursor.Decode(result); err != nil {
+			return nil, 0, err
+		}
+
+		durations[status] += result.Timestamp.Sub(since)
+		if result.Status == checker.HealthCritical && status != checker.HealthCritical {
+			outages++
+		}
+
+		status, since = result.Status, result.Timestamp
+	}
+
+	if err = cursor.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	durations[status] += to.Sub(since)
+
+	return durations, outages, nil
+}
+
+// computeUptime returns the uptime of the time spent in each status, the time
+// before the first result known, i.e. without status, isn't monitored.
+func computeUptime(checkID string, from, to time.Time, durations map[string]time.Duration, outages int64) *dao.Uptime {
+	uptime := &dao.Uptime{
+		CheckID: checkID,
+		From:    from,
+		To:      to,
+		Outages: outages,
+	}
+
+	for status, d := range durations {
+		seconds := d.Seconds()
+		switch status {
+		case "":
+			continue
+		case checker.HealthMaint:
+			uptime.Maintenance += seconds
+			continue
+		case checker.HealthCritical:
+			uptime.Downtime += seconds
+		}
+
+		uptime.Monitored += seconds
+	}
+
+	if uptime.Monitored > 0 {
+		availability := (uptime.Monitored - uptime.Downtime) / uptime.Monitored * 100
+		uptime.Availability = &availability
+	}
+
+	if uptime.Outages > 0 {
+		uptime.MTTR = uptime.Downtime / float64(uptime.Outages)
+		uptime.MTBF = (uptime.Monitored - uptime.Downtime) / float64(uptime.Outages)
+	}
+
+	return uptime
+}
@@ -0,0 +1,107 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// The statuses of an incident, the ones of the open incidents sort before
+// resolved, which the stores may rely on.
+const (
+	StatusOpen         = "open"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// The types of the events of the timeline.
+const (
+	EventOpened       = "opened"
+	EventTransition   = "transition"
+	EventNotification = "notification"
+	EventAcknowledged = "acknowledged"
+	EventAssigned     = "assigned"
+	EventComment      = "comment"
+	EventResolved     = "resolved"
+	EventEscalated    = "escalated"
+)
+
+// TeamLabel is the label of the checks holding the ID of their team,
+// whose escalation policy applies to their incidents.
+const TeamLabel = "team"
+
+// ActorOrbit is the actor of the events recorded automatically.
+const ActorOrbit = "orbit"
+
+var (
+	ErrNotFound     = errors.New("incident not found")
+	ErrResolved     = errors.New("incident already resolved")
+	ErrAcknowledged = errors.New("incident already acknowledged")
+	ErrConflict     = errors.New("incident changed concurrently, try again")
+	ErrOpen         = errors.New("incident already open")
+)
+
+// Incident is opened when a check goes critical, and gathers the transitions of the
+// checks of the same service and the notifications sent about them until resolved.
+type Incident struct {
+	ID          string   `bson:"_id" json:"id"`
+	Key         string   `bson:"key" json:"key"` // "service:<id>" or "check:<id>", one incident per key is open.
+	Title       string   `bson:"title" json:"title"`
+	Status      string   `bson:"status" json:"status"`
+	Node        string   `bson:"node" json:"node"`
+	ServiceID   string   `bson:"service_id" json:"service_id"`
+	ServiceName string   `bson:"service_name" json:"service_name"`
+	CheckIDs    []string `bson:"check_ids" json:"check_ids"`
+	TeamID      string   `bson:"team_id,omitempty" json:"team_id,omitempty"`
+	AssignedTo  string   `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
+	Escalations int      `bson:"escalations" json:"escalations"` // The steps of the escalation policy notified.
+
+	OpenedAt          time.Time     `bson:"opened_at" json:"opened_at"`
+	AcknowledgedAt    *time.Time    `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	AcknowledgedBy    string        `bson:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+	ResolvedAt        *time.Time    `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResolvedBy        string        `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	TimeToAcknowledge time.Duration `bson:"time_to_acknowledge,omitempty" json:"time_to_acknowledge,omitempty"`
+	TimeToResolve     time.Duration `bson:"time_to_resolve,omitempty" json:"time_to_resolve,omitempty"`
+	UpdatedAt         time.Time     `bson:"updated_at" json:"updated_at"`
+
+	Timeline []*Event `bson:"timeline" json:"timeline"`
+}
+
+// Event is an entry of the timeline of an incident.
+type Event struct {
+	Type      string    `bson:"type" json:"type"`
+	Time      time.Time `bson:"time" json:"time"`
+	Actor     string    `bson:"actor,omitempty" json:"actor,omitempty"`
+	CheckID   string    `bson:"check_id,omitempty" json:"check_id,omitempty"`
+	Status    string    `bson:"status,omitempty" json:"status,omitempty"`
+	Previous  string    `bson:"previous,omitempty" json:"previous,omitempty"`
+	ChannelID string    `bson:"channel_id,omitempty" json:"channel_id,omitempty"`
+	Message   string    `bson:"message,omitempty" json:"message,omitempty"`
+}
+
+// Store persists the incidents, e.g. in MongoDB.
+type Store interface {
+	// Create stores the new incident and sets its ID, ErrOpen if
+	// an incident with the same key isn't resolved.
+	Create(ctx context.Context, incident *Incident) error
+
+	// Get returns the incident, ErrNotFound if it doesn't exist.
+	Get(ctx context.Context, id string) (*Incident, error)
+
+	// FindOpen returns the incident with the key which isn't resolved, nil if there is none.
+	FindOpen(ctx context.Context, key string) (*Incident, error)
+
+	// FindByStatus returns the incidents with the status.
+	FindByStatus(ctx context.Context, status string) ([]*Incident, error)
+
+	// FindOpenByCheck returns the incidents of the check which aren't resolved.
+	FindOpenByCheck(ctx context.Context, checkID string) ([]*Incident, error)
+
+	// AddEvent appends the event to the timeline, and the check to the checks of the incident.
+	AddEvent(ctx context.Context, id, checkID string, event *Event) error
+
+	// Update stores the status, assignee, escalations, acknowledgement and resolution of the
+	// incident and appends the event, ErrConflict if its status isn't previous anymore.
+	Update(ctx context.Context, incident *Incident, previous string, event *Event) error
+}
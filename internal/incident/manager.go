@@ -0,0 +1,342 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/notify"
+)
+
+// Manager opens an incident when a check goes critical, records the following
+// transitions and notifications in its timeline, and resolves it once all its
+// checks are passing again. The checks of a service share the incident of the service.
+type Manager struct {
+	state  *checker.State
+	store  Store
+	logger *zap.SugaredLogger
+}
+
+func NewManager(state *checker.State, store Store, logger *zap.SugaredLogger) *Manager {
+	return &Manager{
+		state:  state,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Key returns the key of the incidents of the check.
+func Key(check *checker.HealthCheck) string {
+	if check.ServiceID != "" {
+		return "service:" + check.ServiceID
+	}
+
+	return "check:" + check.CheckID
+}
+
+// Run records the transitions until the context is done.
+func (m *Manager) Run(ctx context.Context, events *checker.Events) {
+	transitions := events.Subscribe(0)
+	defer events.Unsubscribe(transitions)
+
+	for {
+		select {
+		case t := <-transitions:
+			if err := m.Handle(ctx, t); err != nil {
+				m.logger.Errorw("Failed to record the transition in the incidents", "check", t.CheckID, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Handle opens the incident of the check if it went critical, or records the
+// transition in the open incident, which is resolved if all its checks recovered.
+func (m *Manager) Handle(ctx context.Context, t *checker.Transition) error {
+	if t.Removed {
+		return nil
+	}
+
+	check, ok := m.state.Check(t.CheckID)
+	if !ok {
+		check = &checker.HealthCheck{CheckID: t.CheckID}
+	}
+
+	event := &Event{
+		Type:     EventTransition,
+		Time:     t.Time,
+		CheckID:  t.CheckID,
+		Status:   t.Status,
+		Previous: t.Previous,
+		Message:  t.Output,
+	}
+
+	incident, err := m.store.FindOpen(ctx, Key(check))
+	if err != nil {
+		return err
+	}
+
+	if incident == nil {
+		// A flapping check opens an incident once it settles, its
+		// settled transition is emitted even if its status is unchanged.
+		if t.Status != checker.HealthCritical || t.Flapping {
+			return nil
+		}
+
+		err = m.open(ctx, check, event)
+		if !errors.Is(err, ErrOpen) {
+			return err
+		}
+
+		// Opened meanwhile, e.g. by another server, the transition is recorded in it.
+		if incident, err = m.store.FindOpen(ctx, Key(check)); err != nil || incident == nil {
+			return err
+		}
+	}
+
+	if err = m.store.AddEvent(ctx, incident.ID, t.CheckID, event); err != nil {
+		return err
+	}
+
+	if t.Status != checker.HealthPassing || !m.recovered(incident, t.CheckID) {
+		return nil
+	}
+
+	_, err = m.update(ctx, incident.ID, func(incident *Incident, event *Event) error {
+		return resolve(incident, event, ActorOrbit, "All checks are passing.")
+	})
+
+	return err
+}
+
+// LogDelivery records the notification in the timeline of the open incidents
+// of its checks, the dispatcher logs its deliveries to the manager.
+func (m *Manager) LogDelivery(ctx context.Context, delivery *notify.Delivery) error {
+	n := delivery.Notification
+	if n.Test {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s notification sent to %s", n.Status, delivery.ChannelID)
+	if delivery.Error != "" {
+		message = fmt.Sprintf("%s notification to %s failed: %s", n.Status, delivery.ChannelID, delivery.Error)
+	}
+
+	recorded := make(map[string]bool)
+	for _, checkID := range n.CheckIDs() {
+		incidents, err := m.store.FindOpenByCheck(ctx, checkID)
+		if err != nil {
+			return err
+		}
+
+		for _, incident := range incidents {
+			if recorded[incident.ID] {
+				continue
+			}
+			recorded[incident.ID] = true
+
+			event := &Event{
+				Type:      EventNotification,
+				Time:      delivery.Time,
+				Status:    n.Status,
+				ChannelID: delivery.ChannelID,
+				Message:   message,
+			}
+
+			if err = m.store.AddEvent(ctx, incident.ID, "", event); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Acknowledge records that the user is working on the incident.
+func (m *Manager) Acknowledge(ctx context.Context, id, user string) (*Incident, error) {
+	return m.update(ctx, id, func(incident *Incident, event *Event) error {
+		if incident.Status == StatusAcknowledged {
+			return ErrAcknowledged
+		}
+
+		incident.Status = StatusAcknowledged
+		incident.AcknowledgedAt = &event.Time
+		incident.AcknowledgedBy = user
+		incident.TimeToAcknowledge = event.Time.Sub(incident.OpenedAt)
+
+		event.Type = EventAcknowledged
+		event.Actor = user
+
+		return nil
+	})
+}
+
+// Assign assigns the incident to the assignee.
+func (m *Manager) Assign(ctx context.Context, id, user, assignee string) (*Incident, error) {
+	return m.update(ctx, id, func(incident *Incident, event *Event) error {
+		incident.AssignedTo = assignee
+
+		event.Type = EventAssigned
+		event.Actor = user
+		event.Message = assignee
+
+		return nil
+	})
+}
+
+// Comment adds the comment of the user to the timeline, resolved incidents included.
+func (m *Manager) Comment(ctx context.Context, id, user, message string) (*Incident, error) {
+	incident, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &Event{Type: EventComment, Time: time.Now(), Actor: user, Message: message}
+	if err = m.store.AddEvent(ctx, id, "", event); err != nil {
+		return nil, err
+	}
+
+	incident.Timeline = append(incident.Timeline, event)
+
+	return incident, nil
+}
+
+// Unacknowledged returns the open incidents nobody acknowledged yet.
+func (m *Manager) Unacknowledged(ctx context.Context) ([]*Incident, error) {
+	return m.store.FindByStatus(ctx, StatusOpen)
+}
+
+// Escalate records the step of the escalation policy notified,
+// ErrConflict if the incident isn't open anymore.
+func (m *Manager) Escalate(ctx context.Context, id string, step int, message string) error {
+	_, err := m.update(ctx, id, func(incident *Incident, event *Event) error {
+		if incident.Status != StatusOpen {
+			return ErrConflict
+		}
+
+		incident.Escalations = step
+
+		event.Type = EventEscalated
+		event.Actor = ActorOrbit
+		event.Message = message
+
+		return nil
+	})
+
+	return err
+}
+
+// Resolve resolves the incident, even if its checks are still failing.
+func (m *Manager) Resolve(ctx context.Context, id, user, message string) (*Incident, error) {
+	return m.update(ctx, id, func(incident *Incident, event *Event) error {
+		return resolve(incident, event, user, message)
+	})
+}
+
+// open creates the incident of the check, the transition is the first event.
+func (m *Manager) open(ctx context.Context, check *checker.HealthCheck, event *Event) error {
+	opened := *event
+	opened.Type = EventOpened
+
+	incident := &Incident{
+		Key:         Key(check),
+		Title:       title(check),
+		Status:      StatusOpen,
+		Node:        check.Node,
+		ServiceID:   check.ServiceID,
+		ServiceName: check.ServiceName,
+		TeamID:      check.Labels[TeamLabel],
+		CheckIDs:    []string{check.CheckID},
+		OpenedAt:    opened.Time,
+		UpdatedAt:   opened.Time,
+		Timeline:    []*Event{&opened},
+	}
+
+	if err := m.store.Create(ctx, incident); err != nil {
+		return err
+	}
+
+	m.logger.Infow("Incident opened", "incident", incident.ID, "key", incident.Key, "check", check.CheckID)
+
+	return nil
+}
+
+// update applies the change to the incident unless it is resolved,
+// and stores it if it didn't change meanwhile.
+func (m *Manager) update(ctx context.Context, id string, change func(incident *Incident, event *Event) error) (*Incident, error) {
+	incident, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident.Status == StatusResolved {
+		return nil, ErrResolved
+	}
+
+	previous := incident.Status
+	event := &Event{Time: time.Now()}
+	if err = change(incident, event); err != nil {
+		return nil, err
+	}
+
+	if err = m.store.Update(ctx, incident, previous, event); err != nil {
+		return nil, err
+	}
+
+	incident.UpdatedAt = event.Time
+	incident.Timeline = append(incident.Timeline, event)
+
+	if incident.Status != previous {
+		m.logger.Infow("Incident "+incident.Status, "incident", incident.ID, "actor", event.Actor)
+	}
+
+	return incident, nil
+}
+
+// recovered reports whether all checks of the incident are passing,
+// the checks which don't exist anymore are considered recovered.
+func (m *Manager) recovered(incident *Incident, checkID string) bool {
+	for _, id := range append(incident.CheckIDs, checkID) {
+		if check, ok := m.state.Check(id); ok && check.Status != checker.HealthPassing {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resolve(incident *Incident, event *Event, user, message string) error {
+	incident.Status = StatusResolved
+	incident.ResolvedAt = &event.Time
+	incident.ResolvedBy = user
+	incident.TimeToResolve = event.Time.Sub(incident.OpenedAt)
+
+	event.Type = EventResolved
+	event.Actor = user
+	event.Message = message
+
+	return nil
+}
+
+func title(check *checker.HealthCheck) string {
+	if check.ServiceID != "" {
+		name := check.ServiceName
+		if name == "" {
+			name = check.ServiceID
+		}
+
+		return fmt.Sprintf("Service %s is critical", name)
+	}
+
+	name := check.Name
+	if name == "" {
+		name = check.CheckID
+	}
+
+	return fmt.Sprintf("Check %s is critical", name)
+}
@@ -0,0 +1,131 @@
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+const (
+	// DefaultInterval is how often the windows are evaluated.
+	DefaultInterval = 15 * time.Second
+
+	// ReloadInterval is how often the windows are loaded from the store, so
+	// that the windows changed through the other servers of a cluster apply.
+	ReloadInterval = time.Minute
+)
+
+// Store is the source of the maintenance windows.
+type Store interface {
+	Windows(ctx context.Context) ([]*Window, error)
+}
+
+// Target is where the maintenance is applied, usually the checker.Manager.
+type Target interface {
+	SetMaintenance(checkID, reason string)
+}
+
+// Scheduler puts the checks under maintenance while a window matching them is active.
+type Scheduler struct {
+	store    Store
+	state    *checker.State
+	target   Target
+	logger   *zap.SugaredLogger
+	interval time.Duration
+
+	lock    sync.RWMutex
+	windows []*Window
+	reload  chan struct{}
+}
+
+func NewScheduler(store Store, state *checker.State, target Target, logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		state:    state,
+		target:   target,
+		logger:   logger,
+		interval: DefaultInterval,
+		reload:   make(chan struct{}, 1),
+	}
+}
+
+// Reload asks the scheduler to load the windows from the store again,
+// and apply them immediately.
+func (s *Scheduler) Reload() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Run loads and applies the windows until the context is done. The windows
+// are loaded again every ReloadInterval.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.load(ctx)
+	s.apply(time.Now())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	reload := time.NewTicker(ReloadInterval)
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-s.reload:
+			s.load(ctx)
+			s.apply(time.Now())
+		case <-reload.C:
+			s.load(ctx)
+			s.apply(time.Now())
+		case now := <-ticker.C:
+			s.apply(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Active returns the name of the first window which puts the check
+// under maintenance at the given time, and if there is any.
+func (s *Scheduler) Active(check *checker.HealthCheck, at time.Time) (string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, window := range s.windows {
+		if window.Matches(check) && window.Active(at) {
+			return window.Name, true
+		}
+	}
+
+	return "", false
+}
+
+func (s *Scheduler) load(ctx context.Context) {
+	windows, err := s.store.Windows(ctx)
+	if err != nil {
+		s.logger.Errorw("Failed to load maintenance windows", "error", err)
+		return
+	}
+
+	s.lock.Lock()
+	s.windows = windows
+	s.lock.Unlock()
+
+	s.logger.Debugw("Maintenance windows loaded.", "count", len(windows))
+}
+
+func (s *Scheduler) apply(now time.Time) {
+	for _, check := range s.state.Checks() {
+		reason := ""
+		if name, ok := s.Active(check, now); ok {
+			reason = "Under maintenance: " + name
+		}
+
+		s.target.SetMaintenance(check.CheckID, reason)
+	}
+}
@@ -0,0 +1,135 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// Window is a period during which the matching checks are under maintenance.
+// A window is either one-off, from StartsAt to EndsAt, or recurring, starting
+// at every occurrence of Cron or RRule for Duration. The occurrences are
+// computed in Location, StartsAt and EndsAt then optionally bound the recurrence.
+type Window struct {
+	ID          string
+	Name        string
+	CheckIDs    []string
+	ServiceIDs  []string
+	ServiceTags []string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	Cron        string
+	RRule       string
+	Duration    time.Duration
+	Location    *time.Location
+
+	cron  cron.Schedule
+	rrule *rrule.RRule
+}
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Compile validates the window and parses its schedule, it must be called before Active.
+func (w *Window) Compile() error {
+	if w.Location == nil {
+		w.Location = time.UTC
+	}
+
+	if len(w.CheckIDs) == 0 && len(w.ServiceIDs) == 0 && len(w.ServiceTags) == 0 {
+		return errors.New("at least one check, service or tag is required")
+	}
+
+	if !w.StartsAt.IsZero() && !w.EndsAt.IsZero() && !w.StartsAt.Before(w.EndsAt) {
+		return errors.New("starts_at must be before ends_at")
+	}
+
+	switch {
+	case w.Cron != "" && w.RRule != "":
+		return errors.New("cron and rrule cannot both be specified")
+	case w.Cron != "":
+		schedule, err := cronParser.Parse(w.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron %q: %w", w.Cron, err)
+		}
+		w.cron = schedule
+	case w.RRule != "":
+		option, err := rrule.StrToROptionInLocation(w.RRule, w.Location)
+		if err != nil {
+			return fmt.Errorf("invalid rrule %q: %w", w.RRule, err)
+		}
+
+		// The occurrences are iterated from the start of the rule, which
+		// must be recent enough to find the last one quickly.
+		if option.Dtstart.IsZero() {
+			if w.StartsAt.IsZero() {
+				return errors.New("rrule requires a DTSTART or starts_at")
+			}
+			option.Dtstart = w.StartsAt.In(w.Location)
+		}
+
+		if w.rrule, err = rrule.NewRRule(*option); err != nil {
+			return fmt.Errorf("invalid rrule %q: %w", w.RRule, err)
+		}
+	default:
+		if w.StartsAt.IsZero() || w.EndsAt.IsZero() {
+			return errors.New("starts_at and ends_at are required for one-off windows")
+		}
+		return nil
+	}
+
+	if w.Duration <= 0 {
+		return errors.New("duration must be > 0 for recurring windows")
+	}
+
+	return nil
+}
+
+// Active reports whether the window is in effect at the given time.
+func (w *Window) Active(at time.Time) bool {
+	if !w.StartsAt.IsZero() && at.Before(w.StartsAt) {
+		return false
+	}
+
+	if !w.EndsAt.IsZero() && !at.Before(w.EndsAt) {
+		return false
+	}
+
+	at = at.In(w.Location)
+
+	switch {
+	case w.cron != nil:
+		// The window is active if an occurrence started less than Duration ago.
+		next := w.cron.Next(at.Add(-w.Duration))
+		return !next.After(at)
+	case w.rrule != nil:
+		start := w.rrule.Before(at, true)
+		return !start.IsZero() && at.Before(start.Add(w.Duration))
+	default:
+		return true
+	}
+}
+
+// Matches reports whether the check is targeted by the window.
+func (w *Window) Matches(check *checker.HealthCheck) bool {
+	if slices.Contains(w.CheckIDs, check.CheckID) {
+		return true
+	}
+
+	if check.ServiceID != "" && slices.Contains(w.ServiceIDs, check.ServiceID) {
+		return true
+	}
+
+	for _, tag := range check.ServiceTags {
+		if slices.Contains(w.ServiceTags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
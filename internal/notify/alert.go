@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// Alert is the notification of a check changing status, sent to the channels.
+type Alert struct {
+	CheckID     string            `bson:"check_id" json:"check_id"`
+	CheckName   string            `bson:"check_name" json:"check_name"`
+	Node        string            `bson:"node" json:"node"`
+	ServiceID   string            `bson:"service_id" json:"service_id"`
+	ServiceName string            `bson:"service_name" json:"service_name"`
+	ServiceTags []string          `bson:"service_tags" json:"service_tags"`
+	Labels      map[string]string `bson:"labels" json:"labels"`
+	Status      string            `bson:"status" json:"status"`
+	Previous    string            `bson:"previous" json:"previous"`
+	Output      string            `bson:"output" json:"output"`
+	Time        time.Time         `bson:"time" json:"time"`
+	Duration    time.Duration     `bson:"duration" json:"duration"` // How long the check stayed in the previous status.
+	Test        bool              `bson:"test,omitempty" json:"test,omitempty"`
+}
+
+// NewAlert builds the alert of the transition, the check provides its metadata.
+func NewAlert(t *checker.Transition, check *checker.HealthCheck) *Alert {
+	alert := &Alert{
+		CheckID:  t.CheckID,
+		Status:   t.Status,
+		Previous: t.Previous,
+		Output:   t.Output,
+		Time:     t.Time,
+		Duration: t.PreviousDuration,
+	}
+
+	if check != nil {
+		alert.CheckName = check.Name
+		alert.Node = check.Node
+		alert.ServiceID = check.ServiceID
+		alert.ServiceName = check.ServiceName
+		alert.ServiceTags = check.ServiceTags
+		alert.Labels = check.Labels
+	}
+
+	return alert
+}
+
+// Label returns the value of the label used to route, group, silence and inhibit
+// the alert. The labels of the check can't override the built-in ones.
+func (a *Alert) Label(name string) string {
+	switch name {
+	case "check_id":
+		return a.CheckID
+	case "check_name":
+		return a.CheckName
+	case "node":
+		return a.Node
+	case "service_id":
+		return a.ServiceID
+	case "service":
+		return a.ServiceName
+	case "status":
+		return a.Status
+	default:
+		return a.Labels[name]
+	}
+}
+
+// LabelNames returns the names of all labels of the alert, except the status.
+func (a *Alert) LabelNames() []string {
+	names := []string{"check_id", "check_name", "node", "service_id", "service"}
+	for name := range a.Labels {
+		switch name {
+		case "check_id", "check_name", "node", "service_id", "service", "status":
+		default:
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Resolved reports whether the alert is the recovery of the check.
+func (a *Alert) Resolved() bool {
+	return a.Status == checker.HealthPassing
+}
+
+// Title is a one-line summary of the alert.
+func (a *Alert) Title() string {
+	name := a.CheckName
+	if name == "" {
+		name = a.CheckID
+	}
+
+	if a.ServiceName != "" {
+		name = fmt.Sprintf("%s (%s)", name, a.ServiceName)
+	}
+
+	if a.Resolved() {
+		return fmt.Sprintf("[RESOLVED] %s is passing", name)
+	}
+
+	return fmt.Sprintf("[%s] %s is %s", upper(a.Status), name, a.Status)
+}
+
+func upper(status string) string {
+	switch status {
+	case checker.HealthCritical:
+		return "CRITICAL"
+	case checker.HealthWarning:
+		return "WARNING"
+	default:
+		return status
+	}
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// The types of channels.
+const (
+	ChannelWebhook   = "webhook"
+	ChannelEmail     = "email"
+	ChannelSlack     = "slack"
+	ChannelPagerDuty = "pagerduty"
+)
+
+// Sender delivers the notifications through a channel, e.g. an email or a webhook.
+type Sender interface {
+	Send(ctx context.Context, notification *Notification) error
+}
+
+// Channel is a configured destination of the alerts.
+type Channel struct {
+	ID   string
+	Name string
+	Type string
+
+	// Statuses the channel is notified about, all if empty.
+	// The recoveries are notified as passing.
+	Statuses []string
+
+	Sender Sender
+}
+
+// Accepts reports whether the channel is notified about the alert.
+func (c *Channel) Accepts(alert *Alert) bool {
+	return len(c.Statuses) == 0 || slices.Contains(c.Statuses, alert.Status)
+}
+
+// Filter returns the notification with only the alerts accepted by the channel,
+// nil if there are none.
+func (c *Channel) Filter(n *Notification) *Notification {
+	var alerts []*Alert
+	for _, alert := range n.Alerts {
+		if c.Accepts(alert) {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	filtered := NewNotification(n.Receiver, n.GroupKey, n.GroupLabels, alerts)
+	filtered.Test = n.Test
+
+	return filtered
+}
+
+// ChannelStore is a source of channels, e.g. the configuration or MongoDB.
+type ChannelStore interface {
+	Channels(ctx context.Context) ([]*Channel, error)
+}
+
+// Delivery is the outcome of sending a notification to a channel.
+type Delivery struct {
+	ChannelID    string
+	ChannelType  string
+	Notification *Notification
+	Attempts     int
+	Error        string
+	Time         time.Time
+}
+
+// DeliveryLog records the deliveries, e.g. in MongoDB.
+type DeliveryLog interface {
+	LogDelivery(ctx context.Context, delivery *Delivery) error
+}
+
+// DeliveryLogs records the deliveries in all logs.
+type DeliveryLogs []DeliveryLog
+
+func (l DeliveryLogs) LogDelivery(ctx context.Context, delivery *Delivery) error {
+	var errs []error
+	for _, log := range l {
+		errs = append(errs, log.LogDelivery(ctx, delivery))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,506 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+const (
+	// DefaultAttempts is how many times the delivery of a notification is attempted.
+	DefaultAttempts = 3
+
+	// DefaultBackoff is the delay before the first retry, doubled after each attempt.
+	DefaultBackoff = 2 * time.Second
+
+	// SilenceReloadInterval is how often the silences are loaded from the store.
+	SilenceReloadInterval = time.Minute
+
+	// ChannelReloadInterval is how often the channels are loaded from the stores, so
+	// that the channels changed through the other servers of a cluster are used.
+	ChannelReloadInterval = time.Minute
+)
+
+// Dispatcher notifies the receivers whenever checks change status.
+//
+// Alerts are suppressed while a check is flapping or under maintenance. The
+// status of a check is compared with the last status alerted, rather than the
+// previous status of the transition, so that a check still failing when its
+// maintenance ends or when it stops flapping, i.e. on its settled transition,
+// is notified, and a check recovering is only notified if its failure was.
+//
+// The alerts are routed through the routing tree, and batched in groups of the
+// same route and group labels. A group is notified GroupWait after its first
+// alert, then at most every GroupInterval when its alerts change, and every
+// RepeatInterval while some are firing. Alerts muted by a silence or an
+// inhibition rule are left out of the notifications.
+//
+// In a cluster, every server routes the alerts of all checks, those of the checks
+// run by the other servers from the transitions of their Shared status, so that
+// the groups and inhibitions span the whole cluster. Only the Leader delivers
+// the notifications, the others flush their groups without delivering them, to
+// take over where the leader left off.
+type Dispatcher struct {
+	state    *checker.State
+	stores   []ChannelStore
+	log      DeliveryLog
+	logger   *zap.SugaredLogger
+	Attempts int
+	Backoff  time.Duration
+	Silences SilenceStore
+
+	// Shared are the transitions of the checks run by the other servers,
+	// and Leader reports whether the server delivers the notifications.
+	Shared *checker.Events
+	Leader func() bool
+
+	lock     sync.RWMutex
+	channels []*Channel
+
+	routingLock  sync.Mutex
+	route        *Route
+	receivers    map[string][]string
+	inhibitRules []*InhibitRule
+	silences     []*Silence
+	alerts       map[string]*Alert // The firing alert of each check.
+	groups       map[string]*group
+
+	wg sync.WaitGroup
+}
+
+func NewDispatcher(state *checker.State, log DeliveryLog, logger *zap.SugaredLogger, stores ...ChannelStore) *Dispatcher {
+	route, _ := (*RouteConfig)(nil).Build()
+
+	return &Dispatcher{
+		state:    state,
+		stores:   stores,
+		log:      log,
+		logger:   logger,
+		Attempts: DefaultAttempts,
+		Backoff:  DefaultBackoff,
+		route:    route,
+		alerts:   make(map[string]*Alert),
+		groups:   make(map[string]*group),
+	}
+}
+
+// Configure sets the receivers, routing tree and inhibition rules. The pending
+// groups are dropped, the firing alerts are routed again on their next change.
+func (d *Dispatcher) Configure(cfg *Config) error {
+	route, err := cfg.Route.Build()
+	if err != nil {
+		return err
+	}
+
+	receivers := make(map[string][]string, len(cfg.Receivers))
+	for _, receiver := range cfg.Receivers {
+		receivers[receiver.Name] = receiver.Channels
+	}
+
+	var rules []*InhibitRule
+	for _, c := range cfg.InhibitRules {
+		rule, err := c.Build()
+		if err != nil {
+			return err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	d.routingLock.Lock()
+	defer d.routingLock.Unlock()
+
+	d.route = route
+	d.receivers = receivers
+	d.inhibitRules = rules
+	d.groups = make(map[string]*group)
+
+	return nil
+}
+
+// Reload loads the channels from the stores again. The current
+// channels are kept if any store fails.
+func (d *Dispatcher) Reload(ctx context.Context) error {
+	var channels []*Channel
+	for _, store := range d.stores {
+		loaded, err := store.Channels(ctx)
+		if err != nil {
+			return err
+		}
+
+		channels = append(channels, loaded...)
+	}
+
+	d.SetChannels(channels)
+	d.logger.Debugw("Notification channels loaded.", "count", len(channels))
+
+	return nil
+}
+
+// ReloadSilences loads the silences from the store again. The current
+// silences are kept if the store fails.
+func (d *Dispatcher) ReloadSilences(ctx context.Context) error {
+	if d.Silences == nil {
+		return nil
+	}
+
+	silences, err := d.Silences.Silences(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.routingLock.Lock()
+	defer d.routingLock.Unlock()
+
+	d.silences = silences
+
+	return nil
+}
+
+// SetChannels replaces the channels the notifications are sent to.
+func (d *Dispatcher) SetChannels(channels []*Channel) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.channels = channels
+}
+
+// Channel returns the channel with the given ID.
+func (d *Dispatcher) Channel(id string) (*Channel, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	for _, channel := range d.channels {
+		if channel.ID == id {
+			return channel, true
+		}
+	}
+
+	return nil, false
+}
+
+// Run routes the alerts of the transitions and flushes the groups until the
+// context is done, then waits for the pending deliveries. The silences and
+// channels are loaded from the stores again periodically.
+func (d *Dispatcher) Run(ctx context.Context, events *checker.Events) {
+	transitions := events.Subscribe(0)
+	defer events.Unsubscribe(transitions)
+
+	// A nil channel never receives, without shared transitions.
+	var shared chan *checker.Transition
+	if d.Shared != nil {
+		shared = d.Shared.Subscribe(0)
+		defer d.Shared.Unsubscribe(shared)
+	}
+
+	if err := d.ReloadSilences(ctx); err != nil {
+		d.logger.Errorw("Failed to load the silences", "error", err)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	silences := time.NewTicker(SilenceReloadInterval)
+	defer silences.Stop()
+
+	channels := time.NewTicker(ChannelReloadInterval)
+	defer channels.Stop()
+
+	for {
+		select {
+		case t := <-transitions:
+			d.Handle(t, time.Now())
+		case t := <-shared:
+			d.Handle(t, time.Now())
+		case now := <-ticker.C:
+			notifications := d.Flush(now)
+			if d.Leader != nil && !d.Leader() {
+				continue
+			}
+
+			for _, n := range notifications {
+				d.Dispatch(ctx, n)
+			}
+		case <-silences.C:
+			if err := d.ReloadSilences(ctx); err != nil {
+				d.logger.Errorw("Failed to load the silences", "error", err)
+			}
+		case <-channels.C:
+			if err := d.Reload(ctx); err != nil {
+				d.logger.Errorw("Failed to load the notification channels", "error", err)
+			}
+		case <-ctx.Done():
+			d.wg.Wait()
+			return
+		}
+	}
+}
+
+// Handle routes the alert of the transition, if it must be notified.
+func (d *Dispatcher) Handle(t *checker.Transition, now time.Time) {
+	if t.Removed {
+		d.Remove(t, now)
+		return
+	}
+
+	if alert := d.alert(t); alert != nil {
+		d.Insert(alert, now)
+	}
+}
+
+// Insert adds the alert to the groups of its routes. A resolved alert goes to the
+// groups of the firing alert instead, in case the labels of the check changed.
+func (d *Dispatcher) Insert(alert *Alert, now time.Time) {
+	d.routingLock.Lock()
+	defer d.routingLock.Unlock()
+
+	if alert.Resolved() {
+		delete(d.alerts, alert.CheckID)
+
+		for _, g := range d.groups {
+			if _, ok := g.alerts[alert.CheckID]; ok {
+				g.insert(alert, now)
+			}
+		}
+
+		return
+	}
+
+	d.alerts[alert.CheckID] = alert
+
+	for _, route := range d.route.Match(alert) {
+		labels := route.groupLabels(alert)
+		key := groupKey(route, labels)
+
+		g, ok := d.groups[key]
+		if !ok {
+			g = newGroup(route, labels)
+			d.groups[key] = g
+		}
+
+		g.insert(alert, now)
+	}
+}
+
+// Remove forgets about the check the server stopped running. The firing alert of a
+// deleted check is resolved, the one of a check moved to another server is dropped,
+// since that server notifies the status of the check from now on. In a cluster, the
+// alert of a moved check is kept, since its status is still shared with the server.
+func (d *Dispatcher) Remove(t *checker.Transition, now time.Time) {
+	if t.Moved && d.Shared != nil {
+		return
+	}
+
+	d.routingLock.Lock()
+	alert, ok := d.alerts[t.CheckID]
+	if ok && t.Moved {
+		delete(d.alerts, t.CheckID)
+
+		for key, g := range d.groups {
+			delete(g.alerts, t.CheckID)
+			delete(g.notified, t.CheckID)
+
+			if g.empty() {
+				delete(d.groups, key)
+			}
+		}
+	}
+	d.routingLock.Unlock()
+
+	if !ok || t.Moved {
+		return
+	}
+
+	resolved := *alert
+	resolved.Status = checker.HealthPassing
+	resolved.Previous = alert.Status
+	resolved.Output = "The check was removed."
+	resolved.Time = t.Time
+	resolved.Duration = t.Time.Sub(alert.Time)
+
+	d.Insert(&resolved, now)
+}
+
+// Flush returns the notifications of the groups due at the given time.
+func (d *Dispatcher) Flush(now time.Time) []*Notification {
+	d.routingLock.Lock()
+	defer d.routingLock.Unlock()
+
+	muted := func(alert *Alert) bool {
+		return d.muted(alert, now)
+	}
+
+	var notifications []*Notification
+	for key, g := range d.groups {
+		if !g.due(now) {
+			continue
+		}
+
+		if alerts := g.flush(now, muted); alerts != nil {
+			notifications = append(notifications, NewNotification(g.route.Receiver, g.key, g.labels, alerts))
+		}
+
+		if g.empty() {
+			delete(d.groups, key)
+		}
+	}
+
+	return notifications
+}
+
+// Dispatch sends the notification to the channels of its receiver, in the background.
+func (d *Dispatcher) Dispatch(ctx context.Context, notification *Notification) {
+	for _, channel := range d.receiverChannels(notification.Receiver) {
+		filtered := channel.Filter(notification)
+		if filtered == nil {
+			continue
+		}
+
+		d.wg.Add(1)
+		go func(channel *Channel) {
+			defer d.wg.Done()
+			_ = d.Deliver(ctx, channel, filtered)
+		}(channel)
+	}
+}
+
+// Deliver sends the notification to the channel, retrying with an exponential
+// backoff, and records the delivery.
+func (d *Dispatcher) Deliver(ctx context.Context, channel *Channel, notification *Notification) error {
+	attempts := max(d.Attempts, 1)
+	backoff := d.Backoff
+
+	delivery := &Delivery{
+		ChannelID:    channel.ID,
+		ChannelType:  channel.Type,
+		Notification: notification,
+	}
+
+	var err error
+	for delivery.Attempts < attempts {
+		delivery.Attempts++
+		if err = channel.Sender.Send(ctx, notification); err == nil {
+			break
+		}
+
+		d.logger.Warnw("Failed to send notification", "channel", channel.ID, "group", notification.GroupKey, "attempt", delivery.Attempts, "error", err)
+		if delivery.Attempts == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	delivery.Time = time.Now()
+	if err != nil {
+		delivery.Error = err.Error()
+		d.logger.Errorw("Notification not delivered", "channel", channel.ID, "group", notification.GroupKey, "attempts", delivery.Attempts, "error", err)
+	} else {
+		d.logger.Infow("Notification delivered", "channel", channel.ID, "group", notification.GroupKey, "status", notification.Status, "alerts", len(notification.Alerts))
+	}
+
+	if d.log != nil {
+		// The delivery is recorded even if the server is shutting down.
+		logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if logErr := d.log.LogDelivery(logCtx, delivery); logErr != nil {
+			d.logger.Errorw("Failed to record the notification delivery", "channel", channel.ID, "error", logErr)
+		}
+	}
+
+	return err
+}
+
+// receiverChannels returns the channels of the receiver. A receiver which isn't
+// configured is the ID of a channel, and all channels receive the notifications
+// of the routes without receiver.
+func (d *Dispatcher) receiverChannels(receiver string) []*Channel {
+	d.routingLock.Lock()
+	ids, ok := d.receivers[receiver]
+	d.routingLock.Unlock()
+
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	if receiver == "" {
+		return d.channels
+	}
+
+	if !ok {
+		ids = []string{receiver}
+	}
+
+	var channels []*Channel
+	for _, id := range ids {
+		for _, channel := range d.channels {
+			if channel.ID == id {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	return channels
+}
+
+// muted reports whether the firing alert is left out of the notifications, because
+// its check is under maintenance, or the alert is silenced or inhibited.
+func (d *Dispatcher) muted(alert *Alert, now time.Time) bool {
+	if check, ok := d.state.Check(alert.CheckID); ok && check.Status == checker.HealthMaint {
+		return true
+	}
+
+	for _, silence := range d.silences {
+		if silence.Mutes(alert, now) {
+			return true
+		}
+	}
+
+	for _, rule := range d.inhibitRules {
+		for _, source := range d.alerts {
+			if rule.Inhibits(source, alert) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// alert returns the alert of the transition, nil if it must not be notified.
+func (d *Dispatcher) alert(t *checker.Transition) *Alert {
+	if t.Flapping || t.Status == checker.HealthMaint {
+		return nil
+	}
+
+	d.routingLock.Lock()
+	// Checks are considered passing until alerted otherwise.
+	previous := checker.HealthPassing
+	if alert, ok := d.alerts[t.CheckID]; ok {
+		previous = alert.Status
+	}
+	d.routingLock.Unlock()
+
+	if previous == t.Status {
+		return nil
+	}
+
+	check, _ := d.state.Check(t.CheckID)
+	alert := NewAlert(t, check)
+	alert.Previous = previous
+
+	return alert
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// testNotification returns a firing notification of a critical check.
+func testNotification() *Notification {
+	alert := &Alert{
+		CheckID:     "web",
+		CheckName:   "HTTP",
+		Node:        "node-1",
+		ServiceID:   "web-1",
+		ServiceName: "web",
+		Status:      checker.HealthCritical,
+		Previous:    checker.HealthPassing,
+		Output:      "connection refused",
+		Time:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	return NewNotification("ops", "{}:{service=\"web\"}", map[string]string{"service": "web"}, []*Alert{alert})
+}
+
+type deliveryRecorder struct {
+	lock       sync.Mutex
+	deliveries []*Delivery
+}
+
+func (r *deliveryRecorder) LogDelivery(ctx context.Context, delivery *Delivery) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	var lock sync.Mutex
+	var attempts []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	log := &deliveryRecorder{}
+	d := NewDispatcher(checker.NewState(), log, zap.NewNop().Sugar())
+	d.Attempts = 3
+	d.Backoff = 20 * time.Millisecond
+
+	channel := &Channel{ID: "ops", Type: ChannelWebhook, Sender: &Webhook{URL: server.URL}}
+	if err := d.Deliver(context.Background(), channel, testNotification()); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(attempts))
+	}
+
+	// The backoff doubles after each attempt.
+	if delay := attempts[1].Sub(attempts[0]); delay < 20*time.Millisecond {
+		t.Errorf("first retry after %s, want >= 20ms", delay)
+	}
+	if delay := attempts[2].Sub(attempts[1]); delay < 40*time.Millisecond {
+		t.Errorf("second retry after %s, want >= 40ms", delay)
+	}
+
+	if len(log.deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(log.deliveries))
+	}
+	if delivery := log.deliveries[0]; delivery.Attempts != 3 || delivery.Error != "" {
+		t.Errorf("delivery = %+v, want 3 attempts without error", delivery)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	log := &deliveryRecorder{}
+	d := NewDispatcher(checker.NewState(), log, zap.NewNop().Sugar())
+	d.Attempts = 2
+	d.Backoff = time.Millisecond
+
+	channel := &Channel{ID: "ops", Type: ChannelWebhook, Sender: &Webhook{URL: server.URL}}
+	if err := d.Deliver(context.Background(), channel, testNotification()); err == nil {
+		t.Fatal("Deliver() succeeded, want an error")
+	}
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	if len(log.deliveries) != 1 || log.deliveries[0].Error == "" {
+		t.Errorf("deliveries = %+v, want one failed delivery", log.deliveries)
+	}
+}
+
+func TestDeliverStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(checker.NewState(), nil, zap.NewNop().Sugar())
+	d.Attempts = 5
+	d.Backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	channel := &Channel{ID: "ops", Type: ChannelWebhook, Sender: &Webhook{URL: server.URL}}
+	if err := d.Deliver(ctx, channel, testNotification()); err != context.DeadlineExceeded {
+		t.Errorf("Deliver() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// sharedStatuses is the StatusStore of the checks run by another server.
+type sharedStatuses map[string]*checker.HealthCheck
+
+func (s sharedStatuses) SaveStatuses(ctx context.Context, checks []*checker.HealthCheck) error {
+	return nil
+}
+
+func (s sharedStatuses) Statuses(ctx context.Context, checkIDs []string) ([]*checker.HealthCheck, error) {
+	var statuses []*checker.HealthCheck
+	for _, checkID := range checkIDs {
+		if status, ok := s[checkID]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses, nil
+}
+
+// TestSharedInhibition checks that the alert of a check run by another server
+// of the cluster inhibits the alerts of the checks run by the server.
+func TestSharedInhibition(t *testing.T) {
+	state := checker.NewState()
+	manager := checker.NewManager(state, nil, zap.NewNop().Sugar())
+	manager.SetOwner(func(checkID string) bool { return false })
+	defer manager.Stop()
+
+	ping := &checker.HealthCheck{CheckID: "ping", Name: "ping", Node: "node-1", Status: checker.HealthPassing}
+	if err := manager.Add(ping, &checker.CheckType{TTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	state.AddCheck(&checker.HealthCheck{CheckID: "web", Name: "HTTP", Node: "node-1", ServiceName: "web"})
+
+	d := NewDispatcher(state, nil, zap.NewNop().Sugar())
+	d.Shared = manager.SharedEvents()
+	err := d.Configure(&Config{
+		Route:        &RouteConfig{Receiver: "ops", GroupBy: []string{"node"}},
+		InhibitRules: []*InhibitRuleConfig{{SourceMatchers: []string{"check_name=ping"}, TargetMatchers: []string{"service=web"}, Equal: []string{"node"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := d.Shared.Subscribe(0)
+	defer d.Shared.Unsubscribe(shared)
+
+	store := sharedStatuses{"ping": {CheckID: "ping", Status: checker.HealthCritical, Output: "unreachable"}}
+	if err = manager.ExchangeStatus(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	select {
+	case transition := <-shared:
+		d.Handle(transition, now)
+	case <-time.After(time.Second):
+		t.Fatal("the shared status of the check wasn't published")
+	}
+
+	d.Handle(&checker.Transition{CheckID: "web", Previous: checker.HealthPassing, Status: checker.HealthCritical, Time: now}, now)
+
+	notifications := d.Flush(now.Add(time.Minute))
+	if len(notifications) != 1 {
+		t.Fatalf("notifications = %d, want the single group of the node", len(notifications))
+	}
+
+	alerts := notifications[0].Alerts
+	if len(alerts) != 1 || alerts[0].CheckID != "ping" {
+		t.Errorf("alerts = %+v, want the alert of ping only, web is inhibited", alerts)
+	}
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends the notification by email through an SMTP server. STARTTLS is used when
+// the server supports it, or implicit TLS if TLS is set, e.g. on port 465.
+type Email struct {
+	Host          string
+	Port          int
+	Username      string
+	Password      string
+	From          string
+	To            []string
+	TLS           bool
+	TLSSkipVerify bool
+}
+
+func (e *Email) Send(ctx context.Context, notification *Notification) error {
+	if len(e.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	port := e.Port
+	if port == 0 {
+		port = 25
+	}
+
+	address := net.JoinHostPort(e.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: e.Host, InsecureSkipVerify: e.TLSSkipVerify}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if e.TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !e.TLS {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if e.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(e.From); err != nil {
+		return err
+	}
+
+	for _, to := range e.To {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = writer.Write(e.message(notification)); err != nil {
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (e *Email) message(notification *Notification) []byte {
+	var body bytes.Buffer
+	for i, alert := range notification.Alerts {
+		if len(notification.Alerts) > 1 {
+			if i > 0 {
+				body.WriteString("\r\n")
+			}
+			fmt.Fprintf(&body, "%s\r\n\r\n", alert.Title())
+		}
+
+		fmt.Fprintf(&body, "Check: %s\r\n", alert.CheckID)
+		if alert.CheckName != "" {
+			fmt.Fprintf(&body, "Name: %s\r\n", alert.CheckName)
+		}
+		if alert.ServiceName != "" {
+			fmt.Fprintf(&body, "Service: %s\r\n", alert.ServiceName)
+		}
+		if alert.Node != "" {
+			fmt.Fprintf(&body, "Node: %s\r\n", alert.Node)
+		}
+		fmt.Fprintf(&body, "Status: %s -> %s\r\n", alert.Previous, alert.Status)
+		fmt.Fprintf(&body, "Time: %s\r\n", alert.Time.Format(time.RFC1123Z))
+		if alert.Duration > 0 {
+			fmt.Fprintf(&body, "Previously %s for: %s\r\n", alert.Previous, alert.Duration.Round(time.Second))
+		}
+		fmt.Fprintf(&body, "\r\n%s\r\n", strings.ReplaceAll(alert.Output, "\n", "\r\n"))
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Title()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes()
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpServer is a minimal SMTP server accepting one message.
+type smtpServer struct {
+	listener net.Listener
+	done     chan struct{}
+
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &smtpServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = strings.TrimPrefix(line, "AUTH PLAIN ")
+			reply("235 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	server := newSMTPServer(t)
+	defer server.listener.Close()
+
+	email := &Email{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "orbit",
+		Password: "secret",
+		From:     "orbit@example.com",
+		To:       []string{"ops@example.com", "dba@example.com"},
+	}
+
+	if err := email.Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	<-server.done
+
+	auth, err := base64.StdEncoding.DecodeString(server.auth)
+	if err != nil || string(auth) != "\x00orbit\x00secret" {
+		t.Errorf("auth = %q, want the plain credentials", auth)
+	}
+
+	if server.from != "MAIL FROM:<orbit@example.com>" {
+		t.Errorf("from = %q", server.from)
+	}
+
+	if len(server.to) != 2 || server.to[0] != "RCPT TO:<ops@example.com>" || server.to[1] != "RCPT TO:<dba@example.com>" {
+		t.Errorf("to = %q", server.to)
+	}
+
+	for _, want := range []string{
+		"To: ops@example.com, dba@example.com\r\n",
+		"Subject: [CRITICAL] HTTP (web) is critical\r\n",
+		"Check: web\r\n",
+		"Status: passing -> critical\r\n",
+		"\r\nconnection refused\r\n",
+	} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, server.data)
+		}
+	}
+}
+
+func TestEmailRejected(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte("554 No SMTP service here\r\n"))
+	}()
+
+	email := &Email{
+		Host: "127.0.0.1",
+		Port: listener.Addr().(*net.TCPAddr).Port,
+		From: "orbit@example.com",
+		To:   []string{"ops@example.com"},
+	}
+
+	err = email.Send(context.Background(), testNotification())
+	if err == nil || !strings.Contains(err.Error(), "554") {
+		t.Errorf("Send() error = %v, want the rejection of the server", err)
+	}
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// group batches the alerts of a route sharing the same group labels.
+type group struct {
+	key    string
+	route  *Route
+	labels map[string]string
+
+	// alerts is the latest alert of each check of the group.
+	alerts map[string]*Alert
+
+	// notified is the status of the firing alerts of the last notification.
+	notified map[string]string
+
+	// next is when the group is flushed, zero if it isn't scheduled.
+	next      time.Time
+	lastFlush time.Time
+}
+
+func newGroup(route *Route, labels map[string]string) *group {
+	return &group{
+		key:      groupKey(route, labels),
+		route:    route,
+		labels:   labels,
+		alerts:   make(map[string]*Alert),
+		notified: make(map[string]string),
+	}
+}
+
+// insert adds the alert to the group, and schedules the flush of the group: after
+// GroupWait for a new group, and GroupInterval after the last flush otherwise.
+func (g *group) insert(alert *Alert, now time.Time) {
+	g.alerts[alert.CheckID] = alert
+
+	if !g.next.IsZero() {
+		return
+	}
+
+	if g.lastFlush.IsZero() {
+		g.next = now.Add(g.route.GroupWait)
+	} else {
+		g.next = g.lastFlush.Add(g.route.GroupInterval)
+	}
+}
+
+// due reports whether the group must be flushed.
+func (g *group) due(now time.Time) bool {
+	return !g.next.IsZero() && !now.Before(g.next)
+}
+
+// flush returns the alerts to notify, nil if there is nothing new and the
+// notification doesn't need to be repeated yet. The firing alerts are skipped if
+// muted, the resolved ones only notified if they were notified while firing.
+func (g *group) flush(now time.Time, muted func(alert *Alert) bool) []*Alert {
+	var alerts []*Alert
+	firing := make(map[string]string)
+	changed := false
+
+	for _, id := range g.checkIDs() {
+		alert := g.alerts[id]
+		if alert.Resolved() {
+			if _, ok := g.notified[id]; ok {
+				alerts = append(alerts, alert)
+				changed = true
+			}
+
+			// The resolved alerts leave the group once flushed.
+			delete(g.alerts, id)
+			continue
+		}
+
+		if muted(alert) {
+			continue
+		}
+
+		alerts = append(alerts, alert)
+		firing[id] = alert.Status
+		if g.notified[id] != alert.Status {
+			changed = true
+		}
+	}
+
+	if len(firing) != len(g.notified) {
+		changed = true
+	}
+
+	repeat := len(firing) > 0 && g.route.RepeatInterval > 0 && !now.Before(g.lastFlush.Add(g.route.RepeatInterval))
+
+	// The firing alerts are evaluated again every group interval,
+	// e.g. to notify them once their silence expires.
+	g.next = time.Time{}
+	if len(g.alerts) > 0 {
+		g.next = now.Add(max(g.route.GroupInterval, time.Second))
+	}
+
+	if len(alerts) == 0 || (!changed && !repeat) {
+		return nil
+	}
+
+	g.notified = firing
+	g.lastFlush = now
+
+	return alerts
+}
+
+// empty reports whether the group can be forgotten.
+func (g *group) empty() bool {
+	return len(g.alerts) == 0
+}
+
+func (g *group) checkIDs() []string {
+	ids := make([]string, 0, len(g.alerts))
+	for id := range g.alerts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// groupKey identifies the group of the route with the given labels.
+func groupKey(route *Route, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+
+	return route.id + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+var epoch = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+func firing(checkID, status string) *Alert {
+	return &Alert{CheckID: checkID, Status: status, Previous: checker.HealthPassing}
+}
+
+func resolved(checkID string) *Alert {
+	return &Alert{CheckID: checkID, Status: checker.HealthPassing, Previous: checker.HealthCritical}
+}
+
+func TestGroupFlush(t *testing.T) {
+	route := &Route{GroupWait: 30 * time.Second, GroupInterval: 5 * time.Minute, RepeatInterval: time.Hour}
+
+	// Each step inserts the alerts, if any, then flushes the group if it is due.
+	type step struct {
+		at       int
+		insert   []*Alert
+		muted    string
+		notified []string // The checks of the notification, nil if nothing is notified.
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "group_wait batches the first alerts",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical)}},
+				{at: 10, insert: []*Alert{firing("b", checker.HealthWarning)}},
+				{at: 29},
+				{at: 30, notified: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "group_interval delays the changes",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical)}},
+				{at: 30, notified: []string{"a"}},
+				{at: 60, insert: []*Alert{firing("b", checker.HealthCritical)}},
+				{at: 329},
+				{at: 330, notified: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "unchanged alerts are not notified before repeat_interval",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical)}},
+				{at: 30, notified: []string{"a"}},
+				{at: 330},
+				{at: 3300},
+				{at: 3630, notified: []string{"a"}},
+			},
+		},
+		{
+			name: "status change of a firing alert",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthWarning)}},
+				{at: 30, notified: []string{"a"}},
+				{at: 40, insert: []*Alert{firing("a", checker.HealthCritical)}},
+				{at: 330, notified: []string{"a"}},
+			},
+		},
+		{
+			name: "resolved alert notified once",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical), firing("b", checker.HealthCritical)}},
+				{at: 30, notified: []string{"a", "b"}},
+				{at: 40, insert: []*Alert{resolved("a")}},
+				{at: 330, notified: []string{"a", "b"}},
+				{at: 630},
+			},
+		},
+		{
+			name: "resolved alert never notified while firing",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical)}},
+				{at: 10, insert: []*Alert{resolved("a")}},
+				{at: 30},
+			},
+		},
+		{
+			name: "muted alert notified once unmuted",
+			steps: []step{
+				{at: 0, insert: []*Alert{firing("a", checker.HealthCritical)}, muted: "a"},
+				{at: 30, muted: "a"},
+				{at: 330, notified: []string{"a"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGroup(route, map[string]string{})
+
+			for _, s := range tt.steps {
+				for _, alert := range s.insert {
+					g.insert(alert, at(s.at))
+				}
+
+				if !g.due(at(s.at)) {
+					if s.notified != nil {
+						t.Fatalf("at %ds: the group isn't due, want %v notified", s.at, s.notified)
+					}
+					continue
+				}
+
+				alerts := g.flush(at(s.at), func(alert *Alert) bool { return alert.CheckID == s.muted })
+
+				var notified []string
+				for _, alert := range alerts {
+					notified = append(notified, alert.CheckID)
+				}
+
+				if fmt.Sprint(notified) != fmt.Sprint(s.notified) {
+					t.Fatalf("at %ds: notified %v, want %v", s.at, notified, s.notified)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupWithoutRepeat(t *testing.T) {
+	g := newGroup(&Route{GroupInterval: time.Minute}, map[string]string{})
+	g.insert(firing("a", checker.HealthCritical), at(0))
+
+	if alerts := g.flush(at(0), func(*Alert) bool { return false }); len(alerts) != 1 {
+		t.Fatalf("first flush notified %d alerts, want 1", len(alerts))
+	}
+
+	for i := 1; i <= 24*60; i++ {
+		if alerts := g.flush(at(i*60), func(*Alert) bool { return false }); alerts != nil {
+			t.Fatalf("the alert was repeated after %d minutes without repeat_interval", i)
+		}
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	route := &Route{id: "/0/"}
+
+	a := groupKey(route, map[string]string{"service": "web", "node": "node-1"})
+	b := groupKey(route, map[string]string{"node": "node-1", "service": "web"})
+	if a != b || a != `/0/{node="node-1",service="web"}` {
+		t.Errorf("groupKey() = %s and %s, want the labels sorted", a, b)
+	}
+}
@@ -0,0 +1,45 @@
+package notify
+
+// InhibitRule mutes the alerts matching the target matchers while an alert
+// matching the source matchers is firing, and both have the same values for
+// the Equal labels. E.g. the alerts of the services of a node whose own
+// checks are critical.
+type InhibitRule struct {
+	SourceMatchers []*Matcher
+	TargetMatchers []*Matcher
+	Equal          []string
+}
+
+// Build returns the inhibition rule.
+func (c *InhibitRuleConfig) Build() (*InhibitRule, error) {
+	source, err := ParseMatchers(c.SourceMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := ParseMatchers(c.TargetMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InhibitRule{SourceMatchers: source, TargetMatchers: target, Equal: c.Equal}, nil
+}
+
+// Inhibits reports whether the firing source alert inhibits the target alert.
+func (r *InhibitRule) Inhibits(source, target *Alert) bool {
+	if source.CheckID == target.CheckID || source.Resolved() {
+		return false
+	}
+
+	if !matchAll(r.TargetMatchers, target) || !matchAll(r.SourceMatchers, source) {
+		return false
+	}
+
+	for _, name := range r.Equal {
+		if source.Label(name) != target.Label(name) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+func TestInhibits(t *testing.T) {
+	rule, err := (&InhibitRuleConfig{
+		SourceMatchers: []string{"check_name=ping"},
+		TargetMatchers: []string{"service!=", "status=~critical|warning"},
+		Equal:          []string{"node", "dc"},
+	}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := func(value string) map[string]string {
+		return map[string]string{"dc": value}
+	}
+
+	source := &Alert{CheckID: "ping", CheckName: "ping", Node: "node-1", Status: checker.HealthCritical, Labels: dc("eu")}
+
+	tests := []struct {
+		name     string
+		source   *Alert
+		target   *Alert
+		inhibits bool
+	}{
+		{
+			name:     "equal labels",
+			source:   source,
+			target:   &Alert{CheckID: "web", Node: "node-1", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("eu")},
+			inhibits: true,
+		},
+		{
+			name:   "different node",
+			source: source,
+			target: &Alert{CheckID: "web", Node: "node-2", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("eu")},
+		},
+		{
+			name:   "different label",
+			source: source,
+			target: &Alert{CheckID: "web", Node: "node-1", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("us")},
+		},
+		{
+			name:     "label missing on both",
+			source:   &Alert{CheckID: "ping", CheckName: "ping", Node: "node-1", Status: checker.HealthCritical},
+			target:   &Alert{CheckID: "web", Node: "node-1", ServiceName: "web", Status: checker.HealthWarning},
+			inhibits: true,
+		},
+		{
+			name:   "target not matching",
+			source: source,
+			target: &Alert{CheckID: "disk", Node: "node-1", Status: checker.HealthCritical, Labels: dc("eu")},
+		},
+		{
+			name:   "source not matching",
+			source: &Alert{CheckID: "load", CheckName: "load", Node: "node-1", Status: checker.HealthCritical, Labels: dc("eu")},
+			target: &Alert{CheckID: "web", Node: "node-1", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("eu")},
+		},
+		{
+			name:   "source resolved",
+			source: &Alert{CheckID: "ping", CheckName: "ping", Node: "node-1", Status: checker.HealthPassing, Labels: dc("eu")},
+			target: &Alert{CheckID: "web", Node: "node-1", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("eu")},
+		},
+		{
+			name:   "alert inhibiting itself",
+			source: source,
+			target: &Alert{CheckID: "ping", CheckName: "ping", Node: "node-1", ServiceName: "web", Status: checker.HealthCritical, Labels: dc("eu")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if inhibits := rule.Inhibits(tt.source, tt.target); inhibits != tt.inhibits {
+				t.Errorf("Inhibits() = %v, want %v", inhibits, tt.inhibits)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The types of matchers.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// Matcher matches a label of the alerts, like the matchers of Alertmanager.
+// The "tag" label is special: it matches if any of the tags of the service
+// matches, and negative matchers require that none does.
+type Matcher struct {
+	Name  string
+	Type  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// ParseMatcher parses a matcher such as `team="db"`, `service=~"web|api"` or `status!=warning`.
+func ParseMatcher(text string) (*Matcher, error) {
+	index := strings.IndexAny(text, "=!")
+	if index <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q", text)
+	}
+
+	m := &Matcher{Name: strings.TrimSpace(text[:index])}
+
+	rest := text[index:]
+	for _, t := range []string{MatchRegexp, MatchNotRegexp, MatchNotEqual, MatchEqual} {
+		if strings.HasPrefix(rest, t) {
+			m.Type = t
+			rest = rest[len(t):]
+			break
+		}
+	}
+
+	if m.Type == "" {
+		return nil, fmt.Errorf("invalid matcher %q", text)
+	}
+
+	m.Value = strings.TrimSpace(rest)
+	if strings.HasPrefix(m.Value, `"`) {
+		value, err := strconv.Unquote(m.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", text, err)
+		}
+		m.Value = value
+	}
+
+	if m.Type == MatchRegexp || m.Type == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", text, err)
+		}
+		m.re = re
+	}
+
+	return m, nil
+}
+
+// ParseMatchers parses all matchers.
+func ParseMatchers(texts []string) ([]*Matcher, error) {
+	matchers := make([]*Matcher, 0, len(texts))
+	for _, text := range texts {
+		m, err := ParseMatcher(text)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
+
+// Matches reports whether the label of the alert matches.
+func (m *Matcher) Matches(alert *Alert) bool {
+	values := []string{alert.Label(m.Name)}
+	if m.Name == "tag" {
+		values = alert.ServiceTags
+		if len(values) == 0 {
+			values = []string{""}
+		}
+	}
+
+	switch m.Type {
+	case MatchNotEqual, MatchNotRegexp:
+		for _, value := range values {
+			if !m.matches(value) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, value := range values {
+			if m.matches(value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (m *Matcher) matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// matchAll reports whether all matchers match the alert.
+func matchAll(matchers []*Matcher, alert *Alert) bool {
+	for _, m := range matchers {
+		if !m.Matches(alert) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package notify
+
+import "testing"
+
+func TestParseMatcher(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		invalid bool
+	}{
+		{text: `team="db"`, want: `team="db"`},
+		{text: `team = db`, want: `team="db"`},
+		{text: `status!=warning`, want: `status!="warning"`},
+		{text: `service=~"web|api"`, want: `service=~"web|api"`},
+		{text: `service!~api-.*`, want: `service!~"api-.*"`},
+		{text: `team=""`, want: `team=""`},
+		{text: `note="a \"quoted\" value"`, want: `note="a \"quoted\" value"`},
+		{text: `team`, invalid: true},
+		{text: `=db`, invalid: true},
+		{text: `team!db`, invalid: true},
+		{text: `team="db`, invalid: true},
+		{text: `service=~"web(`, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := ParseMatcher(tt.text)
+			if tt.invalid {
+				if err == nil {
+					t.Errorf("ParseMatcher() = %s, want an error", m)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseMatcher() error = %v", err)
+			}
+
+			if m.String() != tt.want {
+				t.Errorf("ParseMatcher() = %s, want %s", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	alert := &Alert{CheckID: "web-http", ServiceName: "web", ServiceTags: []string{"primary", "eu"}, Labels: map[string]string{"team": "ops"}}
+	untagged := &Alert{CheckID: "ping"}
+
+	tests := []struct {
+		matcher string
+		alert   *Alert
+		matches bool
+	}{
+		{`team=ops`, alert, true},
+		{`team=db`, alert, false},
+		{`team!=db`, alert, true},
+		{`region=""`, alert, true},
+		{`service=~"web|api"`, alert, true},
+		{`service=~"we"`, alert, false}, // Anchored.
+		{`service!~"api.*"`, alert, true},
+		{`tag=eu`, alert, true},
+		{`tag!=eu`, alert, false},
+		{`tag=~"prim.*"`, alert, true},
+		{`tag!~"us|asia"`, alert, true},
+		{`tag=""`, untagged, true},
+		{`tag!=eu`, untagged, true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMatcher(tt.matcher)
+		if err != nil {
+			t.Fatalf("ParseMatcher(%s) error = %v", tt.matcher, err)
+		}
+
+		if matches := m.Matches(tt.alert); matches != tt.matches {
+			t.Errorf("%s matches %s = %v, want %v", tt.matcher, tt.alert.CheckID, matches, tt.matches)
+		}
+	}
+}
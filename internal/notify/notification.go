@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The statuses of a notification.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Notification is a group of alerts sent together to the channels of a receiver.
+type Notification struct {
+	Receiver    string            `bson:"receiver" json:"receiver"`
+	GroupKey    string            `bson:"group_key" json:"group_key"`
+	GroupLabels map[string]string `bson:"group_labels" json:"group_labels"`
+	Status      string            `bson:"status" json:"status"`
+	Alerts      []*Alert          `bson:"alerts" json:"alerts"`
+	Test        bool              `bson:"test,omitempty" json:"test,omitempty"`
+}
+
+// NewNotification builds the notification of the alerts, it is firing
+// as long as one of the alerts is.
+func NewNotification(receiver, groupKey string, groupLabels map[string]string, alerts []*Alert) *Notification {
+	n := &Notification{
+		Receiver:    receiver,
+		GroupKey:    groupKey,
+		GroupLabels: groupLabels,
+		Status:      StatusResolved,
+		Alerts:      alerts,
+	}
+
+	for _, alert := range alerts {
+		if !alert.Resolved() {
+			n.Status = StatusFiring
+		}
+	}
+
+	return n
+}
+
+// Firing returns the alerts which are not resolved.
+func (n *Notification) Firing() []*Alert {
+	var alerts []*Alert
+	for _, alert := range n.Alerts {
+		if !alert.Resolved() {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts
+}
+
+// Title is a one-line summary of the notification.
+func (n *Notification) Title() string {
+	if len(n.Alerts) == 1 {
+		return n.Alerts[0].Title()
+	}
+
+	status := strings.ToUpper(n.Status)
+	if n.Status == StatusFiring {
+		status = fmt.Sprintf("%s:%d", status, len(n.Firing()))
+	}
+
+	names := make([]string, 0, len(n.GroupLabels))
+	for name := range n.GroupLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, fmt.Sprintf("%s=%s", name, n.GroupLabels[name]))
+	}
+
+	if len(labels) == 0 {
+		return fmt.Sprintf("[%s] %d checks changed status", status, len(n.Alerts))
+	}
+
+	return fmt.Sprintf("[%s] %s", status, strings.Join(labels, " "))
+}
+
+// CheckIDs returns the IDs of the checks of the alerts.
+func (n *Notification) CheckIDs() []string {
+	ids := make([]string, 0, len(n.Alerts))
+	for _, alert := range n.Alerts {
+		ids = append(ids, alert.CheckID)
+	}
+
+	return ids
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty sends the notification as a PagerDuty Events API v2 event. The group
+// key is the deduplication key, so that the incident triggered by the group is
+// resolved once all its alerts are.
+type PagerDuty struct {
+	URL        string
+	RoutingKey string
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string        `json:"summary"`
+	Source        string        `json:"source"`
+	Severity      string        `json:"severity"`
+	Timestamp     string        `json:"timestamp"`
+	Component     string        `json:"component,omitempty"`
+	Group         string        `json:"group,omitempty"`
+	Class         string        `json:"class,omitempty"`
+	CustomDetails *Notification `json:"custom_details"`
+}
+
+func (p *PagerDuty) Send(ctx context.Context, notification *Notification) error {
+	event := &pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    notification.GroupKey,
+	}
+
+	if notification.Status == StatusResolved {
+		event.EventAction = "resolve"
+	} else {
+		// The event describes the most severe of the firing alerts.
+		var alert *Alert
+		for _, firing := range notification.Firing() {
+			if alert == nil || (firing.Status == checker.HealthCritical && alert.Status != checker.HealthCritical) {
+				alert = firing
+			}
+		}
+
+		source := alert.Node
+		if source == "" {
+			source = "orbit"
+		}
+
+		event.Payload = &pagerDutyPayload{
+			Summary:       notification.Title(),
+			Source:        source,
+			Severity:      pagerDutySeverity(alert.Status),
+			Timestamp:     alert.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+			Component:     alert.ServiceName,
+			Group:         alert.ServiceID,
+			Class:         alert.CheckID,
+			CustomDetails: notification,
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	url := p.URL
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+
+	return postJSON(ctx, url, body, nil)
+}
+
+func pagerDutySeverity(status string) string {
+	switch status {
+	case checker.HealthCritical:
+		return "critical"
+	case checker.HealthWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
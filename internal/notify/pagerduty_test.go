@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagerDuty(t *testing.T) {
+	var events []*pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := &pagerDutyEvent{}
+		if err := json.NewDecoder(r.Body).Decode(event); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	pd := &PagerDuty{URL: server.URL, RoutingKey: "key"}
+
+	firing := testNotification()
+	if err := pd.Send(context.Background(), firing); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	resolved := *firing.Alerts[0]
+	resolved.Status, resolved.Previous = "passing", "critical"
+	if err := pd.Send(context.Background(), NewNotification(firing.Receiver, firing.GroupKey, firing.GroupLabels, []*Alert{&resolved})); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2", len(events))
+	}
+
+	trigger := events[0]
+	if trigger.RoutingKey != "key" || trigger.EventAction != "trigger" || trigger.DedupKey != firing.GroupKey {
+		t.Errorf("trigger = %+v", trigger)
+	}
+
+	if p := trigger.Payload; p == nil || p.Severity != "critical" || p.Source != "node-1" || p.Component != "web" ||
+		p.Class != "web" || p.Timestamp != "2024-05-01T12:00:00.000Z" {
+		t.Errorf("payload = %+v", trigger.Payload)
+	}
+
+	// The incident is resolved with the same deduplication key.
+	resolve := events[1]
+	if resolve.EventAction != "resolve" || resolve.DedupKey != firing.GroupKey || resolve.Payload != nil {
+		t.Errorf("resolve = %+v", resolve)
+	}
+}
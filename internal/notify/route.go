@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The timings of a route when not configured, like Alertmanager.
+const (
+	DefaultGroupWait      = 30 * time.Second
+	DefaultGroupInterval  = 5 * time.Minute
+	DefaultRepeatInterval = 4 * time.Hour
+)
+
+// Route is a node of the routing tree, it picks the receiver of the alerts
+// matching its matchers and how they are grouped. Like Alertmanager, an alert
+// is routed to the first matching child route, to the following matching ones
+// as well if the route has Continue set, or to the route itself if no child matches.
+type Route struct {
+	Receiver       string
+	Matchers       []*Matcher
+	Continue       bool
+	GroupBy        []string // All labels are used if it contains "...".
+	GroupWait      time.Duration
+	GroupInterval  time.Duration
+	RepeatInterval time.Duration // The firing alerts are not repeated if 0.
+	Routes         []*Route
+
+	// id identifies the route in the groups, the path of the route in the tree.
+	id string
+}
+
+// Match returns the routes of the alert, nil if it doesn't match the route.
+func (r *Route) Match(alert *Alert) []*Route {
+	if !matchAll(r.Matchers, alert) {
+		return nil
+	}
+
+	var routes []*Route
+	for _, child := range r.Routes {
+		matches := child.Match(alert)
+		routes = append(routes, matches...)
+
+		if len(matches) > 0 && !child.Continue {
+			break
+		}
+	}
+
+	if len(routes) == 0 {
+		routes = []*Route{r}
+	}
+
+	return routes
+}
+
+// groupLabels returns the labels of the alert the route groups by.
+func (r *Route) groupLabels(alert *Alert) map[string]string {
+	names := r.GroupBy
+	for _, name := range r.GroupBy {
+		if name == "..." {
+			names = alert.LabelNames()
+			break
+		}
+	}
+
+	labels := make(map[string]string, len(names))
+	for _, name := range names {
+		labels[name] = alert.Label(name)
+	}
+
+	return labels
+}
+
+// ReceiverConfig is a named set of notification channels.
+type ReceiverConfig struct {
+	Name     string   `mapstructure:"name"`
+	Channels []string `mapstructure:"channels"`
+}
+
+// RouteConfig is the configuration of a route, durations are Go duration strings.
+type RouteConfig struct {
+	Receiver       string         `mapstructure:"receiver"`
+	Matchers       []string       `mapstructure:"matchers"`
+	Continue       bool           `mapstructure:"continue"`
+	GroupBy        []string       `mapstructure:"group_by"`
+	GroupWait      string         `mapstructure:"group_wait"`
+	GroupInterval  string         `mapstructure:"group_interval"`
+	RepeatInterval string         `mapstructure:"repeat_interval"`
+	Routes         []*RouteConfig `mapstructure:"routes"`
+}
+
+// InhibitRuleConfig is the configuration of an inhibition rule.
+type InhibitRuleConfig struct {
+	SourceMatchers []string `mapstructure:"source_matchers"`
+	TargetMatchers []string `mapstructure:"target_matchers"`
+	Equal          []string `mapstructure:"equal"`
+}
+
+// Config is the routing of the alerts, the "notifications" section of the configuration.
+type Config struct {
+	Receivers    []*ReceiverConfig    `mapstructure:"receivers"`
+	Route        *RouteConfig         `mapstructure:"route"`
+	InhibitRules []*InhibitRuleConfig `mapstructure:"inhibit_rules"`
+}
+
+// Build returns the routing tree. Without route, every alert is sent to all
+// channels as soon as possible, each check in its own group.
+func (c *RouteConfig) Build() (*Route, error) {
+	if c == nil {
+		return &Route{GroupBy: []string{"check_id"}, id: "/"}, nil
+	}
+
+	if c.Receiver == "" {
+		return nil, errors.New("the root route must have a receiver")
+	}
+
+	root := &Route{
+		GroupWait:      DefaultGroupWait,
+		GroupInterval:  DefaultGroupInterval,
+		RepeatInterval: DefaultRepeatInterval,
+	}
+
+	return c.build(root, "/")
+}
+
+// build returns the route, the unset settings are inherited from the parent.
+func (c *RouteConfig) build(parent *Route, id string) (*Route, error) {
+	matchers, err := ParseMatchers(c.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &Route{
+		Receiver:       c.Receiver,
+		Matchers:       matchers,
+		Continue:       c.Continue,
+		GroupBy:        c.GroupBy,
+		GroupWait:      parent.GroupWait,
+		GroupInterval:  parent.GroupInterval,
+		RepeatInterval: parent.RepeatInterval,
+		id:             id,
+	}
+
+	if route.Receiver == "" {
+		route.Receiver = parent.Receiver
+	}
+
+	if route.GroupBy == nil {
+		route.GroupBy = parent.GroupBy
+	}
+
+	durations := []struct {
+		field  string
+		value  string
+		target *time.Duration
+	}{
+		{"group_wait", c.GroupWait, &route.GroupWait},
+		{"group_interval", c.GroupInterval, &route.GroupInterval},
+		{"repeat_interval", c.RepeatInterval, &route.RepeatInterval},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		if *d.target, err = time.ParseDuration(d.value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", d.field, d.value, err)
+		}
+	}
+
+	for i, child := range c.Routes {
+		r, err := child.build(route, fmt.Sprintf("%s%d/", id, i))
+		if err != nil {
+			return nil, err
+		}
+
+		route.Routes = append(route.Routes, r)
+	}
+
+	return route, nil
+}
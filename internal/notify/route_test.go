@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testRoutes(t *testing.T) *Route {
+	t.Helper()
+
+	cfg := &RouteConfig{
+		Receiver:  "default",
+		GroupBy:   []string{"service"},
+		GroupWait: "10s",
+		Routes: []*RouteConfig{
+			{Receiver: "db", Matchers: []string{`team="db"`}, Continue: true},
+			{Receiver: "db-pager", Matchers: []string{`team="db"`, "status=critical"}, GroupWait: "0s"},
+			{Receiver: "web", Matchers: []string{`service=~"web|api"`}, Routes: []*RouteConfig{
+				{Receiver: "web-eu", Matchers: []string{"region=eu"}},
+			}},
+			{Receiver: "db-other", Matchers: []string{`team="db"`}},
+		},
+	}
+
+	route, err := cfg.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return route
+}
+
+func TestRouteMatch(t *testing.T) {
+	route := testRoutes(t)
+
+	tests := []struct {
+		name      string
+		alert     *Alert
+		receivers []string
+	}{
+		{
+			name:      "no child matches",
+			alert:     &Alert{ServiceName: "cache", Status: "critical"},
+			receivers: []string{"default"},
+		},
+		{
+			name:      "continue to the next matching route",
+			alert:     &Alert{ServiceName: "postgres", Status: "critical", Labels: map[string]string{"team": "db"}},
+			receivers: []string{"db", "db-pager"},
+		},
+		{
+			name:      "continue past a route which does not match",
+			alert:     &Alert{ServiceName: "postgres", Status: "warning", Labels: map[string]string{"team": "db"}},
+			receivers: []string{"db", "db-other"},
+		},
+		{
+			name:      "stop at the first matching route",
+			alert:     &Alert{ServiceName: "web", Status: "critical", Labels: map[string]string{"team": "db"}},
+			receivers: []string{"db", "db-pager"},
+		},
+		{
+			name:      "nested route",
+			alert:     &Alert{ServiceName: "api", Status: "critical", Labels: map[string]string{"region": "eu"}},
+			receivers: []string{"web-eu"},
+		},
+		{
+			name:      "parent of a nested route",
+			alert:     &Alert{ServiceName: "api", Status: "critical", Labels: map[string]string{"region": "us"}},
+			receivers: []string{"web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivers []string
+			for _, r := range route.Match(tt.alert) {
+				receivers = append(receivers, r.Receiver)
+			}
+
+			if !reflect.DeepEqual(receivers, tt.receivers) {
+				t.Errorf("receivers = %v, want %v", receivers, tt.receivers)
+			}
+		})
+	}
+}
+
+func TestRouteInheritance(t *testing.T) {
+	route := testRoutes(t)
+
+	db, pager, eu := route.Routes[0], route.Routes[1], route.Routes[2].Routes[0]
+	if db.GroupWait != 10*time.Second || db.GroupInterval != DefaultGroupInterval || db.RepeatInterval != DefaultRepeatInterval {
+		t.Errorf("db timings = %s/%s/%s, want the timings of the root", db.GroupWait, db.GroupInterval, db.RepeatInterval)
+	}
+
+	if pager.GroupWait != 0 {
+		t.Errorf("db-pager group_wait = %s, want 0s", pager.GroupWait)
+	}
+
+	if !reflect.DeepEqual(eu.GroupBy, []string{"service"}) || eu.id != "/2/0/" {
+		t.Errorf("web-eu group_by = %v, id = %q, want [service] and /2/0/", eu.GroupBy, eu.id)
+	}
+}
+
+func TestRouteBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *RouteConfig
+	}{
+		{"root without receiver", &RouteConfig{}},
+		{"invalid duration", &RouteConfig{Receiver: "ops", GroupInterval: "5 minutes"}},
+		{"invalid child matcher", &RouteConfig{Receiver: "ops", Routes: []*RouteConfig{{Matchers: []string{"team"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cfg.Build(); err == nil {
+				t.Error("Build() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestGroupLabels(t *testing.T) {
+	alert := &Alert{CheckID: "web-http", Node: "node-1", ServiceName: "web", Labels: map[string]string{"team": "ops"}}
+
+	tests := []struct {
+		groupBy []string
+		labels  map[string]string
+	}{
+		{[]string{"service"}, map[string]string{"service": "web"}},
+		{[]string{"team", "region"}, map[string]string{"team": "ops", "region": ""}},
+		{[]string{"..."}, map[string]string{"check_id": "web-http", "check_name": "", "node": "node-1", "service_id": "", "service": "web", "team": "ops"}},
+	}
+
+	for _, tt := range tests {
+		route := &Route{GroupBy: tt.groupBy}
+		if labels := route.groupLabels(alert); !reflect.DeepEqual(labels, tt.labels) {
+			t.Errorf("groupLabels(%v) = %v, want %v", tt.groupBy, labels, tt.labels)
+		}
+	}
+}
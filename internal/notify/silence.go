@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Silence mutes the alerts matching all its matchers between StartsAt and EndsAt.
+type Silence struct {
+	ID       string
+	Matchers []*Matcher
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// SilenceStore is the source of the silences.
+type SilenceStore interface {
+	Silences(ctx context.Context) ([]*Silence, error)
+}
+
+// Active reports whether the silence is in effect at the given time.
+func (s *Silence) Active(at time.Time) bool {
+	return !at.Before(s.StartsAt) && at.Before(s.EndsAt)
+}
+
+// Mutes reports whether the silence mutes the alert at the given time.
+func (s *Silence) Mutes(alert *Alert, at time.Time) bool {
+	return s.Active(at) && matchAll(s.Matchers, alert)
+}
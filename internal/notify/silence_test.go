@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSilenceMutes(t *testing.T) {
+	matchers, err := ParseMatchers([]string{"service=web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	silence := &Silence{ID: "deploy", Matchers: matchers, StartsAt: start, EndsAt: start.Add(time.Hour)}
+
+	web := &Alert{CheckID: "web-http", ServiceName: "web"}
+	api := &Alert{CheckID: "api-http", ServiceName: "api"}
+
+	tests := []struct {
+		name  string
+		alert *Alert
+		at    time.Time
+		mutes bool
+	}{
+		{"before the window", web, start.Add(-time.Second), false},
+		{"start of the window", web, start, true},
+		{"within the window", web, start.Add(30 * time.Minute), true},
+		{"end of the window", web, start.Add(time.Hour), false},
+		{"after the window", web, start.Add(2 * time.Hour), false},
+		{"alert not matching", api, start.Add(30 * time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if mutes := silence.Mutes(tt.alert, tt.at); mutes != tt.mutes {
+				t.Errorf("Mutes() = %v, want %v", mutes, tt.mutes)
+			}
+		})
+	}
+}
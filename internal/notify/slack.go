@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+// Slack posts the notification to a Slack-compatible incoming webhook,
+// with an attachment per alert.
+type Slack struct {
+	URL      string
+	Channel  string
+	Username string
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title,omitempty"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields"`
+	Ts     int64        `json:"ts"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (s *Slack) Send(ctx context.Context, notification *Notification) error {
+	message := &slackMessage{
+		Channel:  s.Channel,
+		Username: s.Username,
+		Text:     notification.Title(),
+	}
+
+	for _, alert := range notification.Alerts {
+		message.Attachments = append(message.Attachments, slackAlert(alert, len(notification.Alerts) > 1))
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, s.URL, body, nil)
+}
+
+// slackAlert returns the attachment of the alert, titled if the notification
+// has several alerts.
+func slackAlert(alert *Alert, titled bool) slackAttachment {
+	fields := []slackField{
+		{Title: "Status", Value: fmt.Sprintf("%s → %s", alert.Previous, alert.Status), Short: true},
+		{Title: "Check", Value: alert.CheckID, Short: true},
+	}
+
+	if alert.ServiceName != "" {
+		fields = append(fields, slackField{Title: "Service", Value: alert.ServiceName, Short: true})
+	}
+
+	if alert.Node != "" {
+		fields = append(fields, slackField{Title: "Node", Value: alert.Node, Short: true})
+	}
+
+	attachment := slackAttachment{
+		Color:  slackColor(alert.Status),
+		Text:   alert.Output,
+		Fields: fields,
+		Ts:     alert.Time.Unix(),
+	}
+
+	if titled {
+		attachment.Title = alert.Title()
+	}
+
+	return attachment
+}
+
+func slackColor(status string) string {
+	switch status {
+	case checker.HealthPassing:
+		return "good"
+	case checker.HealthWarning:
+		return "warning"
+	default:
+		return "danger"
+	}
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSlack(t *testing.T) {
+	message := &slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	slack := &Slack{URL: server.URL, Channel: "#ops", Username: "orbit"}
+	if err := slack.Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if message.Channel != "#ops" || message.Username != "orbit" {
+		t.Errorf("channel, username = %q, %q, want #ops, orbit", message.Channel, message.Username)
+	}
+
+	if want := "[CRITICAL] HTTP (web) is critical"; message.Text != want {
+		t.Errorf("text = %q, want %q", message.Text, want)
+	}
+
+	if len(message.Attachments) != 1 {
+		t.Fatalf("attachments = %d, want 1", len(message.Attachments))
+	}
+
+	attachment := message.Attachments[0]
+	if attachment.Color != "danger" || attachment.Text != "connection refused" || attachment.Title != "" {
+		t.Errorf("attachment = %+v", attachment)
+	}
+
+	if attachment.Fields[0].Value != "passing → critical" {
+		t.Errorf("status field = %q, want %q", attachment.Fields[0].Value, "passing → critical")
+	}
+}
+
+func TestSlackResolved(t *testing.T) {
+	message := &slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(message)
+	}))
+	defer server.Close()
+
+	n := testNotification()
+	resolved := *n.Alerts[0]
+	resolved.CheckID = "db"
+	resolved.Status, resolved.Previous = "passing", "critical"
+	n = NewNotification(n.Receiver, n.GroupKey, n.GroupLabels, []*Alert{n.Alerts[0], &resolved})
+
+	if err := (&Slack{URL: server.URL}).Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if want := "[FIRING:1] service=web"; message.Text != want {
+		t.Errorf("text = %q, want %q", message.Text, want)
+	}
+
+	if len(message.Attachments) != 2 || message.Attachments[1].Color != "good" || message.Attachments[1].Title == "" {
+		t.Errorf("attachments = %+v, want a titled good attachment for the recovery", message.Attachments)
+	}
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// SignatureHeader is the header of the HMAC-SHA256 signature of the webhook body.
+const SignatureHeader = "X-Orbit-Signature"
+
+// DefaultTimeout is how long a channel may take to accept a notification.
+const DefaultTimeout = 10 * time.Second
+
+var client = &http.Client{
+	Transport: cleanhttp.DefaultPooledTransport(),
+	Timeout:   DefaultTimeout,
+}
+
+// Webhook posts the notification as JSON to an HTTP endpoint. If a secret is set,
+// the body is signed and the signature sent in the X-Orbit-Signature header
+// as "sha256=<hex>". The timestamp header is part of the signed payload,
+// "<timestamp>.<body>", so that a captured request can't be replayed later.
+type Webhook struct {
+	URL     string
+	Secret  string
+	Headers map[string]string
+}
+
+func (w *Webhook) Send(ctx context.Context, notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(w.Headers)+2)
+	for key, value := range w.Headers {
+		headers[key] = value
+	}
+
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-Orbit-Timestamp"] = timestamp
+		headers[SignatureHeader] = "sha256=" + Sign(w.Secret, timestamp, body)
+	}
+
+	return postJSON(ctx, w.URL, body, headers)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and body of a webhook.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON posts the body and fails unless the response status is 2xx.
+func postJSON(ctx context.Context, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Orbit")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read the response to reuse the connection, and to report errors.
+	output, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP POST %s: %s %s", url, resp.Status, output)
+	}
+
+	return nil
+}
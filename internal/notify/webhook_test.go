@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	var header http.Header
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{URL: server.URL, Secret: "s3cret", Headers: map[string]string{"X-Team": "ops"}}
+	if err := webhook.Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	timestamp := header.Get("X-Orbit-Timestamp")
+	if timestamp == "" {
+		t.Fatal("X-Orbit-Timestamp header is missing")
+	}
+
+	if got, want := header.Get(SignatureHeader), "sha256="+Sign("s3cret", timestamp, body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	// The signature covers the timestamp, so that it can't be replayed with another one.
+	if header.Get(SignatureHeader) == "sha256="+Sign("s3cret", "0", body) {
+		t.Error("signature doesn't depend on the timestamp")
+	}
+
+	if got := header.Get("X-Team"); got != "ops" {
+		t.Errorf("X-Team header = %q, want %q", got, "ops")
+	}
+
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	notification := &Notification{}
+	if err := json.Unmarshal(body, notification); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+
+	if notification.Status != StatusFiring || len(notification.Alerts) != 1 || notification.Alerts[0].CheckID != "web" {
+		t.Errorf("body = %s, want the firing notification of web", body)
+	}
+}
+
+func TestWebhookUnsigned(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	if err := (&Webhook{URL: server.URL}).Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if header.Get(SignatureHeader) != "" || header.Get("X-Orbit-Timestamp") != "" {
+		t.Errorf("headers = %v, want no signature without a secret", header)
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := (&Webhook{URL: server.URL}).Send(context.Background(), testNotification())
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "bad token") {
+		t.Errorf("Send() error = %v, want the status and the response", err)
+	}
+}
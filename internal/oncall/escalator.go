@@ -0,0 +1,326 @@
+package oncall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/betterde/orbit/internal/checker"
+	"github.com/betterde/orbit/internal/incident"
+	"github.com/betterde/orbit/internal/notify"
+)
+
+const (
+	// DefaultInterval is how often the unacknowledged incidents are escalated.
+	DefaultInterval = 30 * time.Second
+
+	// ReloadInterval is how often the schedules and policies are loaded from the
+	// store, so that the changes made through the other servers of a cluster apply.
+	ReloadInterval = time.Minute
+)
+
+// User is a responder, a user of a team.
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Store is the source of the schedules, policies and users.
+type Store interface {
+	Schedules(ctx context.Context) ([]*Schedule, error)
+	Policies(ctx context.Context) ([]*Policy, error)
+	Users(ctx context.Context, ids []string) ([]*User, error)
+}
+
+// Incidents are the incidents escalated, usually the incident.Manager.
+type Incidents interface {
+	Unacknowledged(ctx context.Context) ([]*incident.Incident, error)
+	Escalate(ctx context.Context, id string, step int, message string) error
+}
+
+// Notifier delivers the escalations, usually the notify.Dispatcher.
+type Notifier interface {
+	Channel(id string) (*notify.Channel, bool)
+	Deliver(ctx context.Context, channel *notify.Channel, notification *notify.Notification) error
+}
+
+// Escalator notifies the levels of the escalation policy of the team of
+// the incidents, until they are acknowledged or resolved.
+type Escalator struct {
+	store     Store
+	incidents Incidents
+	notifier  Notifier
+	logger    *zap.SugaredLogger
+	interval  time.Duration
+
+	// Leader reports whether the server escalates the incidents, so that only the
+	// leader of a cluster does. The incidents are always escalated if it is nil.
+	Leader func() bool
+
+	lock      sync.RWMutex
+	schedules []*Schedule
+	policies  []*Policy
+	reload    chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewEscalator(store Store, incidents Incidents, notifier Notifier, logger *zap.SugaredLogger) *Escalator {
+	return &Escalator{
+		store:     store,
+		incidents: incidents,
+		notifier:  notifier,
+		logger:    logger,
+		interval:  DefaultInterval,
+		reload:    make(chan struct{}, 1),
+	}
+}
+
+// Reload asks the escalator to load the schedules and policies from the store again.
+func (e *Escalator) Reload() {
+	select {
+	case e.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Run escalates the incidents until the context is done, then waits for the
+// pending deliveries. The schedules and policies are loaded again every
+// ReloadInterval, and as soon as the server becomes the leader.
+func (e *Escalator) Run(ctx context.Context) {
+	e.load(ctx)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	reload := time.NewTicker(ReloadInterval)
+	defer reload.Stop()
+
+	leader := false
+	for {
+		select {
+		case <-e.reload:
+			e.load(ctx)
+		case <-reload.C:
+			e.load(ctx)
+		case now := <-ticker.C:
+			if e.Leader != nil && !e.Leader() {
+				leader = false
+				continue
+			}
+
+			// The leader escalates with the policies changed while another server was.
+			if !leader && e.Leader != nil {
+				e.load(ctx)
+			}
+			leader = true
+
+			e.Escalate(ctx, now)
+		case <-ctx.Done():
+			e.wg.Wait()
+			return
+		}
+	}
+}
+
+// OnCall returns the shifts of the schedules of the team, or of all teams
+// if teamID is empty, at the given time.
+func (e *Escalator) OnCall(ctx context.Context, teamID string, at time.Time) ([]*Shift, error) {
+	e.lock.RLock()
+	var shifts []*Shift
+	for _, schedule := range e.schedules {
+		if teamID == "" || schedule.TeamID == teamID {
+			shifts = append(shifts, schedule.OnCall(at))
+		}
+	}
+	e.lock.RUnlock()
+
+	ids := make([]string, 0, len(shifts))
+	for _, shift := range shifts {
+		ids = append(ids, shift.UserID)
+	}
+
+	users, err := e.users(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shift := range shifts {
+		shift.User = users[shift.UserID]
+	}
+
+	return shifts, nil
+}
+
+// Escalate notifies the levels due of the unacknowledged incidents.
+func (e *Escalator) Escalate(ctx context.Context, now time.Time) {
+	incidents, err := e.incidents.Unacknowledged(ctx)
+	if err != nil {
+		e.logger.Errorw("Failed to load the unacknowledged incidents", "error", err)
+		return
+	}
+
+	for _, inc := range incidents {
+		policy := e.policy(inc.TeamID)
+		if policy == nil {
+			continue
+		}
+
+		step := policy.Steps(now.Sub(inc.OpenedAt))
+		if step <= inc.Escalations {
+			continue
+		}
+
+		if err = e.escalate(ctx, inc, policy, step, now); err != nil && !errors.Is(err, incident.ErrConflict) && !errors.Is(err, incident.ErrResolved) {
+			e.logger.Errorw("Failed to escalate the incident", "incident", inc.ID, "step", step, "error", err)
+		}
+	}
+}
+
+// escalate records the step in the incident, and notifies the responders of its
+// level. The levels skipped, e.g. while the server was down, aren't notified.
+func (e *Escalator) escalate(ctx context.Context, inc *incident.Incident, policy *Policy, step int, now time.Time) error {
+	level := policy.Level(step)
+	number := (step-1)%len(policy.Levels) + 1
+
+	ids := slices.Clone(level.UserIDs)
+	e.lock.RLock()
+	for _, schedule := range e.schedules {
+		if slices.Contains(level.ScheduleIDs, schedule.ID) {
+			ids = append(ids, schedule.OnCall(now).UserID)
+		}
+	}
+	e.lock.RUnlock()
+
+	users, err := e.users(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	var names, emails []string
+	for _, id := range ids {
+		user, ok := users[id]
+		if !ok {
+			names = append(names, id)
+			continue
+		}
+
+		names = append(names, user.Name)
+		if user.Email != "" && !slices.Contains(emails, user.Email) {
+			emails = append(emails, user.Email)
+		}
+	}
+
+	message := fmt.Sprintf("Escalated to level %d of %s: %s", number, policy.Name, strings.Join(names, ", "))
+	if err = e.incidents.Escalate(ctx, inc.ID, step, message); err != nil {
+		return err
+	}
+
+	e.logger.Infow("Incident escalated", "incident", inc.ID, "policy", policy.Name, "level", number, "responders", names)
+
+	alert := &notify.Alert{
+		CheckName:   inc.Title,
+		Node:        inc.Node,
+		ServiceID:   inc.ServiceID,
+		ServiceName: inc.ServiceName,
+		Status:      checker.HealthCritical,
+		Previous:    checker.HealthCritical,
+		Output:      fmt.Sprintf("Incident unacknowledged for %s. %s.", now.Sub(inc.OpenedAt).Round(time.Second), message),
+		Time:        now,
+	}
+
+	if len(inc.CheckIDs) > 0 {
+		alert.CheckID = inc.CheckIDs[0]
+	}
+
+	n := notify.NewNotification(policy.Name, "incident:"+inc.ID, map[string]string{"incident": inc.ID}, []*notify.Alert{alert})
+
+	for _, id := range level.Channels {
+		channel, ok := e.notifier.Channel(id)
+		if !ok {
+			e.logger.Warnw("Unknown escalation channel", "policy", policy.Name, "channel", id)
+			continue
+		}
+
+		// The emails are sent to the responders rather than the recipients of the channel.
+		if email, ok := channel.Sender.(*notify.Email); ok && len(emails) > 0 {
+			sender := *email
+			sender.To = emails
+
+			c := *channel
+			c.Sender = &sender
+			channel = &c
+		}
+
+		e.wg.Add(1)
+		go func(channel *notify.Channel) {
+			defer e.wg.Done()
+			_ = e.notifier.Deliver(ctx, channel, n)
+		}(channel)
+	}
+
+	return nil
+}
+
+func (e *Escalator) policy(teamID string) *Policy {
+	if teamID == "" {
+		return nil
+	}
+
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	for _, policy := range e.policies {
+		if policy.TeamID == teamID {
+			return policy
+		}
+	}
+
+	return nil
+}
+
+// users returns the users with the given IDs, keyed by ID.
+func (e *Escalator) users(ctx context.Context, ids []string) (map[string]*User, error) {
+	users := make(map[string]*User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	loaded, err := e.store.Users(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range loaded {
+		users[user.ID] = user
+	}
+
+	return users, nil
+}
+
+func (e *Escalator) load(ctx context.Context) {
+	schedules, err := e.store.Schedules(ctx)
+	if err != nil {
+		e.logger.Errorw("Failed to load the on-call schedules", "error", err)
+		return
+	}
+
+	policies, err := e.store.Policies(ctx)
+	if err != nil {
+		e.logger.Errorw("Failed to load the escalation policies", "error", err)
+		return
+	}
+
+	e.lock.Lock()
+	e.schedules = schedules
+	e.policies = policies
+	e.lock.Unlock()
+
+	e.logger.Debugw("On-call schedules and escalation policies loaded.", "schedules", len(schedules), "policies", len(policies))
+}
@@ -0,0 +1,76 @@
+package oncall
+
+import (
+	"errors"
+	"time"
+)
+
+// Policy is the escalation policy of a team. The first level is notified when an
+// incident of the team opens, each level escalates to the next one if the incident
+// is still unacknowledged after its EscalateAfter. After the last level the policy
+// starts over with the first, Repeat times.
+type Policy struct {
+	ID     string
+	TeamID string
+	Name   string
+	Levels []*Level
+	Repeat int
+}
+
+// Level is a step of an escalation policy, notifying its users and the users
+// on call in its schedules through its notification channels.
+type Level struct {
+	EscalateAfter time.Duration
+	UserIDs       []string
+	ScheduleIDs   []string
+	Channels      []string
+}
+
+// Validate checks the policy.
+func (p *Policy) Validate() error {
+	if len(p.Levels) == 0 {
+		return errors.New("at least one level is required")
+	}
+
+	if p.Repeat < 0 {
+		return errors.New("repeat must not be negative")
+	}
+
+	for _, level := range p.Levels {
+		if len(level.UserIDs) == 0 && len(level.ScheduleIDs) == 0 {
+			return errors.New("a level needs at least one user or schedule")
+		}
+
+		if len(level.Channels) == 0 {
+			return errors.New("a level needs at least one notification channel")
+		}
+
+		if level.EscalateAfter < time.Minute {
+			return errors.New("a level must escalate after one minute or more")
+		}
+	}
+
+	return nil
+}
+
+// Steps returns how many steps of the policy are due once an incident has been
+// unacknowledged for the given duration, the first step is due immediately.
+func (p *Policy) Steps(unacknowledged time.Duration) int {
+	total := len(p.Levels) * (p.Repeat + 1)
+
+	var due time.Duration
+	for step := 1; step <= total; step++ {
+		if due > unacknowledged {
+			return step - 1
+		}
+
+		due += p.Level(step).EscalateAfter
+	}
+
+	return total
+}
+
+// Level returns the level notified at the step, starting at 1.
+func (p *Policy) Level(step int) *Level {
+	return p.Levels[(step-1)%len(p.Levels)]
+}
@@ -0,0 +1,76 @@
+package oncall
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicySteps(t *testing.T) {
+	levels := []*Level{{EscalateAfter: 5 * time.Minute}, {EscalateAfter: 10 * time.Minute}}
+
+	tests := []struct {
+		name           string
+		repeat         int
+		unacknowledged time.Duration
+		steps          int
+	}{
+		{"first level immediately", 0, 0, 1},
+		{"before the first escalation", 0, 5*time.Minute - time.Second, 1},
+		{"at the first escalation", 0, 5 * time.Minute, 2},
+		{"after the last level", 0, 15 * time.Minute, 2},
+		{"long after the last level", 0, 24 * time.Hour, 2},
+		{"first repeat", 1, 15 * time.Minute, 3},
+		{"second level of the repeat", 1, 20 * time.Minute, 4},
+		{"after the repeats", 1, 24 * time.Hour, 4},
+		{"two repeats", 2, 45 * time.Minute, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{Levels: levels, Repeat: tt.repeat}
+			if steps := p.Steps(tt.unacknowledged); steps != tt.steps {
+				t.Errorf("Steps(%s) = %d, want %d", tt.unacknowledged, steps, tt.steps)
+			}
+		})
+	}
+}
+
+func TestPolicyLevel(t *testing.T) {
+	first, second := &Level{EscalateAfter: 5 * time.Minute}, &Level{EscalateAfter: 10 * time.Minute}
+	p := &Policy{Levels: []*Level{first, second}, Repeat: 2}
+
+	want := []*Level{first, second, first, second, first, second}
+	for i, level := range want {
+		if p.Level(i+1) != level {
+			t.Errorf("Level(%d) isn't level %d", i+1, i%2+1)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := func() *Level {
+		return &Level{EscalateAfter: time.Minute, UserIDs: []string{"a"}, Channels: []string{"email"}}
+	}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		valid  bool
+	}{
+		{"valid", &Policy{Levels: []*Level{valid()}, Repeat: 1}, true},
+		{"without levels", &Policy{}, false},
+		{"negative repeat", &Policy{Levels: []*Level{valid()}, Repeat: -1}, false},
+		{"level without users", &Policy{Levels: []*Level{{EscalateAfter: time.Minute, Channels: []string{"email"}}}}, false},
+		{"level with a schedule", &Policy{Levels: []*Level{{EscalateAfter: time.Minute, ScheduleIDs: []string{"s"}, Channels: []string{"email"}}}}, true},
+		{"level without channels", &Policy{Levels: []*Level{{EscalateAfter: time.Minute, UserIDs: []string{"a"}}}}, false},
+		{"escalating too fast", &Policy{Levels: []*Level{{EscalateAfter: 30 * time.Second, UserIDs: []string{"a"}, Channels: []string{"email"}}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package oncall
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The rotations of a schedule.
+const (
+	RotationDaily  = "daily"
+	RotationWeekly = "weekly"
+)
+
+// Schedule is the on-call rotation of a team. The users take turns in order,
+// the shifts are handed off every day, or every week on HandoffDay, at
+// HandoffTime in Location. The rotation starts with the first user on the
+// shift of Start. An override puts another user on call for a while.
+type Schedule struct {
+	ID          string
+	TeamID      string
+	Name        string
+	Rotation    string
+	Users       []string
+	Start       time.Time
+	HandoffTime time.Duration // Since midnight, e.g. 9h.
+	HandoffDay  time.Weekday
+	Location    *time.Location
+	Overrides   []*Override
+}
+
+// Override puts the user on call from StartsAt to EndsAt.
+type Override struct {
+	UserID   string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Shift is the on-call shift of a user.
+type Shift struct {
+	ScheduleID string    `json:"schedule_id"`
+	Schedule   string    `json:"schedule"`
+	TeamID     string    `json:"team_id"`
+	UserID     string    `json:"user_id"`
+	User       *User     `json:"user,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Override   bool      `json:"override"`
+}
+
+// Validate checks the schedule, it must be called before OnCall.
+func (s *Schedule) Validate() error {
+	if s.Location == nil {
+		s.Location = time.UTC
+	}
+
+	if len(s.Users) == 0 {
+		return errors.New("at least one user is required")
+	}
+
+	if s.Rotation != RotationDaily && s.Rotation != RotationWeekly {
+		return fmt.Errorf("invalid rotation %q, expected %s or %s", s.Rotation, RotationDaily, RotationWeekly)
+	}
+
+	if s.HandoffTime < 0 || s.HandoffTime >= 24*time.Hour {
+		return fmt.Errorf("invalid handoff time %s", s.HandoffTime)
+	}
+
+	for _, o := range s.Overrides {
+		if o.UserID == "" || !o.StartsAt.Before(o.EndsAt) {
+			return errors.New("an override needs a user and to start before it ends")
+		}
+	}
+
+	return nil
+}
+
+// OnCall returns the shift of the user on call at the given time.
+func (s *Schedule) OnCall(at time.Time) *Shift {
+	shift := &Shift{ScheduleID: s.ID, Schedule: s.Name, TeamID: s.TeamID}
+
+	for _, o := range s.Overrides {
+		if !at.Before(o.StartsAt) && at.Before(o.EndsAt) {
+			shift.UserID, shift.StartsAt, shift.EndsAt, shift.Override = o.UserID, o.StartsAt, o.EndsAt, true
+			return shift
+		}
+	}
+
+	days := 1
+	if s.Rotation == RotationWeekly {
+		days = 7
+	}
+
+	// The shifts are counted in calendar days, so that the handoff
+	// happens at the same local time across DST changes.
+	first := s.handoff(s.Start)
+	start := s.handoff(at)
+	n := floorDiv(civilDays(first, start), days)
+
+	shift.UserID = s.Users[mod(n, len(s.Users))]
+	shift.StartsAt = start
+	shift.EndsAt = start.AddDate(0, 0, days)
+
+	return shift
+}
+
+// handoff returns the last handoff at or before the given time.
+func (s *Schedule) handoff(at time.Time) time.Time {
+	local := at.In(s.Location)
+
+	h := s.at(local.Year(), local.Month(), local.Day())
+	if h.After(local) {
+		h = s.at(h.Year(), h.Month(), h.Day()-1)
+	}
+
+	if s.Rotation == RotationWeekly {
+		for h.Weekday() != s.HandoffDay {
+			h = s.at(h.Year(), h.Month(), h.Day()-1)
+		}
+	}
+
+	return h
+}
+
+// at returns the handoff time of the day.
+func (s *Schedule) at(year int, month time.Month, day int) time.Time {
+	hour := int(s.HandoffTime / time.Hour)
+	minute := int(s.HandoffTime % time.Hour / time.Minute)
+
+	return time.Date(year, month, day, hour, minute, 0, 0, s.Location)
+}
+
+// civilDays returns the number of calendar days from a to b.
+func civilDays(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+
+	return a / b
+}
+
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
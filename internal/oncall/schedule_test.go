@@ -0,0 +1,146 @@
+package oncall
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %s", name, err)
+	}
+
+	return location
+}
+
+func TestScheduleOnCall(t *testing.T) {
+	paris := mustLoad(t, "Europe/Paris")
+	// The times are in 2024, month 0 is December 2023.
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	local := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, paris)
+	}
+
+	daily := &Schedule{Rotation: RotationDaily, Users: []string{"a", "b", "c"}, Start: utc(1, 1, 9, 0), HandoffTime: 9 * time.Hour}
+
+	// Monday handoffs, the rotation starts on the Monday before Start.
+	weekly := &Schedule{Rotation: RotationWeekly, Users: []string{"a", "b"}, Start: utc(1, 3, 12, 0), HandoffTime: 9 * time.Hour, HandoffDay: time.Monday}
+
+	// Europe/Paris springs forward on March 31st and falls back on October 27th.
+	dst := &Schedule{Rotation: RotationDaily, Users: []string{"a", "b"}, Start: local(3, 25, 9, 0), HandoffTime: 9 * time.Hour, Location: paris}
+
+	overridden := &Schedule{
+		Rotation:    RotationDaily,
+		Users:       []string{"a", "b", "c"},
+		Start:       utc(1, 1, 9, 0),
+		HandoffTime: 9 * time.Hour,
+		Overrides:   []*Override{{UserID: "z", StartsAt: utc(1, 2, 12, 0), EndsAt: utc(1, 2, 14, 0)}},
+	}
+
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		at       time.Time
+		user     string
+		starts   time.Time
+		ends     time.Time
+		override bool
+	}{
+		{"first shift", daily, utc(1, 1, 10, 0), "a", utc(1, 1, 9, 0), utc(1, 2, 9, 0), false},
+		{"just before the handoff", daily, utc(1, 2, 8, 59), "a", utc(1, 1, 9, 0), utc(1, 2, 9, 0), false},
+		{"at the handoff", daily, utc(1, 2, 9, 0), "b", utc(1, 2, 9, 0), utc(1, 3, 9, 0), false},
+		{"rotation wraps around", daily, utc(1, 4, 9, 0), "a", utc(1, 4, 9, 0), utc(1, 5, 9, 0), false},
+		{"the day before start", daily, utc(0, 31, 10, 0), "c", utc(0, 31, 9, 0), utc(1, 1, 9, 0), false},
+		{"two days before start", daily, utc(0, 31, 8, 0), "b", utc(0, 30, 9, 0), utc(0, 31, 9, 0), false},
+		{"three days before start", daily, utc(0, 29, 9, 0), "a", utc(0, 29, 9, 0), utc(0, 30, 9, 0), false},
+
+		{"weekly first shift", weekly, utc(1, 1, 9, 0), "a", utc(1, 1, 9, 0), utc(1, 8, 9, 0), false},
+		{"weekly on sunday", weekly, utc(1, 7, 23, 0), "a", utc(1, 1, 9, 0), utc(1, 8, 9, 0), false},
+		{"weekly before the handoff", weekly, utc(1, 8, 8, 59), "a", utc(1, 1, 9, 0), utc(1, 8, 9, 0), false},
+		{"weekly at the handoff", weekly, utc(1, 8, 9, 0), "b", utc(1, 8, 9, 0), utc(1, 15, 9, 0), false},
+		{"weekly before start", weekly, utc(0, 31, 9, 0), "b", utc(0, 25, 9, 0), utc(1, 1, 9, 0), false},
+
+		{"shift shortened by DST", dst, local(3, 30, 12, 0), "b", local(3, 30, 9, 0), local(3, 31, 9, 0), false},
+		{"before the handoff after DST", dst, local(3, 31, 8, 59), "b", local(3, 30, 9, 0), local(3, 31, 9, 0), false},
+		{"handoff at the local time after DST", dst, local(3, 31, 9, 0), "a", local(3, 31, 9, 0), local(4, 1, 9, 0), false},
+		{"handoff at the local time after the fall back", dst, local(10, 27, 9, 0), "a", local(10, 27, 9, 0), local(10, 28, 9, 0), false},
+		{"handoff in UTC", dst, utc(3, 31, 7, 0), "a", local(3, 31, 9, 0), local(4, 1, 9, 0), false},
+
+		{"override", overridden, utc(1, 2, 13, 0), "z", utc(1, 2, 12, 0), utc(1, 2, 14, 0), true},
+		{"end of the override", overridden, utc(1, 2, 14, 0), "b", utc(1, 2, 9, 0), utc(1, 3, 9, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			shift := tt.schedule.OnCall(tt.at)
+			if shift.UserID != tt.user || shift.Override != tt.override {
+				t.Errorf("OnCall() = %s (override %v), want %s (override %v)", shift.UserID, shift.Override, tt.user, tt.override)
+			}
+
+			if !shift.StartsAt.Equal(tt.starts) || !shift.EndsAt.Equal(tt.ends) {
+				t.Errorf("shift from %s to %s, want from %s to %s", shift.StartsAt, shift.EndsAt, tt.starts, tt.ends)
+			}
+		})
+	}
+}
+
+func TestScheduleDSTShiftLength(t *testing.T) {
+	paris := mustLoad(t, "Europe/Paris")
+	s := &Schedule{Rotation: RotationDaily, Users: []string{"a"}, Start: time.Date(2024, 1, 1, 9, 0, 0, 0, paris), HandoffTime: 9 * time.Hour, Location: paris}
+
+	spring := s.OnCall(time.Date(2024, 3, 30, 12, 0, 0, 0, paris))
+	if d := spring.EndsAt.Sub(spring.StartsAt); d != 23*time.Hour {
+		t.Errorf("the shift across the spring forward lasts %s, want 23h", d)
+	}
+
+	fall := s.OnCall(time.Date(2024, 10, 26, 12, 0, 0, 0, paris))
+	if d := fall.EndsAt.Sub(fall.StartsAt); d != 25*time.Hour {
+		t.Errorf("the shift across the fall back lasts %s, want 25h", d)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *Schedule
+	}{
+		{"without users", &Schedule{Rotation: RotationDaily}},
+		{"unknown rotation", &Schedule{Rotation: "monthly", Users: []string{"a"}}},
+		{"handoff time past midnight", &Schedule{Rotation: RotationDaily, Users: []string{"a"}, HandoffTime: 24 * time.Hour}},
+		{"override ending before it starts", &Schedule{Rotation: RotationDaily, Users: []string{"a"}, Overrides: []*Override{{UserID: "z", StartsAt: time.Unix(10, 0), EndsAt: time.Unix(10, 0)}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); err == nil {
+				t.Error("Validate() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct{ a, b, want int }{
+		{0, 7, 0},
+		{6, 7, 0},
+		{7, 7, 1},
+		{-1, 7, -1},
+		{-7, 7, -1},
+		{-8, 7, -2},
+	}
+
+	for _, tt := range tests {
+		if got := floorDiv(tt.a, tt.b); got != tt.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
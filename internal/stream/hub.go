@@ -0,0 +1,239 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/betterde/orbit/internal/checker"
+)
+
+const (
+	// EventResult is published for every result of a check.
+	EventResult = "result"
+
+	// EventTransition is published when the status of a check changes.
+	// Transitions are suppressed while the check is flapping.
+	EventTransition = "transition"
+
+	// EventFlapping is published when a check starts or stops flapping.
+	EventFlapping = "flapping"
+
+	// subscriptionBuffer is the number of events a slow subscriber may lag behind
+	// before events are dropped.
+	subscriptionBuffer = 64
+)
+
+// Event is a change of a check pushed to the subscribers.
+type Event struct {
+	Type        string    `json:"type"`
+	CheckID     string    `json:"check_id"`
+	Node        string    `json:"node"`
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	ServiceTags []string  `json:"service_tags"`
+	Status      string    `json:"status"`
+	Flapping    bool      `json:"flapping"`
+	Previous    string    `json:"previous,omitempty"`
+	Output      string    `json:"output"`
+	Latency     float64   `json:"latency"` // In milliseconds.
+	Timestamp   time.Time `json:"timestamp"`
+
+	// Only set for transitions, how long the check stayed in the previous status in seconds.
+	PreviousDuration float64 `json:"previous_duration,omitempty"`
+}
+
+// Filter selects the events of a subscription. Empty fields match any value,
+// multiple values of a field are separated by commas.
+type Filter struct {
+	CheckID string `query:"check_id"`
+	Service string `query:"service"`
+	Tag     string `query:"tag"`
+	Status  string `query:"status"`
+	Type    string `query:"type"`
+}
+
+// Match reports whether the event is selected by the filter.
+func (f *Filter) Match(event *Event) bool {
+	if !matchAny(f.CheckID, event.CheckID) || !matchAny(f.Service, event.ServiceName) ||
+		!matchAny(f.Status, event.Status) || !matchAny(f.Type, event.Type) {
+		return false
+	}
+
+	if f.Tag == "" {
+		return true
+	}
+
+	for _, tag := range event.ServiceTags {
+		if matchAny(f.Tag, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAny(values, value string) bool {
+	if values == "" {
+		return true
+	}
+
+	for _, v := range strings.Split(values, ",") {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Subscription receives the events matching its filter.
+type Subscription struct {
+	Events chan *Event
+	filter Filter
+}
+
+// Hub fans out the check events to the subscribers.
+type Hub struct {
+	lock        sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to the events matching the filter.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	sub := &Subscription{
+		Events: make(chan *Event, subscriptionBuffer),
+		filter: filter,
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+// Unsubscribe stops the delivery of events to the subscription.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.subscribers, sub)
+}
+
+// Publish delivers the event to the matching subscribers. Events are
+// dropped for the subscribers which can't keep up.
+func (h *Hub) Publish(event *Event) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case sub.Events <- event:
+		default:
+		}
+	}
+}
+
+// Forward publishes the transitions of the checks until the context is done.
+// Transitions of flapping and removed checks are suppressed.
+func (h *Hub) Forward(ctx context.Context, events *checker.Events, state *checker.State) {
+	transitions := events.Subscribe(0)
+	defer events.Unsubscribe(transitions)
+
+	for {
+		select {
+		case t := <-transitions:
+			if t.Flapping || t.Removed {
+				continue
+			}
+
+			event := &Event{
+				Type:             EventTransition,
+				CheckID:          t.CheckID,
+				Status:           t.Status,
+				Previous:         t.Previous,
+				Output:           t.Output,
+				Timestamp:        t.Time,
+				PreviousDuration: t.PreviousDuration.Seconds(),
+			}
+
+			if check, ok := state.Check(t.CheckID); ok {
+				event.Node = check.Node
+				event.ServiceID = check.ServiceID
+				event.ServiceName = check.ServiceName
+				event.ServiceTags = check.ServiceTags
+			}
+
+			h.Publish(event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Notifier returns a CheckNotifier which publishes the results of the check,
+// and when it starts or stops flapping.
+func (h *Hub) Notifier(check *checker.HealthCheck) checker.CheckNotifier {
+	return &hubNotifier{hub: h, check: check, status: check.Status}
+}
+
+type hubNotifier struct {
+	hub      *Hub
+	check    *checker.HealthCheck
+	lock     sync.Mutex
+	status   string
+	flapping bool
+}
+
+func (n *hubNotifier) UpdateCheck(status, output string) {
+	n.UpdateResult(&checker.Result{Status: status, Output: output})
+}
+
+func (n *hubNotifier) UpdateResult(result *checker.Result) {
+	n.lock.Lock()
+	n.status = result.Status
+	n.lock.Unlock()
+
+	event := n.event(EventResult, result.Status)
+	event.Output = result.Output
+	event.Latency = float64(result.Latency) / float64(time.Millisecond)
+	n.hub.Publish(event)
+}
+
+func (n *hubNotifier) UpdateFlapping(flapping bool) {
+	n.lock.Lock()
+	n.flapping = flapping
+	status := n.status
+	n.lock.Unlock()
+
+	n.hub.Publish(n.event(EventFlapping, status))
+}
+
+func (n *hubNotifier) event(eventType, status string) *Event {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return &Event{
+		Type:        eventType,
+		CheckID:     n.check.CheckID,
+		Node:        n.check.Node,
+		ServiceID:   n.check.ServiceID,
+		ServiceName: n.check.ServiceName,
+		ServiceTags: n.check.ServiceTags,
+		Status:      status,
+		Flapping:    n.flapping,
+		Timestamp:   time.Now(),
+	}
+}